@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func init() {
+	rootCmd.AddCommand(addCmd, removeCmd)
+}
+
+// scannedDirectory returns the configured directory path lies in, or ""
+func scannedDirectory(cfg *config.Config, path string) string {
+	for _, dir := range cfg.Directories {
+		if config.IsWithin(path, dir) {
+			return filepath.Clean(dir)
+		}
+	}
+	return ""
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <path>...",
+	Short: "Add repositories to the repository list",
+	Long: `Add repositories to the repository list by hand, e.g. one-off checkouts
+outside the scanned directories. They are updated like the scanned ones and
+kept by every scan until they are removed with 'gogitup remove'.
+
+Repositories that are already in the list are marked as added by hand, so scans
+keep them as well.`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		patterns, err := store.Ignored()
+		if err != nil {
+			return err
+		}
+
+		return store.Update(func(repos []git.Repository) ([]git.Repository, error) {
+			for _, arg := range args {
+				path, err := filepath.Abs(arg)
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve path %s: %w", arg, err)
+				}
+				if git.IsIgnored(path, patterns) {
+					return nil, fmt.Errorf("repository %s is ignored, see 'gogitup ignore'", path)
+				}
+
+				if repo, err := findRepository(repos, path); err == nil {
+					repo.Manual = true
+					fmt.Printf("%s is already in the repository list, scans now keep it\n", path)
+					continue
+				}
+
+				repo, err := git.OpenRepository(path)
+				if err != nil {
+					return nil, err
+				}
+				repo.Manual = true
+				repos = append(repos, *repo)
+				fmt.Printf("Added %s\n", path)
+			}
+			return repos, nil
+		})
+	},
+}
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <path>...",
+	Short: "Remove repositories from the repository list",
+	Long: `Remove repositories from the repository list, so they are no longer updated.
+The repositories themselves are left alone.
+
+A repository in one of the scanned directories is added again by the next
+scan; use 'gogitup ignore' to keep it out for good.`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		// The config is only needed for the hint about the scanned directories
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+
+		// Entries that cannot be opened any more can be removed as well
+		var removed []string
+		err = store.Update(func(repos []git.Repository) ([]git.Repository, error) {
+			for _, arg := range args {
+				repo, err := findRepository(repos, arg)
+				if err != nil {
+					return nil, err
+				}
+				removed = append(removed, repo.Path)
+			}
+			return slices.DeleteFunc(repos, func(repo git.Repository) bool {
+				return slices.Contains(removed, repo.Path)
+			}), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, path := range removed {
+			fmt.Printf("Removed %s\n", path)
+			if dir := scannedDirectory(cfg, path); dir != "" {
+				fmt.Printf("  it is in the scanned directory %s, run 'gogitup ignore %s' to keep scans from adding it again\n", dir, path)
+			}
+		}
+		return nil
+	},
+}
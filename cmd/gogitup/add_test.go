@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+)
+
+func TestAddRemoveIgnore(t *testing.T) {
+	tmpDir := t.TempDir()
+	scanned := filepath.Join(tmpDir, "src")
+	var paths []string
+	for _, path := range []string{"src/app", "src/legacy-archive", "elsewhere/tool"} {
+		path = filepath.Join(tmpDir, path)
+		_, err := git.PlainInit(path, false)
+		require.NoError(t, err)
+		paths = append(paths, path)
+	}
+	app, archive, tool := paths[0], paths[1], paths[2]
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+scanned+"\n"), 0644))
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	useFiles(t, configFile, reposFile)
+	t.Setenv("GOGITUP_PROFILE", "")
+	defer func() { unignore = false }()
+
+	listed := func() []string {
+		t.Helper()
+		repos, err := gitutil.NewStore(reposFile).Load()
+		require.NoError(t, err)
+		var paths []string
+		for _, repo := range repos {
+			paths = append(paths, repo.Path)
+		}
+		return paths
+	}
+	scan := func() {
+		t.Helper()
+		_, err := runCommand(t, scanCmd)
+		require.NoError(t, err)
+	}
+
+	scan()
+	assert.Equal(t, []string{app, archive}, listed())
+
+	// Added repositories survive scans
+	out, err := runCommand(t, addCmd, tool, app)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Added "+tool)
+	assert.Contains(t, out, app+" is already in the repository list")
+	scan()
+	assert.ElementsMatch(t, []string{app, archive, tool}, listed())
+
+	_, err = runCommand(t, addCmd, filepath.Join(tmpDir, "missing"))
+	assert.ErrorContains(t, err, "failed to open repository")
+
+	// Removed repositories in a scanned directory come back with the next scan
+	out, err = runCommand(t, removeCmd, tool, archive)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Removed "+tool+"\n")
+	assert.Contains(t, out, "it is in the scanned directory "+scanned)
+	assert.Equal(t, []string{app}, listed())
+	scan()
+	assert.Equal(t, []string{app, archive}, listed())
+
+	_, err = runCommand(t, removeCmd, tool)
+	assert.ErrorContains(t, err, "is not in the repository list")
+
+	// Ignored repositories stay out
+	out, err = runCommand(t, ignoreCmd, "*-archive")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Removed 1 repositories from the repository list")
+	assert.Equal(t, []string{app}, listed())
+	scan()
+	assert.Equal(t, []string{app}, listed())
+
+	_, err = runCommand(t, addCmd, archive)
+	assert.ErrorContains(t, err, "is ignored")
+
+	out, err = runCommand(t, ignoreCmd)
+	require.NoError(t, err)
+	assert.Equal(t, "*-archive\n", out)
+
+	_, err = runCommand(t, ignoreCmd, "--remove", "*-archive")
+	require.NoError(t, err)
+	scan()
+	assert.Equal(t, []string{app, archive}, listed())
+}
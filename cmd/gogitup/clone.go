@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/manifest"
+	"github.com/trutx/gogitup/internal/pool"
+)
+
+var (
+	cloneThreads int
+	cloneRoot    string
+)
+
+type cloneResult struct {
+	path   string
+	repo   *git.Repository
+	cloned bool
+	error  error
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().IntVarP(&cloneThreads, "threads", "t", runtime.NumCPU(), "number of concurrent clones")
+	cloneCmd.Flags().StringVarP(&cloneRoot, "dir", "d", ".", "workspace root for relative manifest paths")
+}
+
+var cloneCmd = &cobra.Command{
+	Use:     "clone <manifest>",
+	Aliases: []string{"sync"},
+	Short:   "Clone the repositories listed in a manifest",
+	Long: `Clone every repository listed in a manifest file that is missing on disk,
+add the upstream remote for forks, and register all of them in the
+repository list.
+
+A manifest is a YAML file like the one written by 'gogitup export':
+
+  repositories:
+    - url: git@github.com:me/api.git
+      path: ~/code/api
+      upstream: https://github.com/org/api.git
+      branch: main
+    - url: https://github.com/org/tool.git
+
+Relative paths are resolved against --dir. When path is omitted, the
+repository name from the URL is used. Repositories that already exist are left
+untouched, apart from adding a missing upstream remote.`,
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := manifest.Load(args[0])
+		if err != nil {
+			return err
+		}
+		if err := m.Validate(cloneRoot); err != nil {
+			return fmt.Errorf("invalid manifest: %w", err)
+		}
+		if len(m.Repositories) == 0 {
+			return fmt.Errorf("manifest lists no repositories")
+		}
+
+		results := pool.Run(m.Repositories, cloneThreads, func(entry *manifest.Entry) cloneResult {
+			result := cloneResult{path: entry.TargetPath(cloneRoot)}
+			result.repo, result.cloned, result.error = git.Clone(context.Background(), git.CloneOptions{
+				URL:      entry.URL,
+				Path:     result.path,
+				Branch:   entry.Branch,
+				Upstream: entry.Upstream,
+			})
+			return result
+		})
+
+		cloned := 0
+		errors := make([]error, 0)
+		registered := make([]git.Repository, 0, len(m.Repositories))
+		for result := range results {
+			if result.error != nil {
+				errors = append(errors, fmt.Errorf("failed to clone %s: %w", result.path, result.error))
+				if verbose {
+					fmt.Printf("Error cloning %s: %v\n", result.path, result.error)
+				}
+				continue
+			}
+
+			registered = append(registered, *result.repo)
+			if result.cloned {
+				cloned++
+				fmt.Printf("Cloned %s\n", result.path)
+			} else if verbose {
+				fmt.Printf("Already present: %s\n", result.path)
+			}
+		}
+
+		// Register everything that is on disk in the repository list
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		err = store.Update(func(repos []git.Repository) ([]git.Repository, error) {
+			return git.AppendRepositories(repos, registered...), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nCloned %d repositories, %d already present\n", cloned, len(registered)-cloned)
+
+		if len(errors) > 0 {
+			fmt.Printf("\nEncountered %d errors:\n", len(errors))
+			for _, err := range errors {
+				fmt.Printf("- %v\n", err)
+			}
+			fmt.Printf("\nError: failed to clone some repositories\n")
+			// Return error code without message since we already printed it
+			return fmt.Errorf("")
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/manifest"
+)
+
+// createBareRemote creates a bare repository with a single commit on master
+func createBareRemote(t *testing.T, dir string) string {
+	t.Helper()
+
+	srcDir := dir + "-src"
+	repo, err := git.PlainInit(srcDir, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "test.txt"), []byte("test content"), 0644))
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = w.Add("test.txt")
+	require.NoError(t, err)
+	_, err = w.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = git.PlainClone(dir, true, &git.CloneOptions{URL: srcDir})
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(srcDir))
+	return dir
+}
+
+func TestCloneAndExportCommands(t *testing.T) {
+	// Create temporary directory for test files
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	apiRemote := createBareRemote(t, filepath.Join(tmpDir, "remotes", "api.git"))
+	upstreamRemote := createBareRemote(t, filepath.Join(tmpDir, "remotes", "upstream-api.git"))
+	toolRemote := createBareRemote(t, filepath.Join(tmpDir, "remotes", "tool.git"))
+
+	workspace := filepath.Join(tmpDir, "workspace")
+	manifestFile := filepath.Join(tmpDir, "manifest.yaml")
+	m := &manifest.Manifest{
+		Repositories: []manifest.Entry{
+			{URL: apiRemote, Path: "work/api", Upstream: upstreamRemote, Branch: "master"},
+			{URL: toolRemote, Branch: "master"},
+		},
+	}
+	require.NoError(t, m.Save(manifestFile))
+
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	useFiles(t, "", reposFile)
+
+	runClone := func() error {
+		cmd := &cobra.Command{Use: "clone"}
+		cmd.RunE = cloneCmd.RunE
+		cmd.PreRun = cloneCmd.PreRun
+		cmd.Args = cloneCmd.Args
+		cmd.Flags().AddFlagSet(cloneCmd.Flags())
+		cmd.PersistentFlags().AddFlagSet(rootCmd.PersistentFlags())
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			f.Changed = false
+		})
+		cmd.SetArgs([]string{"--dir", workspace, manifestFile})
+		return cmd.Execute()
+	}
+
+	// Clone everything and register it
+	require.NoError(t, runClone())
+	repos, err := gitutil.NewStore(reposFile).Load()
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	for _, repo := range repos {
+		if repo.Path == filepath.Join(workspace, "work", "api") {
+			assert.True(t, repo.HasUpstream)
+		} else {
+			assert.Equal(t, filepath.Join(workspace, "tool"), repo.Path)
+			assert.False(t, repo.HasUpstream)
+		}
+	}
+
+	// Running again is a no-op and does not duplicate cache entries
+	require.NoError(t, runClone())
+	repos, err = gitutil.NewStore(reposFile).Load()
+	require.NoError(t, err)
+	assert.Len(t, repos, 2)
+
+	// Export the cache and compare it with the original manifest
+	exported, skipped := manifestFromRepositories(repos)
+	assert.Empty(t, skipped)
+	require.Len(t, exported.Repositories, 2)
+	for _, entry := range exported.Repositories {
+		assert.Equal(t, "master", entry.Branch)
+		switch entry.URL {
+		case apiRemote:
+			assert.Equal(t, upstreamRemote, entry.Upstream)
+		case toolRemote:
+			assert.Empty(t, entry.Upstream)
+		default:
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+}
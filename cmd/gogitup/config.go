@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/daemon"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	initDirectories []string
+	initAutoScan    bool
+	initForce       bool
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd, configValidateCmd, configShowCmd)
+	configInitCmd.Flags().StringSliceVarP(&initDirectories, "directory", "d", nil, "directory to scan for repositories, may be repeated (asked for when not given)")
+	configInitCmd.Flags().BoolVar(&initAutoScan, "auto-scan", true, "scan for new repositories before every update")
+	configInitCmd.Flags().BoolVarP(&initForce, "force", "f", false, "overwrite an existing config file")
+}
+
+// promptInit asks for the settings of a new config file
+func promptInit(in io.Reader) ([]string, bool, error) {
+	reader := bufio.NewReader(in)
+
+	var directories []string
+	for len(directories) == 0 {
+		fmt.Print("Directories to scan for repositories, separated by commas: ")
+		answer, err := reader.ReadString('\n')
+		for _, dir := range strings.Split(answer, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				directories = append(directories, dir)
+			}
+		}
+		if err != nil && len(directories) == 0 {
+			return nil, false, fmt.Errorf("no directories given")
+		}
+	}
+
+	fmt.Print("Scan for new repositories before every update? [Y/n] ")
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return directories, answer != "n" && answer != "no", nil
+}
+
+// writeConfigFile writes a commented config file to path, which must not
+// exist unless force is set
+func writeConfigFile(path string, directories []string, autoScan, force bool) error {
+	var buf bytes.Buffer
+	if err := config.WriteSample(&buf, directories, autoScan); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("config file %s already exists, use --force to overwrite it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create config file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return f.Close()
+}
+
+// validateConfig checks the config file at path with the selected profile,
+// including the settings only the commands interpret
+func validateConfig(path string) ([]config.Issue, error) {
+	cfg, issues, err := config.Validate(path, profileName())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := daemon.ParseQuietHours(cfg.Daemon.QuietHours); err != nil {
+		issues = append(issues, config.Issue{Message: fmt.Sprintf("daemon: %v", err)})
+	}
+	return issues, nil
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Create, check and show the config file",
+	Long: `Manage the config file. Without --config, the config file is
+$XDG_CONFIG_HOME/gogitup/config.yaml if it exists, ~/.gogitup.yaml otherwise.`,
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a new config file",
+	Long: `Write a new config file with the given directories and comments
+describing the optional settings.
+
+Without --directory the directories are asked for, which requires a terminal.
+An existing config file is only replaced with --force.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil && !initForce {
+			return fmt.Errorf("config file %s already exists, use --force to overwrite it", path)
+		}
+
+		directories, autoScan := initDirectories, initAutoScan
+		if len(directories) == 0 {
+			if !isatty.IsTerminal(os.Stdin.Fd()) {
+				return fmt.Errorf("no directories given, use --directory")
+			}
+			if directories, autoScan, err = promptInit(os.Stdin); err != nil {
+				return err
+			}
+		}
+
+		if err := writeConfigFile(path, directories, autoScan, initForce); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote config file %s\nRun 'gogitup scan' to find your repositories\n", path)
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for mistakes",
+	Long: `Check the config file, with the selected profile applied, for mistakes that
+would otherwise only show up as confusing behaviour:
+
+  - keys that match no setting, such as a misspelled auto_scan
+  - scan directories that do not exist
+  - invalid repository settings and daemon quiet hours
+  - references to unset environment variables (a warning only)
+
+The command fails if any error is found.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		issues, err := validateConfig(path)
+		if err != nil {
+			return err
+		}
+
+		errorCount := 0
+		for _, issue := range issues {
+			if !issue.Warning {
+				errorCount++
+			}
+			fmt.Printf("- %s\n", issue)
+		}
+		if errorCount > 0 {
+			return fmt.Errorf("config file %s has %d errors", path, errorCount)
+		}
+		fmt.Printf("Config file %s is valid\n", path)
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective config",
+	Long: `Print the config the other commands use: the config file merged with its
+includes and the selected profile, with paths expanded and unset values left
+out.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// The includes and profiles are already merged
+		settings := cfg.Settings()
+		delete(settings, "include")
+		delete(settings, "profiles")
+		data, err := yaml.Marshal(settings)
+		if err != nil {
+			return fmt.Errorf("failed to format config: %w", err)
+		}
+
+		fmt.Printf("# %s\n", path)
+		if cfg.Profile != "" {
+			fmt.Printf("# profile: %s\n", cfg.Profile)
+		}
+		fmt.Print(string(data))
+		return nil
+	},
+}
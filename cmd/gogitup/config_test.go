@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCommand runs a command with its flags reset to their defaults and
+// returns its output
+func runCommand(t *testing.T, sub *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+	cmd := &cobra.Command{Use: sub.Use}
+	cmd.RunE = sub.RunE
+	cmd.Args = sub.Args
+	cmd.Flags().AddFlagSet(sub.Flags())
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+		// Slice flags append to their value when set
+		if v, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if s := strings.Trim(f.DefValue, "[]"); s != "" {
+				values = strings.Split(s, ",")
+			}
+			_ = v.Replace(values)
+			return
+		}
+		_ = f.Value.Set(f.DefValue)
+	})
+	cmd.SetArgs(args)
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := cmd.Execute()
+	require.NoError(t, w.Close())
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, copyErr := io.Copy(&buf, r)
+	require.NoError(t, copyErr)
+	return buf.String(), err
+}
+
+func TestConfigCommands(t *testing.T) {
+	tmpDir := t.TempDir()
+	reposDir := filepath.Join(tmpDir, "repos")
+	require.NoError(t, os.MkdirAll(reposDir, 0755))
+	configFile := filepath.Join(tmpDir, "gogitup", "config.yaml")
+	useFiles(t, configFile, "")
+	t.Setenv("GOGITUP_PROFILE", "")
+
+	// init writes a config file that validates
+	out, err := runCommand(t, configInitCmd, "--directory", reposDir, "--auto-scan=false")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Wrote config file "+configFile)
+	data, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "auto_scan: false")
+	assert.Contains(t, string(data), "# profiles:")
+
+	_, err = runCommand(t, configInitCmd, "--directory", reposDir)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = runCommand(t, configInitCmd, "--directory", reposDir, "--force")
+	require.NoError(t, err)
+
+	out, err = runCommand(t, configValidateCmd)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Config file "+configFile+" is valid")
+
+	out, err = runCommand(t, configShowCmd)
+	require.NoError(t, err)
+	assert.Equal(t, "# "+configFile+"\nauto_scan: true\ndirectories:\n    - "+reposDir+"\n", out)
+
+	// Mistakes are reported one per line
+	missing := filepath.Join(tmpDir, "missing")
+	require.NoError(t, os.WriteFile(configFile, []byte(strings.Join([]string{
+		"directories:",
+		"  - " + missing,
+		"metrics:",
+		"  textfile: $GOGITUP_TEST_UNSET/gogitup.prom",
+		"daemon:",
+		"  quiet_hours: 25:00-07:00",
+	}, "\n")), 0644))
+	out, err = runCommand(t, configValidateCmd)
+	assert.ErrorContains(t, err, "has 2 errors")
+	assert.Contains(t, out, "- warning: environment variable GOGITUP_TEST_UNSET is not set\n")
+	assert.Contains(t, out, "- error: directory "+missing+" does not exist\n")
+	assert.Contains(t, out, "- error: daemon: ")
+
+	require.NoError(t, os.WriteFile(configFile, []byte("directories: ["+reposDir+"]\nauto_scann: false\n"), 0644))
+	_, err = runCommand(t, configValidateCmd)
+	assert.ErrorContains(t, err, "auto_scann")
+}
+
+func TestConfigShow_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+include:
+  - daemon.yaml
+profiles:
+  work:
+    directories:
+      - /work
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "daemon.yaml"), []byte("daemon:\n  interval: 90m\n"), 0644))
+	useFiles(t, configFile, "")
+	profile = "work"
+	defer func() { profile = "" }()
+
+	out, err := runCommand(t, configShowCmd)
+	require.NoError(t, err)
+	assert.Equal(t, "# "+configFile+"\n# profile: work\ndaemon:\n    interval: 1h30m0s\ndirectories:\n    - /work\n", out)
+}
+
+func TestPromptInit(t *testing.T) {
+	dirs, autoScan, err := promptInit(strings.NewReader("\n~/code, ~/work ,\nn\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"~/code", "~/work"}, dirs)
+	assert.False(t, autoScan)
+
+	dirs, autoScan, err = promptInit(strings.NewReader("~/code\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"~/code"}, dirs)
+	assert.True(t, autoScan)
+
+	_, _, err = promptInit(strings.NewReader(""))
+	assert.ErrorContains(t, err, "no directories given")
+}
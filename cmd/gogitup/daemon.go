@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/daemon"
+	"github.com/trutx/gogitup/internal/metrics"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+var (
+	daemonInterval   time.Duration
+	daemonJitter     time.Duration
+	daemonQuietHours string
+	daemonSocket     string
+	daemonThreads    int
+	daemonWatch      bool
+	daemonMetrics    string
+)
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd, daemonTriggerCmd)
+	daemonCmd.PersistentFlags().StringVar(&daemonSocket, "socket", "", "control socket path (default: daemon.sock next to the repository list)")
+	daemonCmd.Flags().DurationVar(&daemonInterval, "interval", config.DefaultDaemonInterval, "time between two update runs")
+	daemonCmd.Flags().DurationVar(&daemonJitter, "jitter", 0, "maximum random delay added to every interval")
+	daemonCmd.Flags().StringVar(&daemonQuietHours, "quiet-hours", "", "local time range without scheduled updates, e.g. 22:00-07:00")
+	daemonCmd.Flags().BoolVar(&daemonWatch, "watch", false, "keep the repository list current with a filesystem watcher")
+	daemonCmd.Flags().StringVar(&daemonMetrics, "metrics-listen", "", "TCP address to serve Prometheus metrics on, e.g. localhost:9419")
+	daemonCmd.Flags().IntVarP(&daemonThreads, "threads", "t", runtime.NumCPU(), "number of concurrent repository updates")
+	addFilterFlags(daemonCmd)
+}
+
+// daemonSocketPath returns the control socket path from the flag, the config
+// file or the default location next to the repository list
+func daemonSocketPath(cfg *config.Config, store *gogitup.Store) string {
+	if daemonSocket != "" {
+		return config.ExpandPath(daemonSocket)
+	}
+	if cfg.Daemon.Socket != "" {
+		return config.ExpandPath(cfg.Daemon.Socket)
+	}
+	return filepath.Join(filepath.Dir(store.Path()), "daemon.sock")
+}
+
+// newDaemonCycle returns the daemon run function, which scans and updates the
+// repositories of store once with the selected config file and profile, using
+// the same machinery as the scan and update commands. With serveMetrics the
+// commits behind are recorded for the metrics served by the daemon.
+func newDaemonCycle(store *gogitup.Store, serveMetrics bool) daemon.RunFunc {
+	return func(ctx context.Context, scan bool) (daemon.Summary, error) {
+		return runDaemonCycle(ctx, store, serveMetrics, scan)
+	}
+}
+
+// runDaemonCycle performs a single run of the daemon, see newDaemonCycle
+func runDaemonCycle(ctx context.Context, store *gogitup.Store, serveMetrics, scan bool) (summary daemon.Summary, err error) {
+	summary.Started = time.Now()
+	defer func() { summary.Finished = time.Now() }()
+
+	// Reload the config on every run so edits apply without a restart
+	cfg, err := loadConfig()
+	if err != nil {
+		return summary, err
+	}
+
+	if scan && (cfg.AutoScan == nil || *cfg.AutoScan) && !watcherIsCurrent(cfg, store) {
+		if _, err := scanRepositories(ctx, cfg, store, nil); err != nil {
+			return summary, err
+		}
+		summary.Scanned = true
+	}
+
+	repos, err := store.Load()
+	if err != nil {
+		return summary, err
+	}
+	repos, err = selectRepositories(repos)
+	if err != nil {
+		return summary, err
+	}
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+
+	summary.Repositories = len(repos)
+	// --metrics-listen may serve metrics the config knows nothing about
+	opts := updateOptions(cfg, daemonThreads, false)
+	opts.Behind = opts.Behind || serveMetrics
+	outcomes := gogitup.NewUpdater(cfg, opts).Run(ctx, repos)
+	for _, result := range outcomes {
+		switch {
+		case result.Err != nil:
+			summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", result.Path, result.Err))
+		case result.Warning != "":
+			summary.Warnings++
+		default:
+			summary.Updated++
+		}
+	}
+
+	saved, err := store.Record(outcomes, time.Now())
+	if err != nil {
+		return summary, err
+	}
+	if file := metricsFile(cfg); file != "" {
+		if err := metrics.WriteTextfile(file, saved, summary.Started); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+// repositoryMetrics returns a function writing the metrics of the
+// repositories in store as recorded by the last update run
+func repositoryMetrics(store *gogitup.Store) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+		return metrics.Write(w, repos)
+	}
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Update repositories on a schedule in the background",
+	Long: `Run a long-lived process that updates all repositories on a schedule.
+
+The first update starts right away, the following ones every --interval plus
+a random delay of up to --jitter. No scheduled update starts during the quiet
+hours. The configured directories are rescanned once a day (see
+daemon.scan_interval in the config file), or watched for new and removed
+repositories with --watch, and the outcome of every update is recorded in the
+repository list.
+
+Only one update runs at a time. Use 'gogitup daemon status' to inspect a
+running daemon and 'gogitup daemon trigger' to start an update immediately.
+Prometheus metrics are served on /metrics of the control socket, and on a TCP
+address with --metrics-listen.
+
+Flags override the daemon section of the config file.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		interval := cfg.Daemon.UpdateInterval()
+		if cmd.Flags().Changed("interval") {
+			interval = daemonInterval
+		}
+		if interval <= 0 {
+			return fmt.Errorf("interval must be positive")
+		}
+		jitter := cfg.Daemon.Jitter
+		if cmd.Flags().Changed("jitter") {
+			jitter = daemonJitter
+		}
+		quietHours := cfg.Daemon.QuietHours
+		if cmd.Flags().Changed("quiet-hours") {
+			quietHours = daemonQuietHours
+		}
+		quiet, err := daemon.ParseQuietHours(quietHours)
+		if err != nil {
+			return err
+		}
+		socket := daemonSocketPath(cfg, store)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		logInfoByDefault()
+		if cfg.Daemon.Watch || daemonWatch {
+			w := newWatcher(cfg, store, infof)
+			go func() {
+				if err := w.Run(ctx); err != nil {
+					slog.Error("watcher stopped", "error", err)
+				}
+			}()
+		}
+
+		metricsListen := cfg.Metrics.Listen
+		if cmd.Flags().Changed("metrics-listen") {
+			metricsListen = daemonMetrics
+		}
+
+		d := daemon.New(daemon.Options{
+			Socket:        socket,
+			Interval:      interval,
+			Jitter:        jitter,
+			ScanInterval:  cfg.Daemon.RescanInterval(),
+			QuietHours:    quiet,
+			Run:           newDaemonCycle(store, metricsListen != ""),
+			Logf:          infof,
+			Metrics:       repositoryMetrics(store),
+			MetricsListen: metricsListen,
+		})
+		return d.Run(ctx)
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:           "status",
+	Short:         "Show the state of the running daemon",
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		socket := daemonSocketPath(cfg, store)
+
+		status, err := daemon.GetStatus(socket)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Daemon running (pid %d) since %s\n", status.PID, status.Started.Format(time.DateTime))
+		fmt.Printf("State: %s\n", status.State)
+		if !status.NextRun.IsZero() {
+			fmt.Printf("Next update: %s\n", status.NextRun.Format(time.DateTime))
+		}
+		fmt.Printf("Runs: %d\n", status.Runs)
+
+		if status.LastError != "" {
+			fmt.Printf("\nLast update failed: %s\n", status.LastError)
+		}
+		if last := status.LastRun; last != nil && status.LastError == "" {
+			fmt.Printf("\nLast update: %s (took %s)\n", last.Finished.Format(time.DateTime), last.Finished.Sub(last.Started).Round(time.Second))
+			fmt.Printf("Updated %d of %d repositories, %d warnings\n", last.Updated, last.Repositories, last.Warnings)
+			if len(last.Errors) > 0 {
+				fmt.Printf("\nEncountered %d errors:\n", len(last.Errors))
+				for _, e := range last.Errors {
+					fmt.Printf("- %s\n", e)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+var daemonTriggerCmd = &cobra.Command{
+	Use:           "trigger",
+	Short:         "Start an update in the running daemon now",
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		socket := daemonSocketPath(cfg, store)
+
+		if err := daemon.Trigger(socket); err != nil {
+			return err
+		}
+		fmt.Println("Update triggered")
+		return nil
+	},
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/config"
+	gitutil "github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+func TestDaemonSocketPath(t *testing.T) {
+	defer func() { daemonSocket = "" }()
+	store := gogitup.NewStore("/cache/gogitup/repositories.json")
+
+	socket := daemonSocketPath(&config.Config{}, store)
+	assert.Equal(t, filepath.FromSlash("/cache/gogitup/daemon.sock"), socket)
+
+	socket = daemonSocketPath(&config.Config{Daemon: config.Daemon{Socket: "/run/gogitup.sock"}}, store)
+	assert.Equal(t, "/run/gogitup.sock", socket)
+
+	daemonSocket = "/tmp/flag.sock"
+	socket = daemonSocketPath(&config.Config{Daemon: config.Daemon{Socket: "/run/gogitup.sock"}}, store)
+	assert.Equal(t, "/tmp/flag.sock", socket)
+}
+
+func TestRunDaemonCycle(t *testing.T) {
+	// Create temporary directory for test files
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	remoteDir := createBareRemote(t, filepath.Join(tmpDir, "remote.git"))
+	reposDir := filepath.Join(tmpDir, "repos")
+	for _, name := range []string{"good", "broken"} {
+		out, err := exec.Command("git", "clone", "-q", remoteDir, filepath.Join(reposDir, name)).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	out, err := exec.Command("git", "-C", filepath.Join(reposDir, "broken"), "remote", "set-url", "origin", filepath.Join(tmpDir, "missing.git")).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	metricsFile := filepath.Join(tmpDir, "textfile", "gogitup.prom")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+reposDir+"\nmetrics:\n  textfile: "+metricsFile+"\n"), 0644))
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+
+	useFiles(t, configFile, reposFile)
+	t.Setenv("GOGITUP_PROFILE", "")
+	resetFilters()
+	daemonThreads = 2
+
+	run := newDaemonCycle(gogitup.NewStore(reposFile), false)
+	summary, err := run(context.Background(), true)
+	require.NoError(t, err)
+	assert.True(t, summary.Scanned)
+	assert.Equal(t, 2, summary.Repositories)
+	assert.Equal(t, 1, summary.Updated)
+	require.Len(t, summary.Errors, 1)
+	assert.Contains(t, summary.Errors[0], "broken")
+	assert.False(t, summary.Finished.Before(summary.Started))
+
+	// Outcomes are persisted in the repository list
+	repos, err := gitutil.NewStore(reposFile).Load()
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	outcomes := make(map[string]gitutil.Repository)
+	for _, repo := range repos {
+		outcomes[filepath.Base(repo.Path)] = repo
+	}
+	assert.Equal(t, gitutil.OutcomeUpdated, outcomes["good"].LastOutcome)
+	assert.False(t, outcomes["good"].LastUpdated.IsZero())
+	assert.Equal(t, gitutil.OutcomeFailed, outcomes["broken"].LastOutcome)
+	assert.NotEmpty(t, outcomes["broken"].LastError)
+	assert.Equal(t, map[string]int{gitutil.OutcomeUpdated: 1}, outcomes["good"].Outcomes)
+	assert.Positive(t, outcomes["good"].LastDuration)
+
+	// The metrics textfile reflects the run
+	data, err := os.ReadFile(metricsFile)
+	require.NoError(t, err)
+	good := filepath.Join(reposDir, "good")
+	assert.Contains(t, string(data), `gogitup_repository_updates_total{repository="`+good+`",outcome="updated"} 1`)
+	assert.Contains(t, string(data), `gogitup_repository_commits_behind{repository="`+good+`"} 0`)
+	assert.Contains(t, string(data), `gogitup_repository_last_update_failed{repository="`+filepath.Join(reposDir, "broken")+`"} 1`)
+
+	// Without a config file the run fails as a whole
+	useFiles(t, filepath.Join(tmpDir, "missing.yaml"), reposFile)
+	_, err = run(context.Background(), false)
+	assert.ErrorContains(t, err, "failed to load config")
+}
+
+func TestRunDaemonCycle_DefaultConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	remoteDir := createBareRemote(t, filepath.Join(tmpDir, "remote.git"))
+	for _, name := range []string{"code", "work"} {
+		out, err := exec.Command("git", "clone", "-q", remoteDir, filepath.Join(tmpDir, name, "app")).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// Without --config the cycle reads the default config file, with the
+	// profile selected in the environment applied
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+	configDir := filepath.Join(tmpDir, "xdg", "gogitup")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(`
+directories:
+  - `+filepath.Join(tmpDir, "code")+`
+profiles:
+  work:
+    directories:
+      - `+filepath.Join(tmpDir, "work")+`
+`), 0644))
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	useFiles(t, "", reposFile)
+	configFile = ""
+	t.Setenv("GOGITUP_PROFILE", "work")
+	resetFilters()
+	daemonThreads = 1
+
+	summary, err := newDaemonCycle(gogitup.NewStore(reposFile), false)(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Repositories)
+	assert.Equal(t, 1, summary.Updated)
+	assert.Empty(t, summary.Errors)
+
+	repos, err := gitutil.NewStore(reposFile).Load()
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, filepath.Join(tmpDir, "work", "app"), repos[0].Path)
+}
+
+func TestRunDaemonCycle_ServeMetrics(t *testing.T) {
+	for _, name := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+name+"_NAME", "Test User")
+		t.Setenv("GIT_"+name+"_EMAIL", "test@example.com")
+	}
+
+	tmpDir := t.TempDir()
+	remoteDir := createBareRemote(t, filepath.Join(tmpDir, "remote.git"))
+	reposDir := filepath.Join(tmpDir, "repos")
+	path := filepath.Join(reposDir, "app")
+	other := filepath.Join(tmpDir, "other")
+	for _, dir := range []string{path, other} {
+		out, err := exec.Command("git", "clone", "-q", remoteDir, dir).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run := func(dir string, args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// The clone diverges from the remote, so it stays one commit behind
+	require.NoError(t, os.WriteFile(filepath.Join(other, "remote.txt"), []byte("remote"), 0644))
+	run(other, "add", "remote.txt")
+	run(other, "commit", "-q", "-m", "Remote change")
+	run(other, "push", "-q", "origin", "HEAD")
+	require.NoError(t, os.WriteFile(filepath.Join(path, "local.txt"), []byte("local"), 0644))
+	run(path, "add", "local.txt")
+	run(path, "commit", "-q", "-m", "Local change")
+
+	// No metrics in the config, only served with --metrics-listen
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+reposDir+"\n"), 0644))
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	useFiles(t, configFile, reposFile)
+	t.Setenv("GOGITUP_PROFILE", "")
+	resetFilters()
+	daemonThreads = 1
+
+	behind := func() int {
+		repos, err := gitutil.NewStore(reposFile).Load()
+		require.NoError(t, err)
+		require.Len(t, repos, 1)
+		return repos[0].Behind
+	}
+
+	_, err := newDaemonCycle(gogitup.NewStore(reposFile), false)(context.Background(), true)
+	require.NoError(t, err)
+	assert.Zero(t, behind())
+
+	_, err = newDaemonCycle(gogitup.NewStore(reposFile), true)(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, behind())
+}
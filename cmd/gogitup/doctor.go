@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/doctor"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+var doctorOffline bool
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "skip the checks that connect to the remote hosts")
+	addFilterFlags(doctorCmd)
+}
+
+// doctorReport prints check results by section and counts the errors
+type doctorReport struct {
+	errors int
+}
+
+// section starts a new section of the report
+func (r *doctorReport) section(title string) {
+	fmt.Printf("\n%s\n", color.New(color.Bold).Sprint(title))
+}
+
+// print prints a check result and how to fix it
+func (r *doctorReport) print(result doctor.Result) {
+	var mark string
+	switch result.Status {
+	case doctor.OK:
+		mark = color.GreenString("✓")
+	case doctor.Warning:
+		mark = color.YellowString("!")
+	default:
+		mark = color.RedString("✗")
+		r.errors++
+	}
+
+	fmt.Printf("  %s %s: %s\n", mark, result.Name, result.Message)
+	if result.Fix != "" {
+		fmt.Printf("    fix: %s\n", result.Fix)
+	}
+}
+
+// printProblem prints a problem of a repository and how to fix it
+func (r *doctorReport) printProblem(problem git.Problem) {
+	r.errors++
+	fmt.Printf("    %s %s\n", color.RedString("✗"), problem.Message)
+	fmt.Printf("      fix: %s\n", problem.Fix)
+}
+
+// checkConfig validates the config file, returning it unless the other
+// commands cannot load it either
+func (r *doctorReport) checkConfig() *config.Config {
+	path, err := configPath()
+	if err != nil {
+		r.print(doctor.Result{Name: "config", Status: doctor.Error, Message: err.Error()})
+		return nil
+	}
+
+	issues, err := validateConfig(path)
+	if err != nil {
+		result := doctor.Result{Name: "config", Status: doctor.Error, Message: err.Error(), Fix: "edit " + path}
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			result.Fix = "create one with 'gogitup config init'"
+		}
+		r.print(result)
+	}
+	for _, issue := range issues {
+		status := doctor.Error
+		if issue.Warning {
+			status = doctor.Warning
+		}
+		r.print(doctor.Result{Name: "config", Status: status, Message: issue.Message, Fix: "edit " + path})
+	}
+	if err == nil && len(issues) == 0 {
+		r.print(doctor.Result{Name: "config", Message: path + " is valid"})
+	}
+
+	// The validation is strict, the other commands may still load the
+	// config
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	return cfg
+}
+
+// checkCache reads the repository list, returning the repositories that
+// can be opened and the ones that cannot
+func (r *doctorReport) checkCache() ([]git.Repository, []git.Repository) {
+	store, err := openStore()
+	if err != nil {
+		r.print(doctor.Result{Name: "cache", Status: doctor.Error, Message: err.Error()})
+		return nil, nil
+	}
+
+	repos, broken, err := git.NewStore(store.Path()).Inspect()
+	switch {
+	case err != nil:
+		r.print(doctor.Result{
+			Name:    "cache",
+			Status:  doctor.Error,
+			Message: err.Error(),
+			Fix:     fmt.Sprintf("remove %s and run 'gogitup scan' to rebuild it", store.Path()),
+		})
+	case len(repos) == 0 && len(broken) == 0:
+		r.print(doctor.Result{
+			Name:    "cache",
+			Status:  doctor.Warning,
+			Message: fmt.Sprintf("no repositories in %s", store.Path()),
+			Fix:     "run 'gogitup scan' to find your repositories",
+		})
+	case len(broken) > 0:
+		r.print(doctor.Result{
+			Name:    "cache",
+			Status:  doctor.Warning,
+			Message: fmt.Sprintf("%d of %d repositories in %s cannot be opened and are not updated", len(broken), len(repos)+len(broken), store.Path()),
+			Fix:     "see the repositories below",
+		})
+	default:
+		r.print(doctor.Result{Name: "cache", Message: fmt.Sprintf("%d repositories in %s", len(repos), store.Path())})
+	}
+	return repos, broken
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the environment and the repositories",
+	Long: `Look for the causes of failing updates and tell how to fix them:
+
+  - git missing or too old, and git-lfs missing while repositories use Git LFS
+  - mistakes in the config file, as found by 'gogitup config validate'
+  - a repository list that cannot be read, or entries that cannot be opened
+  - an SSH agent without keys, when repositories are updated over SSH
+  - remote hosts that cannot be reached with the configured credentials, and
+    GitHub tokens that are rejected or lack the repo scope
+  - repositories with a detached HEAD, a branch missing on the remote, or
+    negative refspecs go-git cannot parse
+
+The hosts are reached with 'git ls-remote' against one repository each, which
+--offline skips. The command fails if any error is found.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		report := &doctorReport{}
+		report.section("Config")
+		cfg := report.checkConfig()
+		if cfg == nil {
+			cfg = &config.Config{}
+		}
+
+		report.section("Cache")
+		repos, broken := report.checkCache()
+		repos, err := selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+
+		// Configure the repositories so the remotes and credentials follow
+		// the settings. The broken ones are diagnosed for why they cannot be
+		// opened.
+		all := make([]*git.Repository, 0, len(repos)+len(broken))
+		for i := range repos {
+			all = append(all, &repos[i])
+		}
+		for i := range broken {
+			all = append(all, &broken[i])
+		}
+		problems := make(map[*git.Repository][]git.Problem)
+		var updated []*git.Repository
+		needLFS, needSSH := false, false
+		for i, repo := range all {
+			settings, err := cfg.SettingsFor(repo.Path)
+			if err != nil {
+				problems[repo] = []git.Problem{{Message: err.Error(), Fix: "fix the repository settings in the config file"}}
+				continue
+			}
+			repo.Configure(settings)
+			if settings.IsSkipped() {
+				continue
+			}
+			problems[repo] = repo.Diagnose(ctx)
+			if i < len(repos) {
+				updated = append(updated, repo)
+				needLFS = needLFS || repo.UsesLFS()
+			}
+		}
+		hosts := doctor.Hosts(updated)
+		for _, host := range hosts {
+			needSSH = needSSH || host.SSH
+		}
+
+		report.section("Environment")
+		report.print(doctor.Git(ctx))
+		report.print(doctor.LFS(ctx, needLFS))
+		if needSSH {
+			report.print(doctor.SSHAgent(ctx))
+		}
+
+		if !doctorOffline && len(hosts) > 0 {
+			report.section("Hosts")
+			for _, host := range hosts {
+				for _, result := range host.Check(ctx) {
+					report.print(result)
+				}
+			}
+		}
+
+		report.section("Repositories")
+		healthy := 0
+		for _, repo := range all {
+			if len(problems[repo]) == 0 {
+				healthy++
+				continue
+			}
+			fmt.Printf("  %s\n", repo.Path)
+			for _, problem := range problems[repo] {
+				report.printProblem(problem)
+			}
+		}
+		fmt.Printf("  %d repositories without problems\n", healthy)
+
+		if report.errors > 0 {
+			return fmt.Errorf("found %d problems", report.errors)
+		}
+		fmt.Println("\nNo problems found")
+		return nil
+	},
+}
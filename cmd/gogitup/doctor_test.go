@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func TestDoctor(t *testing.T) {
+	tmpDir := t.TempDir()
+	reposDir := filepath.Join(tmpDir, "repos")
+	remote := filepath.Join(tmpDir, "remote.git")
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	gitRun("init", "-q", "--bare", remote)
+	healthy := filepath.Join(reposDir, "healthy")
+	gitRun("clone", "-q", remote, healthy)
+	gitRun("-C", healthy, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	gitRun("-C", healthy, "push", "-q", "origin", "HEAD")
+	gitRun("-C", healthy, "fetch", "-q")
+	detached := filepath.Join(reposDir, "detached")
+	gitRun("clone", "-q", remote, detached)
+	gitRun("-C", detached, "checkout", "-q", "--detach")
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+reposDir+"\n"), 0644))
+	reposFile := filepath.Join(tmpDir, "repos.json")
+	useFiles(t, configFile, reposFile)
+	t.Setenv("GOGITUP_PROFILE", "")
+
+	writeRepos := func(paths ...string) {
+		t.Helper()
+		repos := make([]git.Repository, 0, len(paths))
+		for _, path := range paths {
+			repos = append(repos, git.Repository{Path: path})
+		}
+		data, err := json.Marshal(repos)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(reposFile, data, 0644))
+	}
+
+	writeRepos(healthy)
+	out, err := runCommand(t, doctorCmd, "--offline")
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "config: "+configFile+" is valid")
+	assert.Contains(t, out, "cache: 1 repositories in "+reposFile)
+	assert.Contains(t, out, "git: version ")
+	assert.Contains(t, out, "1 repositories without problems")
+	assert.Contains(t, out, "No problems found")
+
+	writeRepos(healthy, detached, filepath.Join(reposDir, "gone"))
+	out, err = runCommand(t, doctorCmd, "--offline")
+	assert.EqualError(t, err, "found 2 problems")
+	assert.Contains(t, out, "cache: 1 of 3 repositories in "+reposFile+" cannot be opened")
+	assert.Contains(t, out, detached+"\n    ✗ HEAD is detached at ")
+	assert.Contains(t, out, "fix: check out a branch with 'git -C "+detached+" switch <branch>'")
+	assert.Contains(t, out, "repository no longer exists")
+	assert.Contains(t, out, "1 repositories without problems")
+	assert.NotContains(t, out, "Hosts")
+}
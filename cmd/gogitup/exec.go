@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/pool"
+)
+
+var (
+	execThreads  int
+	execPrefix   bool
+	execFailOnly bool
+)
+
+type execResult struct {
+	path     string
+	stdout   []byte
+	stderr   []byte
+	exitCode int
+	error    error
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().IntVarP(&execThreads, "threads", "t", runtime.NumCPU(), "number of concurrent commands")
+	execCmd.Flags().BoolVar(&execPrefix, "prefix", false, "prefix every output line with the repository path instead of grouping output")
+	execCmd.Flags().BoolVar(&execFailOnly, "failed-only", false, "only print output for repositories where the command failed")
+	addFilterFlags(execCmd)
+}
+
+// writePrefixed writes every line of data to w prefixed with prefix
+func writePrefixed(w io.Writer, prefix string, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintf(w, "%s %s\n", prefix, scanner.Text())
+	}
+}
+
+// printExecResult prints the captured output of a single command run
+func printExecResult(result execResult) {
+	if execFailOnly && result.error == nil && result.exitCode == 0 {
+		return
+	}
+
+	if execPrefix {
+		prefix := fmt.Sprintf("[%s]", result.path)
+		writePrefixed(os.Stdout, prefix, result.stdout)
+		writePrefixed(os.Stderr, prefix, result.stderr)
+		return
+	}
+
+	if len(result.stdout) == 0 && len(result.stderr) == 0 && !verbose {
+		return
+	}
+	fmt.Printf("\n==> %s <==\n", result.path)
+	_, _ = os.Stdout.Write(result.stdout)
+	_, _ = os.Stderr.Write(result.stderr)
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [flags] -- <command> [args...]",
+	Short: "Run a command in every tracked repository",
+	Long: `Run a command in every repository from the repository list.
+
+A single argument is run by the shell, so it may use pipes, redirections and
+the like; several arguments run the command directly, each argument passed
+unchanged.
+
+Commands run concurrently (see --threads) and their output is captured per
+repository. By default the output of each repository is printed as a group
+once its command finishes; use --prefix to print every line prefixed with the
+repository path instead.
+
+Use --group, --tag, --path and --exclude to limit the repositories the
+command runs in.
+
+Examples:
+  gogitup exec -- make lint
+  gogitup exec --path '*-service' -- go mod tidy
+  gogitup exec --prefix -- git gc --auto
+  gogitup exec -- git commit -am "Bump dependencies"
+  gogitup exec -- 'git status --short | wc -l'`,
+	Args:          cobra.MinimumNArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		if len(repos) == 0 {
+			return fmt.Errorf("no repositories found. Run 'scan' first")
+		}
+
+		repos, err = selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+		if len(repos) == 0 {
+			return fmt.Errorf("no repositories match the given filters")
+		}
+
+		results := pool.Run(repos, execThreads, func(repo *git.Repository) execResult {
+			result := execResult{path: repo.Path}
+			var out *git.ExecResult
+			var err error
+			if len(args) == 1 {
+				out, err = repo.Exec(args[0])
+			} else {
+				out, err = repo.ExecArgs(args[0], args[1:]...)
+			}
+			if out != nil {
+				result.stdout = out.Stdout
+				result.stderr = out.Stderr
+				result.exitCode = out.ExitCode
+			}
+			result.error = err
+			return result
+		})
+
+		// Print results as they come in so output never interleaves
+		errors := make([]error, 0)
+		for result := range results {
+			printExecResult(result)
+
+			if result.error != nil {
+				errors = append(errors, fmt.Errorf("%s: %w", result.path, result.error))
+			} else if result.exitCode != 0 {
+				errors = append(errors, fmt.Errorf("%s: exit status %d", result.path, result.exitCode))
+			}
+		}
+
+		fmt.Printf("\nRan command in %d repositories\n", len(repos)-len(errors))
+
+		if len(errors) > 0 {
+			fmt.Printf("\nEncountered %d errors:\n", len(errors))
+			for _, err := range errors {
+				fmt.Printf("- %v\n", err)
+			}
+			fmt.Printf("\nError: command failed in some repositories\n")
+			// Return error code without message since we already printed it
+			return fmt.Errorf("")
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+)
+
+func TestExecCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell commands in this test require a POSIX shell")
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedError  bool
+		expectedOutput []string
+	}{
+		{
+			name:           "grouped_output",
+			args:           []string{"--", "echo", "hello"},
+			expectedOutput: []string{"==> ", "hello", "Ran command in 2 repositories"},
+		},
+		{
+			name:           "prefixed_output",
+			args:           []string{"--prefix", "--", "echo", "hello"},
+			expectedOutput: []string{"/alpha] hello", "/beta] hello"},
+		},
+		{
+			name:           "path_filter",
+			args:           []string{"--path", "alpha", "--", "pwd"},
+			expectedOutput: []string{"Ran command in 1 repositories"},
+		},
+		{
+			name:           "arguments_kept_intact",
+			args:           []string{"--prefix", "--", "printf", "[%s]\n", "two words"},
+			expectedOutput: []string{"/alpha] [two words]", "/beta] [two words]"},
+		},
+		{
+			name:           "shell_command",
+			args:           []string{"--prefix", "--", "echo hello | tr a-z A-Z"},
+			expectedOutput: []string{"/alpha] HELLO"},
+		},
+		{
+			name:           "failing_command",
+			args:           []string{"--", "exit 2"},
+			expectedError:  true,
+			expectedOutput: []string{"Encountered 2 errors", "exit status 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary directory for test files
+			tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+			require.NoError(t, err)
+			defer func() {
+				err := os.RemoveAll(tmpDir)
+				if err != nil {
+					t.Errorf("Failed to remove temp directory: %v", err)
+				}
+			}()
+
+			// Create test repositories
+			var repos []gitutil.Repository
+			for _, name := range []string{"alpha", "beta"} {
+				repoDir := filepath.Join(tmpDir, name)
+				_, err := git.PlainInit(repoDir, false)
+				require.NoError(t, err)
+				repos = append(repos, gitutil.Repository{Path: repoDir, LastScanned: time.Now()})
+			}
+
+			reposFile := filepath.Join(tmpDir, "repositories.json")
+			data, err := json.Marshal(repos)
+			require.NoError(t, err)
+			err = os.WriteFile(reposFile, data, 0644)
+			require.NoError(t, err)
+
+			useFiles(t, "", reposFile)
+
+			// Create a new command instance
+			cmd := &cobra.Command{Use: "exec"}
+			cmd.RunE = execCmd.RunE
+			cmd.PreRun = execCmd.PreRun
+			cmd.Flags().AddFlagSet(execCmd.Flags())
+			cmd.PersistentFlags().AddFlagSet(rootCmd.PersistentFlags())
+
+			cmd.Flags().VisitAll(func(f *pflag.Flag) {
+				f.Changed = false
+			})
+			verbose = false
+			filterPaths = nil
+			execPrefix = false
+			execFailOnly = false
+
+			cmd.SetArgs(tt.args)
+
+			// Capture output
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err = cmd.Execute()
+
+			// Restore stdout
+			require.NoError(t, w.Close())
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			_, copyErr := io.Copy(&buf, r)
+			require.NoError(t, copyErr)
+			output := buf.String()
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, expectedStr := range tt.expectedOutput {
+				assert.Contains(t, output, expectedStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/manifest"
+)
+
+// manifestFromRepositories builds a manifest from the cached repositories.
+// Repositories without an origin remote cannot be cloned and are skipped.
+func manifestFromRepositories(repos []git.Repository) (*manifest.Manifest, []string) {
+	m := &manifest.Manifest{}
+	var skipped []string
+
+	for i := range repos {
+		repo := &repos[i]
+		url := repo.RemoteURL("origin")
+		if url == "" {
+			skipped = append(skipped, repo.Path)
+			continue
+		}
+
+		entry := manifest.Entry{
+			URL:    url,
+			Path:   manifest.ShortenHome(repo.Path),
+			Branch: repo.CurrentBranch(),
+		}
+		if repo.HasUpstream {
+			entry.Upstream = repo.RemoteURL("upstream")
+		}
+		m.Repositories = append(m.Repositories, entry)
+	}
+
+	return m, skipped
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write a manifest of the tracked repositories",
+	Long: `Write a manifest listing the remote URL, path, upstream and current branch of
+every repository in the repository list. The manifest can be used with
+'gogitup clone' to bootstrap the same workspace on another machine.
+
+Paths below your home directory are written with a leading ~. Without a file
+argument the manifest is written to stdout.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+		repos, err = selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+
+		m, skipped := manifestFromRepositories(repos)
+		for _, path := range skipped {
+			slog.Warn("skipping repository without origin remote", "path", path)
+		}
+
+		if len(args) == 0 {
+			return m.Write(os.Stdout)
+		}
+		if err := m.Save(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d repositories to %s\n", len(m.Repositories), args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	addFilterFlags(exportCmd)
+}
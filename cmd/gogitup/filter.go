@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+var (
+	filterGroups   []string
+	filterTags     []string
+	filterPaths    []string
+	filterExcludes []string
+)
+
+// addFilterFlags registers the repository selection flags on cmd. Only
+// commands that call selectRepositories take them.
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&filterGroups, "group", "g", nil, "only include repositories in this config group (can be repeated)")
+	cmd.Flags().StringArrayVar(&filterTags, "tag", nil, "only include repositories with this tag (can be repeated)")
+	cmd.Flags().StringArrayVarP(&filterPaths, "path", "p", nil, "only include repositories matching this glob (can be repeated)")
+	cmd.Flags().StringArrayVarP(&filterExcludes, "exclude", "x", nil, "exclude repositories matching this glob (can be repeated)")
+}
+
+// hasFilters reports whether any repository selection flag is set
+func hasFilters() bool {
+	return len(filterGroups) > 0 || len(filterTags) > 0 || len(filterPaths) > 0 || len(filterExcludes) > 0
+}
+
+// repoFilter selects repositories based on groups, tags and path globs.
+// Each kind of criterion matches if any of its values matches; a repository
+// is selected when every given kind matches and no exclude glob does.
+type repoFilter struct {
+	groups   []config.Group
+	tags     []string
+	paths    []string
+	excludes []string
+}
+
+// newRepoFilter builds a filter from the command line flags. The config is
+// only needed when groups are requested.
+func newRepoFilter(cfg *config.Config) (*repoFilter, error) {
+	f := &repoFilter{
+		tags:     filterTags,
+		paths:    filterPaths,
+		excludes: filterExcludes,
+	}
+
+	for _, name := range filterGroups {
+		if cfg == nil {
+			return nil, fmt.Errorf("group %q requested but no config is available", name)
+		}
+		// viper lower-cases map keys, so group names are case-insensitive
+		group, ok := cfg.Groups[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown group %q (available: %s)", name, strings.Join(groupNames(cfg), ", "))
+		}
+		f.groups = append(f.groups, group)
+	}
+
+	return f, nil
+}
+
+// groupNames returns the sorted names of the configured groups
+func groupNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Matches reports whether the repository is selected by the filter
+func (f *repoFilter) Matches(repo *git.Repository) bool {
+	for _, pattern := range f.excludes {
+		if config.MatchPath(repo.Path, pattern) {
+			return false
+		}
+	}
+
+	if len(f.groups) > 0 {
+		found := false
+		for _, group := range f.groups {
+			if group.Contains(repo.Path) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.tags) > 0 {
+		found := false
+		for _, tag := range f.tags {
+			if repo.HasTag(tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.paths) > 0 {
+		found := false
+		for _, pattern := range f.paths {
+			if config.MatchPath(repo.Path, pattern) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply returns the repositories selected by the filter
+func (f *repoFilter) Apply(repos []git.Repository) []git.Repository {
+	filtered := make([]git.Repository, 0, len(repos))
+	for i := range repos {
+		if f.Matches(&repos[i]) {
+			filtered = append(filtered, repos[i])
+		}
+	}
+	return filtered
+}
+
+// selectRepositories applies the command line filters to repos. The config
+// is only loaded when a group filter is given.
+func selectRepositories(repos []git.Repository) ([]git.Repository, error) {
+	if !hasFilters() {
+		return repos, nil
+	}
+
+	var cfg *config.Config
+	if len(filterGroups) > 0 {
+		var err error
+		if cfg, err = loadConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := newRepoFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return f.Apply(repos), nil
+}
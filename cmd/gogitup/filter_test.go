@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func resetFilters() {
+	filterGroups = nil
+	filterTags = nil
+	filterPaths = nil
+	filterExcludes = nil
+}
+
+func TestRepoFilter(t *testing.T) {
+	repos := []git.Repository{
+		{Path: "/code/work/api", Tags: []string{"backend"}},
+		{Path: "/code/work/web", Tags: []string{"frontend"}},
+		{Path: "/code/personal/blog"},
+		{Path: "/code/personal/infra", Tags: []string{"backend", "ops"}},
+	}
+
+	cfg := &config.Config{
+		Groups: map[string]config.Group{
+			"work": {Directories: []string{"/code/work"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		groups   []string
+		tags     []string
+		paths    []string
+		excludes []string
+		expected []string
+		wantErr  string
+	}{
+		{
+			name:     "no_filters",
+			expected: []string{"/code/work/api", "/code/work/web", "/code/personal/blog", "/code/personal/infra"},
+		},
+		{
+			name:     "group",
+			groups:   []string{"work"},
+			expected: []string{"/code/work/api", "/code/work/web"},
+		},
+		{
+			name:     "group_case_insensitive",
+			groups:   []string{"WORK"},
+			expected: []string{"/code/work/api", "/code/work/web"},
+		},
+		{
+			name:     "tag",
+			tags:     []string{"backend"},
+			expected: []string{"/code/work/api", "/code/personal/infra"},
+		},
+		{
+			name:     "group_and_tag",
+			groups:   []string{"work"},
+			tags:     []string{"backend"},
+			expected: []string{"/code/work/api"},
+		},
+		{
+			name:     "path_glob",
+			paths:    []string{"/code/personal/*"},
+			expected: []string{"/code/personal/blog", "/code/personal/infra"},
+		},
+		{
+			name:     "exclude",
+			tags:     []string{"backend"},
+			excludes: []string{"infra"},
+			expected: []string{"/code/work/api"},
+		},
+		{
+			name:    "unknown_group",
+			groups:  []string{"missing"},
+			wantErr: "unknown group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFilters()
+			defer resetFilters()
+			filterGroups = tt.groups
+			filterTags = tt.tags
+			filterPaths = tt.paths
+			filterExcludes = tt.excludes
+
+			f, err := newRepoFilter(cfg)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var paths []string
+			for _, repo := range f.Apply(repos) {
+				paths = append(paths, repo.Path)
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+func TestSelectRepositories_LoadsGroupsFromConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	err = os.WriteFile(configFile, []byte(`
+directories:
+  - /code
+groups:
+  oss:
+    repositories:
+      - /code/personal/blog
+`), 0644)
+	require.NoError(t, err)
+
+	useFiles(t, configFile, "")
+	resetFilters()
+	defer resetFilters()
+	filterGroups = []string{"oss"}
+
+	repos, err := selectRepositories([]git.Repository{
+		{Path: "/code/work/api"},
+		{Path: "/code/personal/blog"},
+	})
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, "/code/personal/blog", repos[0].Path)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+)
+
+var unignore bool
+
+func init() {
+	rootCmd.AddCommand(ignoreCmd)
+	ignoreCmd.Flags().BoolVarP(&unignore, "remove", "d", false, "stop ignoring the given paths and globs")
+}
+
+// ignorePattern returns the ignore pattern of a command line argument. Globs
+// without a path separator match the repository directory name and are kept
+// as they are, paths are made absolute.
+func ignorePattern(arg string) (string, error) {
+	if !strings.ContainsAny(arg, `/\`) && strings.ContainsAny(arg, "*?[") {
+		return arg, nil
+	}
+	path, err := filepath.Abs(config.ExpandPath(arg))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", arg, err)
+	}
+	return path, nil
+}
+
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore [path|glob]...",
+	Short: "Keep repositories out of the repository list",
+	Long: `Keep repositories out of the repository list, even though they are in a
+scanned directory. Ignored repositories are removed from the list right away
+and left out by every scan until they are no longer ignored.
+
+A path ignores the repository at that path, or every repository below it. A
+glob is matched like the paths of the repository settings: against the
+directory name of the repository when it has no slash, against the full path
+otherwise.
+
+Examples:
+  gogitup ignore ~/src/vendor/legacy    # ignore a repository
+  gogitup ignore '~/src/forks/*'        # ignore the repositories matching a glob
+  gogitup ignore '*-archive'            # ignore by directory name
+  gogitup ignore -d '*-archive'         # stop ignoring
+  gogitup ignore                        # list what is ignored`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			patterns, err := store.Ignored()
+			if err != nil {
+				return err
+			}
+			if len(patterns) == 0 {
+				fmt.Println("No repositories are ignored")
+			}
+			for _, pattern := range patterns {
+				fmt.Println(pattern)
+			}
+			return nil
+		}
+
+		patterns := make([]string, 0, len(args))
+		for _, arg := range args {
+			pattern, err := ignorePattern(arg)
+			if err != nil {
+				return err
+			}
+			patterns = append(patterns, pattern)
+		}
+
+		if unignore {
+			if err := store.Unignore(patterns...); err != nil {
+				return err
+			}
+			for _, pattern := range patterns {
+				fmt.Printf("No longer ignoring %s\n", pattern)
+			}
+			fmt.Println("Run 'gogitup scan' to find their repositories again")
+			return nil
+		}
+
+		removed, err := store.Ignore(patterns...)
+		if err != nil {
+			return err
+		}
+		for _, pattern := range patterns {
+			fmt.Printf("Ignoring %s\n", pattern)
+		}
+		fmt.Printf("Removed %d repositories from the repository list\n", removed)
+		return nil
+	},
+}
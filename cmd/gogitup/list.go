@@ -0,0 +1,292 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/pool"
+	"github.com/trutx/gogitup/internal/stale"
+)
+
+// States of a repository selectable with list --state
+const (
+	stateDirty    = "dirty"
+	stateDiverged = "diverged"
+	stateStale    = "stale"
+	stateErrored  = "errored"
+)
+
+var (
+	listColumns   []string
+	listSort      string
+	listReverse   bool
+	listStates    []string
+	listStaleDays int
+	listFormat    string
+	listThreads   int
+)
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringSliceVar(&listColumns, "columns", defaultListColumns, "columns of the table: "+strings.Join(listColumnNames(), ", "))
+	listCmd.Flags().StringVarP(&listSort, "sort", "s", "path", "sort by this column")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false, "reverse the sort order")
+	listCmd.Flags().StringArrayVar(&listStates, "state", nil, "only list repositories in this state: dirty, diverged, stale or errored (can be repeated)")
+	listCmd.Flags().IntVar(&listStaleDays, "stale-days", 90, "days without a checkout or commit after which a repository is stale")
+	listCmd.Flags().StringVarP(&listFormat, "format", "f", "", "format every repository with this Go template instead of the table")
+	listCmd.Flags().IntVarP(&listThreads, "threads", "t", runtime.NumCPU(), "number of repositories to inspect concurrently")
+	addFilterFlags(listCmd)
+}
+
+// listColumn is a column of the list table
+type listColumn struct {
+	name    string
+	header  string
+	value   func(git.Info) string
+	compare func(a, b git.Info) int
+}
+
+// formatDate formats a time as a date, "-" when it is unset
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// yesNo formats a flag of the list table
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// remoteURLs returns the remote URLs of info ordered by remote name
+func remoteURLs(info git.Info) string {
+	var urls []string
+	for _, name := range slices.Sorted(maps.Keys(info.Remotes)) {
+		urls = append(urls, info.Remotes[name])
+	}
+	return strings.Join(urls, ",")
+}
+
+// compareBool orders false before true
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// listTable are the columns of the list table, in their default order
+var listTable = []listColumn{
+	{"path", "PATH", func(i git.Info) string { return i.Path }, func(a, b git.Info) int { return cmp.Compare(a.Path, b.Path) }},
+	{"branch", "BRANCH", func(i git.Info) string { return cmp.Or(i.Branch, "(detached)") }, func(a, b git.Info) int { return cmp.Compare(a.Branch, b.Branch) }},
+	{"remotes", "REMOTES", remoteURLs, func(a, b git.Info) int { return cmp.Compare(remoteURLs(a), remoteURLs(b)) }},
+	{"fork", "FORK", func(i git.Info) string { return yesNo(i.Fork) }, func(a, b git.Info) int { return compareBool(a.Fork, b.Fork) }},
+	{"lfs", "LFS", func(i git.Info) string { return yesNo(i.LFS) }, func(a, b git.Info) int { return compareBool(a.LFS, b.LFS) }},
+	{"updated", "UPDATED", func(i git.Info) string { return formatDate(i.LastUpdated) }, func(a, b git.Info) int { return a.LastUpdated.Compare(b.LastUpdated) }},
+	{"outcome", "OUTCOME", func(i git.Info) string { return cmp.Or(i.LastOutcome, "-") }, func(a, b git.Info) int { return cmp.Compare(a.LastOutcome, b.LastOutcome) }},
+	{"size", "SIZE", func(i git.Info) string { return formatBytes(i.Size) }, func(a, b git.Info) int { return cmp.Compare(a.Size, b.Size) }},
+	{"commit", "LAST COMMIT", func(i git.Info) string { return formatDate(i.LastCommit) }, func(a, b git.Info) int { return a.LastCommit.Compare(b.LastCommit) }},
+}
+
+// defaultListColumns are the columns shown without --columns
+var defaultListColumns = listColumnNames()
+
+// listColumnNames returns the names of the list columns
+func listColumnNames() []string {
+	names := make([]string, 0, len(listTable))
+	for _, column := range listTable {
+		names = append(names, column.name)
+	}
+	return names
+}
+
+// findListColumn returns the named column of the list table
+func findListColumn(name string) (listColumn, error) {
+	for _, column := range listTable {
+		if column.name == strings.ToLower(name) {
+			return column, nil
+		}
+	}
+	return listColumn{}, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(listColumnNames(), ", "))
+}
+
+// stateMatcher returns a function reporting whether a repository is in any
+// of the given states, nil if no state is given
+func stateMatcher(states []string, staleDays int, now time.Time) (func(git.Info) bool, error) {
+	if len(states) == 0 {
+		return nil, nil
+	}
+
+	checks := make([]func(git.Info) bool, 0, len(states))
+	for _, state := range states {
+		switch strings.ToLower(state) {
+		case stateDirty:
+			checks = append(checks, func(i git.Info) bool { return i.Dirty })
+		case stateDiverged:
+			checks = append(checks, git.Info.Diverged)
+		case stateStale:
+			checks = append(checks, func(i git.Info) bool { return stale.Inactive(i.LastActivity, now, staleDays) })
+		case stateErrored:
+			checks = append(checks, git.Info.Failed)
+		default:
+			return nil, fmt.Errorf("unknown state %q (available: dirty, diverged, stale, errored)", state)
+		}
+	}
+
+	return func(info git.Info) bool {
+		return slices.ContainsFunc(checks, func(check func(git.Info) bool) bool { return check(info) })
+	}, nil
+}
+
+// writeListTable writes infos as a table of the given columns
+func writeListTable(w io.Writer, infos []git.Info, columns []listColumn) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, info := range infos {
+		values := make([]string, 0, len(columns))
+		for _, column := range columns {
+			values = append(values, column.value(info))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeListTemplate writes every info formatted with tmpl on a line of its own
+func writeListTemplate(w io.Writer, infos []git.Info, tmpl *template.Template) error {
+	for _, info := range infos {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, info); err != nil {
+			return fmt.Errorf("failed to format %s: %w", info.Path, err)
+		}
+		fmt.Fprintln(w, strings.TrimSuffix(b.String(), "\n"))
+	}
+	return nil
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tracked repositories and their state",
+	Long: `List the repositories in the repository list with their branch, remotes,
+fork and LFS status, the outcome of their last update, the size of their
+object database and the date of their last commit.
+
+States select repositories; a repository is listed when it is in any of them:
+
+  dirty      tracked files have uncommitted changes
+  diverged   the branch and the remote branch it is updated from both have
+             commits the other lacks
+  stale      no checkout or commit for --stale-days days, as 'gogitup stale'
+             finds without checking the remotes
+  errored    the last update failed
+
+--format replaces the table with a Go template executed for every repository,
+e.g. '{{.Path}}' to feed a fuzzy finder. The fields are Path, Branch, Remotes
+(a map of remote names to URLs), Fork, LFS, Tags, LastUpdated, LastOutcome,
+LastError, LastCommit, LastActivity, Size (in bytes), Dirty, Ahead and Behind, and the
+methods Diverged and Failed. The join function joins a list, e.g.
+'{{join .Tags ","}}'.
+
+Examples:
+  gogitup list --sort size --reverse
+  gogitup list --state dirty --state diverged --columns path,branch
+  cd "$(gogitup list --format '{{.Path}}' | fzf)"`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		columns := make([]listColumn, 0, len(listColumns))
+		for _, name := range listColumns {
+			column, err := findListColumn(name)
+			if err != nil {
+				return err
+			}
+			columns = append(columns, column)
+		}
+		sortColumn, err := findListColumn(listSort)
+		if err != nil {
+			return err
+		}
+		matches, err := stateMatcher(listStates, listStaleDays, time.Now())
+		if err != nil {
+			return err
+		}
+		var tmpl *template.Template
+		if listFormat != "" {
+			tmpl, err = template.New("format").Funcs(template.FuncMap{"join": strings.Join}).Parse(listFormat)
+			if err != nil {
+				return fmt.Errorf("invalid format: %w", err)
+			}
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+		repos, err = selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+
+		// The remote a repository is compared with depends on its settings
+		results := pool.Run(repos, listThreads, func(repo *git.Repository) git.Info {
+			if settings, err := cfg.SettingsFor(repo.Path); err == nil {
+				repo.Configure(settings)
+			}
+			return repo.Info(context.Background())
+		})
+		infos := make([]git.Info, 0, len(repos))
+		for info := range results {
+			if matches == nil || matches(info) {
+				infos = append(infos, info)
+			}
+		}
+
+		slices.SortStableFunc(infos, func(a, b git.Info) int {
+			c := cmp.Or(sortColumn.compare(a, b), cmp.Compare(a.Path, b.Path))
+			if listReverse {
+				return -c
+			}
+			return c
+		})
+
+		if tmpl != nil {
+			return writeListTemplate(os.Stdout, infos, tmpl)
+		}
+		return writeListTable(os.Stdout, infos, columns)
+	},
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func TestStateMatcher(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	infos := map[string]git.Info{
+		"clean":    {LastActivity: now.AddDate(0, 0, -1)},
+		"dirty":    {Dirty: true, LastActivity: now},
+		"diverged": {Ahead: 1, Behind: 2, LastActivity: now},
+		"ahead":    {Ahead: 1, LastActivity: now},
+		"stale":    {LastActivity: now.AddDate(0, 0, -31), LastCommit: now},
+		"unknown":  {LastCommit: now},
+		"errored":  {LastOutcome: git.OutcomeFailed, LastActivity: now},
+	}
+
+	tests := []struct {
+		states []string
+		want   []string
+	}{
+		{[]string{"dirty"}, []string{"dirty"}},
+		{[]string{"diverged"}, []string{"diverged"}},
+		{[]string{"stale"}, []string{"stale", "unknown"}},
+		{[]string{"Errored"}, []string{"errored"}},
+		{[]string{"dirty", "errored"}, []string{"dirty", "errored"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.states, ","), func(t *testing.T) {
+			matches, err := stateMatcher(tt.states, 30, now)
+			require.NoError(t, err)
+			var got []string
+			for name, info := range infos {
+				if matches(info) {
+					got = append(got, name)
+				}
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+
+	matches, err := stateMatcher(nil, 30, now)
+	require.NoError(t, err)
+	assert.Nil(t, matches)
+
+	_, err = stateMatcher([]string{"old"}, 30, now)
+	assert.ErrorContains(t, err, `unknown state "old"`)
+}
+
+func TestListCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	remote := filepath.Join(tmpDir, "remote.git")
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	gitRun("init", "-q", "--bare", remote)
+	clean := filepath.Join(tmpDir, "src", "clean")
+	gitRun("clone", "-q", remote, clean)
+	require.NoError(t, os.WriteFile(filepath.Join(clean, "a.txt"), []byte("a"), 0644))
+	gitRun("-C", clean, "add", "a.txt")
+	gitRun("-C", clean, "commit", "-q", "-m", "Initial commit")
+	gitRun("-C", clean, "push", "-q", "origin", "HEAD:master")
+	dirty := filepath.Join(tmpDir, "src", "dirty")
+	gitRun("clone", "-q", "-b", "master", remote, dirty)
+	require.NoError(t, os.WriteFile(filepath.Join(dirty, "a.txt"), []byte("changed"), 0644))
+
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	data, err := json.Marshal([]git.Repository{
+		{Path: dirty, LastOutcome: git.OutcomeFailed},
+		{Path: clean, Tags: []string{"work", "tools"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reposFile, data, 0644))
+	useFiles(t, "", reposFile)
+	t.Setenv("GOGITUP_PROFILE", "")
+
+	out, err := runCommand(t, listCmd)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^PATH\s+BRANCH\s+REMOTES\s+FORK\s+LFS\s+UPDATED\s+OUTCOME\s+SIZE\s+LAST COMMIT$`, lines[0])
+	assert.Regexp(t, `^`+clean+`\s+master\s+`+remote+`\s+no\s+no\s+-\s+-\s+`, lines[1])
+	assert.Regexp(t, `^`+dirty+`\s+master\s+.*failed`, lines[2])
+
+	out, err = runCommand(t, listCmd, "--format", `{{.Path}} {{.Dirty}} {{join .Tags ","}}`, "--sort", "path", "--reverse")
+	require.NoError(t, err)
+	assert.Equal(t, dirty+" true \n"+clean+" false work,tools\n", out)
+
+	out, err = runCommand(t, listCmd, "--state", "dirty", "--columns", "path,outcome")
+	require.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Regexp(t, `^`+dirty+`\s+failed$`, lines[1])
+
+	_, err = runCommand(t, listCmd, "--sort", "age")
+	assert.ErrorContains(t, err, `unknown column "age"`)
+	_, err = runCommand(t, listCmd, "--format", "{{.Path")
+	assert.ErrorContains(t, err, "invalid format")
+}
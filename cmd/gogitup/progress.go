@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/trutx/gogitup/internal/logging"
+	"github.com/trutx/gogitup/pkg/gogitup"
+	"golang.org/x/term"
+)
+
+// progress reports what the update workers are doing
+type progress interface {
+	// start marks the update of path as begun
+	start(path string)
+	// phase records the phase the update of path is in
+	phase(path, phase string)
+	// done marks the update of result.Path as finished
+	done(result gogitup.Result)
+	// log prints a message without disturbing the progress display
+	log(format string, args ...any)
+	// stop ends the progress display
+	stop()
+}
+
+// newProgress returns a live multi-line display for terminals, and plain
+// line logging otherwise
+func newProgress(out *os.File, total int) progress {
+	if isatty.IsTerminal(out.Fd()) {
+		width := func() int {
+			if w, _, err := term.GetSize(int(out.Fd())); err == nil && w > 0 {
+				return w
+			}
+			return 80
+		}
+		live := newLiveProgress(out, total, width)
+		// Log messages for stderr would break the display, they are printed
+		// above it instead
+		live.restoreLog = logging.RedirectStderr(logWriter{live})
+		return live
+	}
+	return newLineProgress(out, total)
+}
+
+// task is an update in progress
+type task struct {
+	path    string
+	phase   string
+	started time.Time
+}
+
+// progressState tracks the running and finished updates
+type progressState struct {
+	mu       sync.Mutex
+	total    int
+	finished int
+	started  time.Time
+	tasks    []*task
+}
+
+func (s *progressState) start(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, &task{path: path, phase: "starting", started: time.Now()})
+}
+
+func (s *progressState) phase(path, phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		if t.path == path {
+			t.phase = phase
+		}
+	}
+}
+
+// finish removes the task of path and returns how long it ran
+func (s *progressState) finish(path string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished++
+	for i, t := range s.tasks {
+		if t.path == path {
+			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
+			return time.Since(t.started)
+		}
+	}
+	return 0
+}
+
+// eta estimates the time left from the average duration of the finished
+// updates, it is 0 until the first update finished
+func (s *progressState) eta(now time.Time) time.Duration {
+	if s.finished == 0 || s.finished >= s.total {
+		return 0
+	}
+	perRepo := now.Sub(s.started) / time.Duration(s.finished)
+	return perRepo * time.Duration(s.total-s.finished)
+}
+
+// lineProgress logs one line per finished update, for output that is not a
+// terminal
+type lineProgress struct {
+	progressState
+	out io.Writer
+}
+
+func newLineProgress(out io.Writer, total int) *lineProgress {
+	return &lineProgress{progressState: progressState{total: total, started: time.Now()}, out: out}
+}
+
+func (p *lineProgress) done(result gogitup.Result) {
+	elapsed := p.finish(result.Path)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.out, "[%d/%d] %s %s (%s)\n", p.finished, p.total, result.Outcome(), result.Path, elapsed.Round(100*time.Millisecond))
+}
+
+func (p *lineProgress) log(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.out, format, args...)
+}
+
+func (p *lineProgress) stop() {}
+
+// liveProgress redraws a line per running update and an overall progress bar
+// below the regular output
+type liveProgress struct {
+	progressState
+	out   io.Writer
+	width func() int
+	// lines is the height of the last drawn display
+	lines int
+	frame int
+	quit  chan struct{}
+	wg    sync.WaitGroup
+	// restoreLog ends the redirection of the log to the display
+	restoreLog func()
+}
+
+func newLiveProgress(out io.Writer, total int, width func() int) *liveProgress {
+	p := &liveProgress{
+		progressState: progressState{total: total, started: time.Now()},
+		out:           out,
+		width:         width,
+		quit:          make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.quit:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.frame++
+				p.redraw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+	return p
+}
+
+func (p *liveProgress) done(result gogitup.Result) {
+	p.finish(result.Path)
+}
+
+func (p *liveProgress) log(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	fmt.Fprintf(p.out, format, args...)
+	p.draw()
+}
+
+func (p *liveProgress) stop() {
+	if p.restoreLog != nil {
+		p.restoreLog()
+	}
+	close(p.quit)
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
+
+// clear erases the last drawn display, the cursor is at its first line
+// afterwards
+func (p *liveProgress) clear() {
+	if p.lines > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.lines)
+		p.lines = 0
+	}
+}
+
+func (p *liveProgress) draw() {
+	lines := p.render(time.Now())
+	for _, line := range lines {
+		fmt.Fprintln(p.out, line)
+	}
+	p.lines = len(lines)
+}
+
+func (p *liveProgress) redraw() {
+	p.clear()
+	p.draw()
+}
+
+// render returns the lines of the display, the caller holds the lock
+func (p *liveProgress) render(now time.Time) []string {
+	width := p.width()
+	lines := make([]string, 0, len(p.tasks)+1)
+
+	// Phase and elapsed time take 25 columns, the path gets the rest
+	pathWidth := max(width-27, 10)
+	for _, t := range p.tasks {
+		lines = append(lines, fmt.Sprintf("%s %-*s %-12s %8s",
+			spinnerFrames[p.frame%len(spinnerFrames)],
+			pathWidth, truncate(t.path, pathWidth),
+			t.phase,
+			now.Sub(t.started).Round(100*time.Millisecond)))
+	}
+
+	status := fmt.Sprintf(" %d/%d", p.finished, p.total)
+	if eta := p.eta(now); eta > 0 {
+		status += fmt.Sprintf(" ETA %s", eta.Round(time.Second))
+	}
+	lines = append(lines, progressBar(p.finished, p.total, max(width-len(status)-1, 10))+status)
+	return lines
+}
+
+// logWriter prints log output through the progress display
+type logWriter struct {
+	p progress
+}
+
+func (w logWriter) Write(b []byte) (int, error) {
+	w.p.log("%s", b)
+	return len(b), nil
+}
+
+// progressBar renders done out of total as a bar of the given width
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(done*(width-2)/total, width-2)
+	}
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-2-filled) + "]"
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+func TestLineProgress(t *testing.T) {
+	var out bytes.Buffer
+	p := newLineProgress(&out, 2)
+
+	p.start("/src/alpha")
+	p.phase("/src/alpha", "fetching")
+	p.start("/src/beta")
+	p.done(gogitup.Result{Path: "/src/beta", Err: errors.New("boom")})
+	p.log("Error updating %s\n", "/src/beta")
+	p.done(gogitup.Result{Path: "/src/alpha"})
+	p.stop()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "[1/2] failed /src/beta ("), lines[0])
+	assert.Equal(t, "Error updating /src/beta", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "[2/2] updated /src/alpha ("), lines[2])
+	assert.Empty(t, p.tasks)
+}
+
+func TestLiveProgress_Render(t *testing.T) {
+	var out bytes.Buffer
+	p := newLiveProgress(&out, 4, func() int { return 60 })
+	defer p.stop()
+
+	p.start("/src/alpha")
+	p.start("/home/user/src/a/very/long/path/to/the/repository/beta")
+	p.phase("/src/alpha", "lfs")
+
+	p.mu.Lock()
+	p.started = time.Now().Add(-10 * time.Second)
+	lines := p.render(time.Now())
+	p.mu.Unlock()
+
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "/src/alpha")
+	assert.Contains(t, lines[0], "lfs")
+	assert.Contains(t, lines[1], "…")
+	assert.Contains(t, lines[1], "starting")
+	assert.Contains(t, lines[2], " 0/4")
+	assert.NotContains(t, lines[2], "ETA")
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), 60, line)
+	}
+
+	// The ETA is estimated once updates finished
+	p.done(gogitup.Result{Path: "/src/alpha"})
+	p.mu.Lock()
+	lines = p.render(p.started.Add(10 * time.Second))
+	p.mu.Unlock()
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "1/4 ETA 30s")
+}
+
+func TestLiveProgress_Log(t *testing.T) {
+	var out bytes.Buffer
+	p := newLiveProgress(&out, 1, func() int { return 40 })
+
+	p.start("/src/alpha")
+	p.log("first\n")
+	p.log("second\n")
+	p.done(gogitup.Result{Path: "/src/alpha"})
+	p.stop()
+
+	// Each message replaces the display drawn after the previous one, and
+	// stop erases the last one
+	output := out.String()
+	assert.Equal(t, 1, strings.Count(output, "first\n"))
+	assert.Contains(t, output, "first\n")
+	assert.Contains(t, output, "\x1b[2A\x1b[Jsecond\n")
+	assert.True(t, strings.HasSuffix(output, "\x1b[2A\x1b[J") || strings.HasSuffix(output, "\x1b[1A\x1b[J"), output)
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		done, total, width int
+		want               string
+	}{
+		{0, 4, 10, "[        ]"},
+		{2, 4, 10, "[====    ]"},
+		{4, 4, 10, "[========]"},
+		{0, 0, 4, "[  ]"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, progressBar(tt.done, tt.total, tt.width))
+	}
+}
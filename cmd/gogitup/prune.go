@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/pool"
+)
+
+var (
+	pruneYes     bool
+	pruneForce   bool
+	pruneThreads int
+)
+
+type pruneResult struct {
+	repo     *git.Repository
+	branches []git.StaleBranch
+	error    error
+}
+
+func init() {
+	rootCmd.AddCommand(pruneBranchesCmd)
+	pruneBranchesCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "delete the branches without asking for confirmation")
+	pruneBranchesCmd.Flags().BoolVar(&pruneForce, "force", false, "also delete branches with commits that are on no remote")
+	pruneBranchesCmd.Flags().IntVarP(&pruneThreads, "threads", "t", runtime.NumCPU(), "number of repositories to inspect concurrently")
+	addFilterFlags(pruneBranchesCmd)
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(in io.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+var pruneBranchesCmd = &cobra.Command{
+	Use:   "prune-branches",
+	Short: "Delete local branches that are merged or whose upstream is gone",
+	Long: `List the local branches of every repository that are already merged into the
+default branch, or whose upstream branch was deleted on the remote, and delete
+them after confirmation (or right away with --yes).
+
+The default branch is taken from the remote HEAD of origin, or of upstream for
+forks. The current branch is never deleted. Run 'gogitup update --prune' first
+so deleted remote branches are detected.
+
+Branches with commits that no remote branch contains are listed but kept, as
+deleting them loses those commits. Use --force to delete them as well.`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+		if len(repos) == 0 {
+			return fmt.Errorf("no repositories found. Run 'scan' first")
+		}
+		repos, err = selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+
+		results := pool.Run(repos, pruneThreads, func(repo *git.Repository) pruneResult {
+			result := pruneResult{repo: repo}
+			settings, err := cfg.SettingsFor(repo.Path)
+			if err != nil {
+				result.error = err
+				return result
+			}
+			repo.Configure(settings)
+			result.branches, result.error = repo.StaleBranches(context.Background())
+			return result
+		})
+
+		var stale []pruneResult
+		errors := make([]error, 0)
+		total := 0
+		for result := range results {
+			if result.error != nil {
+				errors = append(errors, fmt.Errorf("failed to inspect %s: %w", result.repo.Path, result.error))
+				continue
+			}
+			if len(result.branches) > 0 {
+				stale = append(stale, result)
+			}
+		}
+
+		// Commits that exist only in a branch are lost with it
+		kept := 0
+		deletable := make(map[*git.Repository][]string, len(stale))
+		for _, result := range stale {
+			fmt.Printf("\n%s:\n", result.repo.Path)
+			for _, branch := range result.branches {
+				if branch.Unpushed > 0 && !pruneForce {
+					fmt.Printf("- %s (%s, kept)\n", branch.Name, branch.Reason())
+					kept++
+					continue
+				}
+				fmt.Printf("- %s (%s)\n", branch.Name, branch.Reason())
+				deletable[result.repo] = append(deletable[result.repo], branch.Name)
+				total++
+			}
+		}
+		if kept > 0 {
+			fmt.Printf("\nKeeping %d branches with commits on no remote, use --force to delete them\n", kept)
+		}
+
+		if total == 0 {
+			if kept == 0 {
+				fmt.Println("\nNo stale branches found")
+			}
+		} else if pruneYes || confirm(os.Stdin, fmt.Sprintf("\nDelete %d branches in %d repositories?", total, len(deletable))) {
+			deleted := 0
+			for _, result := range stale {
+				names := deletable[result.repo]
+				if len(names) == 0 {
+					continue
+				}
+				if err := result.repo.DeleteBranches(context.Background(), names...); err != nil {
+					errors = append(errors, fmt.Errorf("failed to prune %s: %w", result.repo.Path, err))
+					continue
+				}
+				deleted += len(names)
+			}
+			fmt.Printf("\nDeleted %d branches\n", deleted)
+		} else {
+			fmt.Println("\nNo branches deleted")
+		}
+
+		if len(errors) > 0 {
+			fmt.Printf("\nEncountered %d errors:\n", len(errors))
+			for _, err := range errors {
+				fmt.Printf("- %v\n", err)
+			}
+			// Return error code without message since we already printed it
+			return fmt.Errorf("")
+		}
+
+		return nil
+	},
+}
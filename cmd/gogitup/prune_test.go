@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "y\n", expected: true},
+		{input: "YES\n", expected: true},
+		{input: "n\n", expected: false},
+		{input: "\n", expected: false},
+		{input: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.input), func(t *testing.T) {
+			assert.Equal(t, tt.expected, confirm(strings.NewReader(tt.input), "Continue?"))
+		})
+	}
+}
+
+func TestPruneBranchesCommand(t *testing.T) {
+	// Create temporary directory for test files
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	remoteDir := createBareRemote(t, filepath.Join(tmpDir, "remote.git"))
+	repoDir := filepath.Join(tmpDir, "repo")
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	out, err := exec.Command("git", "clone", "-q", "-b", "master", remoteDir, repoDir).CombinedOutput()
+	require.NoError(t, err, string(out))
+	git("branch", "merged-feature")
+
+	// A branch whose upstream is gone, with a commit that is on no remote
+	git("checkout", "-q", "-b", "gone-feature")
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "gone.txt"), []byte("gone"), 0644))
+	git("add", "gone.txt")
+	git("-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "-q", "-m", "Gone work")
+	git("push", "-q", "-u", "origin", "gone-feature")
+	git("push", "-q", "origin", "--delete", "gone-feature")
+	git("checkout", "-q", "master")
+	git("fetch", "-q", "--prune", "origin")
+
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	data, err := json.Marshal([]gitutil.Repository{{Path: repoDir, LastScanned: time.Now()}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reposFile, data, 0644))
+
+	useFiles(t, filepath.Join(tmpDir, "missing.yaml"), reposFile)
+
+	run := func(args ...string) {
+		t.Helper()
+		cmd := &cobra.Command{Use: "prune-branches"}
+		cmd.RunE = pruneBranchesCmd.RunE
+		cmd.PreRun = pruneBranchesCmd.PreRun
+		cmd.Flags().AddFlagSet(pruneBranchesCmd.Flags())
+		cmd.PersistentFlags().AddFlagSet(rootCmd.PersistentFlags())
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			f.Changed = false
+		})
+		pruneForce = false
+		cmd.SetArgs(args)
+		require.NoError(t, cmd.Execute())
+	}
+
+	// Unpushed work is kept unless --force is given
+	run("--yes")
+	assert.Equal(t, "gone-feature\nmaster", git("for-each-ref", "--format=%(refname:short)", "refs/heads"))
+
+	run("--yes", "--force")
+	assert.Equal(t, "master", git("for-each-ref", "--format=%(refname:short)", "refs/heads"))
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+// Supported report formats
+const (
+	reportMarkdown = "markdown"
+	reportHTML     = "html"
+	reportJUnit    = "junit"
+)
+
+// ansiEscape matches the color codes of the diff stats
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// updateReport is the outcome of an update run in a form the report formats
+// can render
+type updateReport struct {
+	Started  time.Time
+	Duration time.Duration
+	Counts   map[string]int
+	Entries  []reportEntry
+}
+
+// reportEntry is a repository in the report
+type reportEntry struct {
+	Path      string
+	Status    string
+	Message   string
+	Duration  time.Duration
+	Commits   []reportCommit
+	DiffStats string
+}
+
+// reportCommit is an incoming commit in the report
+type reportCommit struct {
+	Hash    string
+	Author  string
+	Subject string
+}
+
+// reportFormat returns the format of the report file from the flag, or from
+// the file extension when the flag is empty
+func reportFormat(file, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".md", ".markdown":
+			format = reportMarkdown
+		case ".html", ".htm":
+			format = reportHTML
+		case ".xml":
+			format = reportJUnit
+		default:
+			return "", fmt.Errorf("cannot tell the report format from %s, use --report-format", file)
+		}
+	}
+
+	switch format {
+	case reportMarkdown, reportHTML, reportJUnit:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown report format %q, must be one of markdown, html or junit", format)
+}
+
+// newUpdateReport builds the report of the results of a run that started at
+// started, sorted by repository path
+func newUpdateReport(results []gogitup.Result, started, finished time.Time) updateReport {
+	report := updateReport{
+		Started:  started,
+		Duration: finished.Sub(started),
+		Counts:   make(map[string]int),
+	}
+	for _, result := range results {
+		entry := reportEntry{
+			Path:      result.Path,
+			Status:    result.Status(),
+			Message:   result.Warning,
+			Duration:  result.Elapsed,
+			DiffStats: strings.TrimRight(ansiEscape.ReplaceAllString(result.DiffStats, ""), "\n"),
+		}
+		if result.Err != nil {
+			entry.Message = result.Err.Error()
+		}
+		for _, c := range result.Commits {
+			entry.Commits = append(entry.Commits, reportCommit{Hash: c.ShortHash(), Author: c.Author, Subject: c.Subject})
+		}
+		report.Counts[entry.Status]++
+		report.Entries = append(report.Entries, entry)
+	}
+	slices.SortFunc(report.Entries, func(a, b reportEntry) int { return strings.Compare(a.Path, b.Path) })
+	return report
+}
+
+// writeReport writes the report of results to file in the given format
+func writeReport(file, format string, results []gogitup.Result, started, finished time.Time) error {
+	report := newUpdateReport(results, started, finished)
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	switch format {
+	case reportMarkdown:
+		err = report.writeMarkdown(f)
+	case reportHTML:
+		err = report.writeHTML(f)
+	case reportJUnit:
+		err = report.writeJUnit(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// seconds formats a duration for the reports
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// writeMarkdown renders the report as Markdown
+func (r updateReport) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# gogitup update report\n\n")
+	fmt.Fprintf(&b, "Started %s, took %s.\n\n", r.Started.Format(time.DateTime), seconds(r.Duration))
+	fmt.Fprintf(&b, "| Updated | Up to date | Skipped | Failed |\n|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d |\n\n", r.Counts[gogitup.StatusUpdated], r.Counts[gogitup.StatusCurrent], r.Counts[gogitup.StatusSkipped], r.Counts[gogitup.StatusFailed])
+
+	fmt.Fprintf(&b, "| Repository | Status | Duration | Commits | Message |\n|---|---|---|---|---|\n")
+	for _, e := range r.Entries {
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", markdownCell(e.Path), e.Status, seconds(e.Duration), len(e.Commits), markdownCell(e.Message))
+	}
+
+	for _, e := range r.Entries {
+		if len(e.Commits) == 0 && e.DiffStats == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n", e.Path)
+		if len(e.Commits) > 0 {
+			b.WriteString("\n")
+			for _, c := range e.Commits {
+				fmt.Fprintf(&b, "- `%s` %s (%s)\n", c.Hash, c.Subject, c.Author)
+			}
+		}
+		if e.DiffStats != "" {
+			fmt.Fprintf(&b, "\n```\n%s\n```\n", e.DiffStats)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// htmlReport is the template of the HTML report
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": seconds,
+	"class":   func(status string) string { return strings.ReplaceAll(status, " ", "-") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>gogitup update report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 8px; }
+.updated { color: #1a7f37; }
+.skipped { color: #9a6700; }
+.failed { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>gogitup update report</h1>
+<p>Started {{.Started.Format "2006-01-02 15:04:05"}}, took {{seconds .Duration}}.</p>
+<p>{{index .Counts "updated"}} updated, {{index .Counts "up to date"}} up to date, {{index .Counts "skipped"}} skipped, {{index .Counts "failed"}} failed</p>
+<table>
+<tr><th>Repository</th><th>Status</th><th>Duration</th><th>Commits</th><th>Message</th></tr>
+{{- range .Entries}}
+<tr><td>{{.Path}}</td><td class="{{class .Status}}">{{.Status}}</td><td>{{seconds .Duration}}</td><td>{{len .Commits}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- range .Entries}}
+{{- if or .Commits .DiffStats}}
+<h2>{{.Path}}</h2>
+{{- if .Commits}}
+<ul>
+{{- range .Commits}}
+<li><code>{{.Hash}}</code> {{.Subject}} ({{.Author}})</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .DiffStats}}
+<pre>{{.DiffStats}}</pre>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML renders the report as a standalone HTML page
+func (r updateReport) writeHTML(w io.Writer) error {
+	return htmlReport.Execute(w, r)
+}
+
+// JUnit XML elements, every repository is a test case
+type (
+	junitSuites struct {
+		XMLName xml.Name     `xml:"testsuites"`
+		Suites  []junitSuite `xml:"testsuite"`
+	}
+	junitSuite struct {
+		Name      string      `xml:"name,attr"`
+		Tests     int         `xml:"tests,attr"`
+		Failures  int         `xml:"failures,attr"`
+		Skipped   int         `xml:"skipped,attr"`
+		Time      string      `xml:"time,attr"`
+		Timestamp string      `xml:"timestamp,attr"`
+		Cases     []junitCase `xml:"testcase"`
+	}
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitMessage `xml:"failure,omitempty"`
+		Skipped   *junitMessage `xml:"skipped,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// writeJUnit renders the report as JUnit XML
+func (r updateReport) writeJUnit(w io.Writer) error {
+	suite := junitSuite{
+		Name:      "gogitup update",
+		Tests:     len(r.Entries),
+		Failures:  r.Counts[gogitup.StatusFailed],
+		Skipped:   r.Counts[gogitup.StatusSkipped],
+		Time:      fmt.Sprintf("%.3f", r.Duration.Seconds()),
+		Timestamp: r.Started.Format(time.RFC3339),
+	}
+
+	for _, e := range r.Entries {
+		tc := junitCase{
+			Name:      e.Path,
+			Classname: "gogitup.update",
+			Time:      fmt.Sprintf("%.3f", e.Duration.Seconds()),
+		}
+		switch e.Status {
+		case gogitup.StatusFailed:
+			tc.Failure = &junitMessage{Message: e.Message, Text: e.Message}
+		case gogitup.StatusSkipped:
+			tc.Skipped = &junitMessage{Message: e.Message}
+		}
+
+		var out strings.Builder
+		for _, c := range e.Commits {
+			fmt.Fprintf(&out, "%s %s (%s)\n", c.Hash, c.Subject, c.Author)
+		}
+		if e.DiffStats != "" {
+			if out.Len() > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString(e.DiffStats + "\n")
+		}
+		tc.SystemOut = out.String()
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
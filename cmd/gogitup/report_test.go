@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+// testResults returns one result of every status
+func testResults() []gogitup.Result {
+	return []gogitup.Result{
+		{Path: "/src/failed", Err: errors.New("cannot fast-forward <main>"), Elapsed: 2 * time.Second},
+		{
+			Path:      "/src/updated",
+			Changed:   true,
+			Elapsed:   1500 * time.Millisecond,
+			DiffStats: " a.txt | 2 \x1b[32m++\x1b[m\n 1 file changed, 2 insertions(+)\n",
+			Commits:   []gitutil.Commit{{Hash: "0123456789abcdef", Author: "Jane", Subject: "Fix <b>bold</b> | pipes"}},
+		},
+		{Path: "/src/current", Elapsed: 300 * time.Millisecond},
+		{Path: "/src/dirty", Warning: "worktree contains uncommitted changes"},
+	}
+}
+
+func TestReportFormat(t *testing.T) {
+	tests := []struct {
+		file    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{file: "report.md", want: reportMarkdown},
+		{file: "report.HTML", want: reportHTML},
+		{file: "junit.xml", want: reportJUnit},
+		{file: "report.txt", format: "junit", want: reportJUnit},
+		{file: "report.txt", wantErr: "use --report-format"},
+		{file: "report.md", format: "pdf", wantErr: `unknown report format "pdf"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file+"/"+tt.format, func(t *testing.T) {
+			got, err := reportFormat(tt.file, tt.format)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewUpdateReport(t *testing.T) {
+	started := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	report := newUpdateReport(testResults(), started, started.Add(5*time.Second))
+
+	assert.Equal(t, 5*time.Second, report.Duration)
+	assert.Equal(t, map[string]int{gogitup.StatusUpdated: 1, gogitup.StatusCurrent: 1, gogitup.StatusSkipped: 1, gogitup.StatusFailed: 1}, report.Counts)
+
+	// Entries are sorted by path and diff stats lose their colors
+	require.Len(t, report.Entries, 4)
+	assert.Equal(t, "/src/current", report.Entries[0].Path)
+	assert.Equal(t, gogitup.StatusCurrent, report.Entries[0].Status)
+	assert.Equal(t, "cannot fast-forward <main>", report.Entries[2].Message)
+	updated := report.Entries[3]
+	assert.Equal(t, gogitup.StatusUpdated, updated.Status)
+	assert.Equal(t, " a.txt | 2 ++\n 1 file changed, 2 insertions(+)", updated.DiffStats)
+	assert.Equal(t, []reportCommit{{Hash: "0123456", Author: "Jane", Subject: "Fix <b>bold</b> | pipes"}}, updated.Commits)
+}
+
+func TestUpdateReport_Markdown(t *testing.T) {
+	started := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	require.NoError(t, newUpdateReport(testResults(), started, started.Add(5*time.Second)).writeMarkdown(&b))
+
+	out := b.String()
+	assert.Contains(t, out, "Started 2026-03-01 02:00:00, took 5.0s.")
+	assert.Contains(t, out, "| 1 | 1 | 1 | 1 |")
+	assert.Contains(t, out, "| /src/updated | updated | 1.5s | 1 |  |")
+	assert.Contains(t, out, "| /src/dirty | skipped | 0.0s | 0 | worktree contains uncommitted changes |")
+	assert.Contains(t, out, "## /src/updated\n\n- `0123456` Fix <b>bold</b> | pipes (Jane)\n")
+	assert.Contains(t, out, "```\n a.txt | 2 ++\n 1 file changed, 2 insertions(+)\n```")
+	assert.NotContains(t, out, "## /src/current")
+}
+
+func TestUpdateReport_HTML(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, newUpdateReport(testResults(), time.Now(), time.Now()).writeHTML(&b))
+
+	out := b.String()
+	assert.Contains(t, out, "1 updated, 1 up to date, 1 skipped, 1 failed")
+	assert.Contains(t, out, `<td class="up-to-date">up to date</td>`)
+	assert.Contains(t, out, "cannot fast-forward &lt;main&gt;")
+	assert.Contains(t, out, "Fix &lt;b&gt;bold&lt;/b&gt; | pipes")
+	assert.NotContains(t, out, "<b>bold</b>")
+}
+
+func TestUpdateReport_JUnit(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, newUpdateReport(testResults(), time.Now(), time.Now().Add(time.Second)).writeJUnit(&b))
+
+	var suites junitSuites
+	require.NoError(t, xml.Unmarshal(b.Bytes(), &suites))
+	require.Len(t, suites.Suites, 1)
+	suite := suites.Suites[0]
+	assert.Equal(t, 4, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	require.Len(t, suite.Cases, 4)
+
+	cases := make(map[string]junitCase)
+	for _, tc := range suite.Cases {
+		cases[tc.Name] = tc
+	}
+	assert.Nil(t, cases["/src/current"].Failure)
+	assert.Nil(t, cases["/src/current"].Skipped)
+	require.NotNil(t, cases["/src/failed"].Failure)
+	assert.Equal(t, "cannot fast-forward <main>", cases["/src/failed"].Failure.Message)
+	assert.Equal(t, "2.000", cases["/src/failed"].Time)
+	require.NotNil(t, cases["/src/dirty"].Skipped)
+	assert.Contains(t, cases["/src/updated"].SystemOut, "0123456 Fix <b>bold</b> | pipes (Jane)\n")
+	assert.Contains(t, cases["/src/updated"].SystemOut, "1 file changed")
+}
+
+func TestWriteReport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.xml")
+	require.NoError(t, writeReport(file, reportJUnit, testResults(), time.Now(), time.Now()))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<testsuite name="gogitup update" tests="4"`)
+
+	err = writeReport(filepath.Join(t.TempDir(), "missing", "report.md"), reportMarkdown, nil, time.Now(), time.Now())
+	assert.ErrorContains(t, err, "failed to create report")
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+var resolveStrategy string
+
+func init() {
+	rootCmd.AddCommand(resolveCmd)
+	resolveCmd.Flags().StringVarP(&resolveStrategy, "strategy", "s", "", "resolve without asking: reset, rebase or merge")
+}
+
+// formatDivergence describes a diverged branch with the commits of both
+// sides, every line indented by two spaces
+func formatDivergence(d *git.Divergence) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %s diverged from %s", d.Branch, d.RemoteBranch)
+	if d.MergeBase != "" {
+		fmt.Fprintf(&b, " after %s", color.YellowString(git.Commit{Hash: d.MergeBase}.ShortHash()))
+	}
+	b.WriteString("\n")
+
+	if d.Merged > 0 {
+		fmt.Fprintf(&b, "  local commits (%d, %d already merged):\n", d.LocalCount, d.Merged)
+	} else {
+		fmt.Fprintf(&b, "  local commits (%d):\n", d.LocalCount)
+	}
+	for _, c := range d.Local {
+		line := fmt.Sprintf("    %s %s", color.YellowString(c.ShortHash()), c.Subject)
+		if c.Merged {
+			line += color.GreenString(" (already merged)")
+		}
+		b.WriteString(line + "\n")
+	}
+	if hidden := d.LocalCount - len(d.Local); hidden > 0 {
+		fmt.Fprintf(&b, "    ... and %d more\n", hidden)
+	}
+
+	fmt.Fprintf(&b, "  %s commits (%d):\n", d.RemoteBranch, d.RemoteCount)
+	for _, c := range d.Remote {
+		fmt.Fprintf(&b, "    %s %s (%s)\n", color.YellowString(c.ShortHash()), c.Subject, c.Author)
+	}
+	if hidden := d.RemoteCount - len(d.Remote); hidden > 0 {
+		fmt.Fprintf(&b, "    ... and %d more\n", hidden)
+	}
+	return b.String()
+}
+
+// chooseResolution asks on in how to resolve d, returning "" to quit
+func chooseResolution(in io.Reader, d *git.Divergence) string {
+	recommended := func(how string) string {
+		switch {
+		case how == git.ResolveReset && d.AlreadyMerged():
+			return " (recommended, all local commits are already merged)"
+		case how == git.ResolveRebase && !d.AlreadyMerged():
+			return " (recommended)"
+		}
+		return ""
+	}
+
+	fmt.Println("\nHow do you want to resolve it?")
+	fmt.Printf("  [r] reset %s to %s, keeping the local commits in a backup branch%s\n", d.Branch, d.RemoteBranch, recommended(git.ResolveReset))
+	fmt.Printf("  [b] rebase the local commits onto %s%s\n", d.RemoteBranch, recommended(git.ResolveRebase))
+	fmt.Printf("  [m] merge %s into %s\n", d.RemoteBranch, d.Branch)
+	fmt.Println("  [q] quit")
+	fmt.Print("Choice [q]: ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return ""
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "r", git.ResolveReset:
+		return git.ResolveReset
+	case "b", git.ResolveRebase:
+		return git.ResolveRebase
+	case "m", git.ResolveMerge:
+		return git.ResolveMerge
+	}
+	return ""
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve <path>",
+	Short: "Resolve a branch that diverged from its remote branch",
+	Long: `Show how the checked out branch of a repository diverged from the remote
+branch it is updated from (upstream for forks, origin otherwise), and bring it
+up to date in one of three ways:
+
+  reset    move the branch to the remote branch, after creating a backup
+           branch <branch>-backup-<date> with the local commits
+  rebase   replay the local commits on top of the remote branch
+  merge    merge the remote branch into the branch
+
+Local commits whose changes the remote branch already has, e.g. because they
+were merged upstream as a squash or cherry-pick, are marked; when all of them
+are, resetting loses nothing. Failed rebases and merges are aborted, leaving
+the branch as it was. The remote is fetched first.
+
+Examples:
+  gogitup resolve ~/src/fork
+  gogitup resolve ~/src/fork --strategy rebase`,
+	Args:          cobra.ExactArgs(1),
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch resolveStrategy {
+		case "", git.ResolveReset, git.ResolveRebase, git.ResolveMerge:
+		default:
+			return fmt.Errorf("unknown strategy %q, must be one of reset, rebase or merge", resolveStrategy)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+		repo, err := findRepository(repos, args[0])
+		if err != nil {
+			return err
+		}
+		settings, err := cfg.SettingsFor(repo.Path)
+		if err != nil {
+			return err
+		}
+		repo.Configure(settings)
+
+		if err := repo.Fetch(ctx); err != nil {
+			slog.Warn("fetch failed, comparing with the last fetched state", "path", repo.Path, "error", err)
+		}
+		d, err := repo.Diverged(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", repo.Path, err)
+		}
+		if d == nil {
+			fmt.Printf("%s has not diverged from %s, nothing to resolve\n", repo.Path, repo.FetchRemote())
+			return nil
+		}
+
+		fmt.Printf("%s:\n%s", repo.Path, formatDivergence(d))
+		how := resolveStrategy
+		if how == "" {
+			if how = chooseResolution(os.Stdin, d); how == "" {
+				fmt.Println("\nNothing changed")
+				return nil
+			}
+		}
+
+		backup, err := repo.Resolve(ctx, *d, how)
+		if err != nil {
+			return err
+		}
+		switch how {
+		case git.ResolveReset:
+			fmt.Printf("\nReset %s to %s, the local commits are kept in branch %s\n", d.Branch, d.RemoteBranch, backup)
+		case git.ResolveRebase:
+			fmt.Printf("\nRebased %s onto %s\n", d.Branch, d.RemoteBranch)
+		case git.ResolveMerge:
+			fmt.Printf("\nMerged %s into %s\n", d.RemoteBranch, d.Branch)
+		}
+		return nil
+	},
+}
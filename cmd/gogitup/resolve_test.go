@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func TestChooseResolution(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "r\n", want: git.ResolveReset},
+		{input: "B\n", want: git.ResolveRebase},
+		{input: "merge\n", want: git.ResolveMerge},
+		{input: "q\n", want: ""},
+		{input: "\n", want: ""},
+		{input: "", want: ""},
+		{input: "x\n", want: ""},
+	}
+
+	d := &git.Divergence{Branch: "main", RemoteBranch: "upstream/main", LocalCount: 1, RemoteCount: 1}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, chooseResolution(strings.NewReader(tt.input), d))
+		})
+	}
+}
+
+func TestFormatDivergence(t *testing.T) {
+	d := &git.Divergence{
+		Branch:       "main",
+		RemoteBranch: "upstream/main",
+		MergeBase:    "1a2b3c4d5e6f",
+		Local: []git.DivergedCommit{
+			{Commit: git.Commit{Hash: "aaaaaaa1", Subject: "Local fix"}, Merged: true},
+			{Commit: git.Commit{Hash: "bbbbbbb2", Subject: "Local feature"}},
+		},
+		LocalCount:  3,
+		Merged:      1,
+		Remote:      []git.Commit{{Hash: "ccccccc3", Subject: "Upstream change", Author: "Jane"}},
+		RemoteCount: 1,
+	}
+
+	out := formatDivergence(d)
+	assert.Contains(t, out, "  main diverged from upstream/main after 1a2b3c4\n")
+	assert.Contains(t, out, "  local commits (3, 1 already merged):\n")
+	assert.Contains(t, out, "    aaaaaaa Local fix (already merged)\n")
+	assert.Contains(t, out, "    bbbbbbb Local feature\n")
+	assert.Contains(t, out, "    ... and 1 more\n")
+	assert.Contains(t, out, "  upstream/main commits (1):\n    ccccccc Upstream change (Jane)\n")
+}
+
+func TestResolveCommand(t *testing.T) {
+	for _, name := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+name+"_NAME", "Test User")
+		t.Setenv("GIT_"+name+"_EMAIL", "test@example.com")
+	}
+
+	tmpDir := t.TempDir()
+	scanned := filepath.Join(tmpDir, "src")
+	remote := createBareRemote(t, filepath.Join(tmpDir, "app.git"))
+	path := filepath.Join(scanned, "app")
+	other := filepath.Join(tmpDir, "other")
+	for _, dir := range []string{path, other} {
+		out, err := exec.Command("git", "clone", "-q", remote, dir).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run := func(dir string, args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+scanned+"\n"), 0644))
+	useFiles(t, configFile, filepath.Join(tmpDir, "repositories.json"))
+	t.Setenv("GOGITUP_PROFILE", "")
+	_, err := runCommand(t, scanCmd)
+	require.NoError(t, err)
+
+	out, err := runCommand(t, resolveCmd, path)
+	require.NoError(t, err)
+	assert.Contains(t, out, "has not diverged from origin, nothing to resolve")
+
+	// Both the remote and the clone get a commit of their own
+	require.NoError(t, os.WriteFile(filepath.Join(other, "remote.txt"), []byte("remote"), 0644))
+	run(other, "add", "remote.txt")
+	run(other, "commit", "-q", "-m", "Remote change")
+	run(other, "push", "-q", "origin", "HEAD")
+	require.NoError(t, os.WriteFile(filepath.Join(path, "local.txt"), []byte("local"), 0644))
+	run(path, "add", "local.txt")
+	run(path, "commit", "-q", "-m", "Local change")
+
+	_, err = runCommand(t, resolveCmd, path, "--strategy", "squash")
+	assert.ErrorContains(t, err, `unknown strategy "squash"`)
+
+	out, err = runCommand(t, resolveCmd, path, "--strategy", "rebase")
+	require.NoError(t, err)
+	assert.Contains(t, out, "local commits (1):\n")
+	assert.Contains(t, out, "Local change")
+	assert.Contains(t, out, "origin/master commits (1):\n")
+	assert.Contains(t, out, "Remote change")
+	assert.Contains(t, out, "Rebased master onto origin/master")
+	assert.FileExists(t, filepath.Join(path, "remote.txt"))
+	assert.FileExists(t, filepath.Join(path, "local.txt"))
+
+	out, err = runCommand(t, resolveCmd, path)
+	require.NoError(t, err)
+	assert.Contains(t, out, "nothing to resolve")
+}
@@ -2,16 +2,24 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
 	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/logging"
+	"github.com/trutx/gogitup/pkg/gogitup"
 )
 
 var (
 	configFile string
 	reposFile  string
+	profile    string
 	verbose    bool
+	logLevel   string
+	logFormat  string
+	logFile    string
 	rootCmd    = &cobra.Command{
 		Use:   "gogitup",
 		Short: "A tool to automatically update Git repositories",
@@ -21,22 +29,96 @@ It can handle both regular repositories and forks with upstream remotes.`,
 )
 
 func init() {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error getting user home directory:", err)
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "config file path (default $XDG_CONFIG_HOME/gogitup/config.yaml or ~/.gogitup.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&reposFile, "repos-file", "r", "", "repository list file path (default: the cache file of the profile)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "config profile to use (default $GOGITUP_PROFILE)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "show verbose output")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "log level: debug, info, warn or error (default warn)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", logging.FormatText, "log format: text or json")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "append the log to this file instead of stderr")
+	cobra.OnInitialize(initLogging)
+	rootCmd.AddCommand(scanCmd)
+	addFilterFlags(scanCmd)
+}
+
+// initLogging sets up the default logger from the logging flags
+func initLogging() {
+	if err := logging.Setup(logging.Options{Level: logLevel, Format: logFormat, File: logFile}); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
-	defaultConfig := fmt.Sprintf("%s/.gogitup.yaml", home)
+}
+
+// logInfoByDefault raises the log level to info unless --log-level is given,
+// for long running commands whose progress messages are their output
+func logInfoByDefault() {
+	if logLevel == "" {
+		logging.SetLevel(slog.LevelInfo)
+	}
+}
+
+// infof logs a formatted message at info level, for the Logf option of the
+// daemon and the watcher
+func infof(format string, args ...any) {
+	slog.Info(fmt.Sprintf(format, args...))
+}
+
+// profileName returns the profile selected with --profile or
+// GOGITUP_PROFILE, "" if none is
+func profileName() string {
+	if profile != "" {
+		return profile
+	}
+	return os.Getenv("GOGITUP_PROFILE")
+}
+
+// configPath returns the config file selected with --config, or the default
+// one
+func configPath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	return config.DefaultPath()
+}
 
-	// Get default repos file from git package
-	defaultReposFile, err := git.GetCacheFile()
+// loadConfig loads the config file selected with --config, or the default
+// one, with the selected profile applied
+func loadConfig() (*config.Config, error) {
+	path, err := configPath()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error getting default repos file:", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", defaultConfig, "config file path")
-	rootCmd.PersistentFlags().StringVarP(&reposFile, "repos-file", "r", defaultReposFile, "repository list file path")
-	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "show verbose output")
-	rootCmd.AddCommand(scanCmd)
+	cfg, err := config.LoadProfile(path, profileName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
+
+// openStore returns the repository list selected with --repos-file, or the
+// cache file of the selected profile
+func openStore() (*gogitup.Store, error) {
+	if reposFile != "" {
+		return gogitup.NewStore(reposFile), nil
+	}
+
+	// Without a profile the config is optional, commands such as tag work
+	// on the default cache file without one
+	name := profileName()
+	cfg, err := loadConfig()
+	switch {
+	case err == nil && cfg.CacheFile != "":
+		return gogitup.NewStore(cfg.CacheFile), nil
+	case err == nil:
+		name = cfg.Profile
+	case name != "":
+		return nil, err
+	}
+
+	path, err := git.ProfileCacheFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return gogitup.NewStore(path), nil
 }
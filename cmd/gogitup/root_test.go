@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useFiles points the --config and --repos-file flags at the given files
+// until the end of the test. Empty names select missing files in a
+// temporary directory, so that the user's files are never touched.
+func useFiles(t *testing.T, config, repos string) {
+	t.Helper()
+	if config == "" {
+		config = filepath.Join(t.TempDir(), "missing.yaml")
+	}
+	if repos == "" {
+		repos = filepath.Join(t.TempDir(), "repositories.json")
+	}
+	oldConfig, oldRepos := configFile, reposFile
+	configFile, reposFile = config, repos
+	t.Cleanup(func() { configFile, reposFile = oldConfig, oldRepos })
+}
+
+func TestOpenStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmpDir, "cache"))
+	t.Setenv("GOGITUP_PROFILE", "")
+	defer func() { profile = "" }()
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+directories:
+  - /code
+profiles:
+  work:
+    directories:
+      - /work
+    cache_file: `+filepath.Join(tmpDir, "work.json")+`
+  personal:
+    directories:
+      - /home
+`), 0644))
+	useFiles(t, configFile, "")
+	reposFile = ""
+
+	cacheDir, err := os.UserCacheDir()
+	require.NoError(t, err)
+
+	store, err := openStore()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "gogitup", "repositories.json"), store.Path())
+
+	profile = "work"
+	store, err = openStore()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmpDir, "work.json"), store.Path())
+
+	// The environment selects a profile unless the flag is given
+	profile = ""
+	t.Setenv("GOGITUP_PROFILE", "personal")
+	store, err = openStore()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "gogitup", "profiles", "personal", "repositories.json"), store.Path())
+
+	t.Setenv("GOGITUP_PROFILE", "other")
+	_, err = openStore()
+	assert.ErrorContains(t, err, `unknown profile "other"`)
+
+	// --repos-file wins over every profile
+	reposFile = filepath.Join(tmpDir, "repos.json")
+	store, err = openStore()
+	require.NoError(t, err)
+	assert.Equal(t, reposFile, store.Path())
+}
@@ -1,15 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
-	"github.com/trutx/gogitup/internal/config"
-	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/pkg/gogitup"
 )
 
 var scanCmd = &cobra.Command{
@@ -18,54 +17,62 @@ var scanCmd = &cobra.Command{
 	Long: `Scan all configured directories for Git repositories.
 This command will only scan and list repositories, without updating them.`,
 	SilenceUsage: true,
-	PreRun: func(cmd *cobra.Command, args []string) {
-		if err := viper.BindPFlag("config", cmd.Flags().Lookup("config")); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to bind config flag: %v\n", err)
-		}
-		if err := viper.BindPFlag("repos-file", cmd.Flags().Lookup("repos-file")); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to bind repos-file flag: %v\n", err)
-		}
-	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 		s.Suffix = " Found 0 repositories..."
 		s.Start()
 
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfig()
 		if err != nil {
 			s.Stop()
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
 		}
 
-		repos, err := git.FindRepositories(cfg.Directories, func(count int) {
-			s.Suffix = fmt.Sprintf(" Found %d repositories...", count)
-		})
+		store, err := openStore()
 		if err != nil {
 			s.Stop()
-			return fmt.Errorf("failed to find repositories: %w", err)
+			return err
 		}
 
-		// Save repositories to file
-		if err := git.SaveRepositories(repos); err != nil {
+		// Keep user-managed fields such as tags from the previous scan
+		scanner := gogitup.NewScanner(cfg.Directories...)
+		scanner.OnFound = func(count int) {
+			s.Suffix = fmt.Sprintf(" Found %d repositories...", count)
+		}
+		repos, err := scanner.Refresh(context.Background(), store)
+		if err != nil {
 			s.Stop()
-			return fmt.Errorf("failed to save repositories: %w", err)
+			return err
 		}
 
 		s.Stop()
 		fmt.Printf("\nFound %d repositories\n", len(repos))
 
+		// Filters only narrow down what is listed; the full list is saved
+		listed, err := selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+		if hasFilters() {
+			fmt.Printf("%d repositories match the given filters\n", len(listed))
+		}
+
 		if verbose {
 			fmt.Println("\nRepository list:")
-			for _, repo := range repos {
+			for _, repo := range listed {
 				upstreamStatus := ""
 				if repo.HasUpstream {
 					upstreamStatus = " (has upstream)"
 				}
-				fmt.Printf("- %s%s\n", repo.Path, upstreamStatus)
+				tags := ""
+				if len(repo.Tags) > 0 {
+					tags = fmt.Sprintf(" [%s]", strings.Join(repo.Tags, ", "))
+				}
+				fmt.Printf("- %s%s%s\n", repo.Path, upstreamStatus, tags)
 			}
 		}
 
-		fmt.Printf("\nResults saved to: %s\n", viper.GetString("repos-file"))
+		fmt.Printf("\nResults saved to: %s\n", store.Path())
 		return nil
 	},
 }
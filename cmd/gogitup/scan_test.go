@@ -14,7 +14,6 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	gitutil "github.com/trutx/gogitup/internal/git"
@@ -26,8 +25,8 @@ func setupTestCommand() *cobra.Command {
 	cmd.PreRun = scanCmd.PreRun
 
 	// Add all flags from the original command
-	cmd.Flags().String("config", "", "config file")
-	cmd.Flags().String("repos-file", "", "repositories file")
+	cmd.Flags().StringVar(&configFile, "config", configFile, "config file")
+	cmd.Flags().StringVar(&reposFile, "repos-file", reposFile, "repositories file")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 
 	return cmd
@@ -75,26 +74,18 @@ func TestScanCommand_Flags(t *testing.T) {
 				}
 			}()
 
-			// Reset viper
-			viper.Reset()
-
 			// Create test config file
 			configFile := filepath.Join(tmpDir, "config.yaml")
 			if tt.setupConfig {
 				err = os.WriteFile(configFile, []byte("directories: [\".\"]"), 0644)
 				require.NoError(t, err)
-
-				// Set up viper with the config file
-				viper.SetConfigFile(configFile)
-				err = viper.ReadInConfig()
-				require.NoError(t, err)
 			}
 
 			// Create repositories file
 			reposFile := filepath.Join(tmpDir, "repositories.json")
 			err = os.WriteFile(reposFile, []byte("[]"), 0644)
 			require.NoError(t, err)
-			viper.Set("repos-file", reposFile)
+			useFiles(t, configFile, reposFile)
 
 			// Create a new command instance for each test
 			cmd := setupTestCommand()
@@ -226,10 +217,7 @@ func TestScanCommand_RepositoryDiscovery(t *testing.T) {
 			err = os.WriteFile(reposFile, []byte("[]"), 0644)
 			require.NoError(t, err)
 
-			// Reset viper and set config
-			viper.Reset()
-			viper.Set("config", configFile)
-			viper.Set("repos-file", reposFile)
+			useFiles(t, configFile, reposFile)
 
 			// Create a new command instance
 			cmd := setupTestCommand()
@@ -345,10 +333,7 @@ func TestScanCommand_Output(t *testing.T) {
 			err = os.WriteFile(reposFile, []byte("[]"), 0644)
 			require.NoError(t, err)
 
-			// Reset viper and set config
-			viper.Reset()
-			viper.Set("config", configFile)
-			viper.Set("repos-file", reposFile)
+			useFiles(t, configFile, reposFile)
 
 			// Create a new command instance
 			cmd := setupTestCommand()
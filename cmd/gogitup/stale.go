@@ -0,0 +1,236 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/pool"
+	"github.com/trutx/gogitup/internal/stale"
+)
+
+var (
+	staleDays    int
+	staleOffline bool
+	staleArchive string
+	staleRemove  bool
+	staleYes     bool
+	staleThreads int
+)
+
+func init() {
+	rootCmd.AddCommand(staleCmd)
+	staleCmd.Flags().IntVar(&staleDays, "days", 90, "days without a checkout or commit after which a repository is stale")
+	staleCmd.Flags().BoolVar(&staleOffline, "offline", false, "skip the checks that connect to the remotes")
+	staleCmd.Flags().StringVar(&staleArchive, "archive", "", "move the stale repositories into this directory")
+	staleCmd.Flags().BoolVar(&staleRemove, "remove", false, "remove the stale repositories from the repository list and ignore them")
+	staleCmd.Flags().BoolVarP(&staleYes, "yes", "y", false, "archive or remove without asking for confirmation")
+	staleCmd.Flags().IntVarP(&staleThreads, "threads", "t", runtime.NumCPU(), "number of repositories to inspect concurrently")
+	addFilterFlags(staleCmd)
+}
+
+// printStaleReport prints why a repository is stale and the local work
+// keeping it from being archived or removed
+func printStaleReport(report stale.Report) {
+	fmt.Printf("\n%s:\n", report.Repository.Path)
+	for _, reason := range report.Reasons {
+		fmt.Printf("- %s\n", reason)
+	}
+	switch {
+	case report.WorkError != nil:
+		fmt.Printf("  kept, cannot check for unpushed work: %v\n", report.WorkError)
+	case !report.Work.Empty():
+		fmt.Printf("  kept, it has %s\n", report.Work)
+	}
+}
+
+// archiveRepositories moves the repositories into dir and returns the paths
+// they were moved from. Local work is checked again right before moving, it
+// may have appeared while the user was asked.
+func archiveRepositories(ctx context.Context, reports []stale.Report, dir string) ([]string, []error) {
+	var archived []string
+	var errors []error
+	for _, report := range reports {
+		repo := report.Repository
+		work, err := stale.CheckWork(ctx, repo)
+		if err == nil && !work.Empty() {
+			err = fmt.Errorf("it has %s", work)
+		}
+		if err != nil {
+			errors = append(errors, fmt.Errorf("not archiving %s: %w", repo.Path, err))
+			continue
+		}
+		target, err := stale.Archive(repo.Path, dir)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+		archived = append(archived, repo.Path)
+		fmt.Printf("Archived %s to %s\n", repo.Path, target)
+	}
+	return archived, errors
+}
+
+var staleCmd = &cobra.Command{
+	Use:   "stale",
+	Short: "Find repositories nobody works on anymore",
+	Long: `Report the repositories of the repository list that look abandoned:
+
+  - no checkout or commit for --days days. Activity is read from the reflog
+    of HEAD and from the commits of the configured user.email, so updates by
+    gogitup do not count.
+  - the remote repository no longer exists, or its host does not resolve
+  - the GitHub repository is archived
+
+For forks both origin and upstream are checked. --offline skips the remote
+checks, which run 'git ls-remote' and ask the GitHub API.
+
+--archive moves the stale repositories into a directory outside the scanned
+directories and drops them from the repository list. --remove drops them from
+the list and ignores them so scans do not add them again, leaving the files
+alone. Both ask for confirmation unless --yes is given. Repositories with
+unpushed commits, stashes, uncommitted changes or untracked files are never
+archived or removed.
+
+Examples:
+  gogitup stale --days 365
+  gogitup stale --archive ~/archive
+  gogitup stale --offline --remove`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if staleArchive != "" && staleRemove {
+			return fmt.Errorf("--archive and --remove cannot be used together")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+
+		var archiveDir string
+		if staleArchive != "" {
+			archiveDir, err = filepath.Abs(config.ExpandPath(staleArchive))
+			if err != nil {
+				return fmt.Errorf("failed to resolve path %s: %w", staleArchive, err)
+			}
+			if dir := scannedDirectory(cfg, archiveDir); dir != "" {
+				return fmt.Errorf("archive directory %s is in the scanned directory %s, scans would find the archived repositories again", archiveDir, dir)
+			}
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		all, err := store.Load()
+		if err != nil {
+			return err
+		}
+		if len(all) == 0 {
+			return fmt.Errorf("no repositories found. Run 'scan' first")
+		}
+		repos, err := selectRepositories(all)
+		if err != nil {
+			return err
+		}
+
+		opts := stale.Options{Days: staleDays, Offline: staleOffline, Now: time.Now()}
+		results := pool.Run(repos, staleThreads, func(repo *git.Repository) stale.Report {
+			if settings, err := cfg.SettingsFor(repo.Path); err == nil {
+				repo.Configure(settings)
+			}
+			return stale.Check(ctx, repo, opts)
+		})
+
+		var reports, safe []stale.Report
+		for report := range results {
+			if report.Stale() {
+				reports = append(reports, report)
+			}
+		}
+		slices.SortFunc(reports, func(a, b stale.Report) int {
+			return cmp.Compare(a.Repository.Path, b.Repository.Path)
+		})
+		for _, report := range reports {
+			printStaleReport(report)
+			if report.Safe() {
+				safe = append(safe, report)
+			}
+		}
+
+		if len(reports) == 0 {
+			fmt.Println("\nNo stale repositories found")
+			return nil
+		}
+		fmt.Printf("\nFound %d stale repositories, %d without unpushed work\n", len(reports), len(safe))
+
+		var errors []error
+		switch {
+		case archiveDir == "" && !staleRemove:
+			if len(safe) > 0 {
+				fmt.Println("Run with --archive <dir> or --remove to clean them up")
+			}
+		case len(safe) == 0:
+			fmt.Println("Nothing to clean up")
+		case archiveDir != "":
+			if !staleYes && !confirm(os.Stdin, fmt.Sprintf("\nMove %d repositories to %s?", len(safe), archiveDir)) {
+				fmt.Println("\nNo repositories archived")
+				break
+			}
+			var archived []string
+			archived, errors = archiveRepositories(ctx, safe, archiveDir)
+			if len(archived) > 0 {
+				err := store.Update(func(repos []git.Repository) ([]git.Repository, error) {
+					return slices.DeleteFunc(repos, func(repo git.Repository) bool {
+						return slices.Contains(archived, repo.Path)
+					}), nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			fmt.Printf("\nArchived %d repositories\n", len(archived))
+		default:
+			if !staleYes && !confirm(os.Stdin, fmt.Sprintf("\nRemove %d repositories from the repository list?", len(safe))) {
+				fmt.Println("\nNo repositories removed")
+				break
+			}
+			paths := make([]string, 0, len(safe))
+			for _, report := range safe {
+				paths = append(paths, report.Repository.Path)
+			}
+			if _, err := store.Ignore(paths...); err != nil {
+				return err
+			}
+			for _, path := range paths {
+				fmt.Printf("Removed %s\n", path)
+			}
+			fmt.Printf("\nRemoved %d repositories, 'gogitup ignore -d <path>' lets scans find them again\n", len(paths))
+		}
+
+		if len(errors) > 0 {
+			fmt.Printf("\nEncountered %d errors:\n", len(errors))
+			for _, err := range errors {
+				fmt.Printf("- %v\n", err)
+			}
+			// Return error code without message since we already printed it
+			return fmt.Errorf("")
+		}
+		return nil
+	},
+}
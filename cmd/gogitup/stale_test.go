@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+)
+
+func TestStaleCommand(t *testing.T) {
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "Test User")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "test@example.com")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// Two clones of remotes that are deleted, one of them with a local commit
+	tmpDir := t.TempDir()
+	scanned := filepath.Join(tmpDir, "src")
+	gone, busy := filepath.Join(scanned, "gone"), filepath.Join(scanned, "busy")
+	for _, path := range []string{gone, busy} {
+		remote := createBareRemote(t, filepath.Join(tmpDir, filepath.Base(path)+".git"))
+		git("clone", "-q", remote, path)
+		require.NoError(t, os.RemoveAll(remote))
+	}
+	git("-C", busy, "commit", "-q", "--allow-empty", "-m", "Local change")
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+scanned+"\n"), 0644))
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	useFiles(t, configFile, reposFile)
+	t.Setenv("GOGITUP_PROFILE", "")
+	_, err := runCommand(t, scanCmd)
+	require.NoError(t, err)
+
+	out, err := runCommand(t, staleCmd)
+	require.NoError(t, err)
+	assert.Contains(t, out, gone+":\n- remote origin ("+filepath.Join(tmpDir, "gone.git")+") no longer exists\n")
+	assert.Contains(t, out, busy+":\n- remote origin")
+	assert.Contains(t, out, "  kept, it has 1 unpushed commits\n")
+	assert.Contains(t, out, "Found 2 stale repositories, 1 without unpushed work")
+	assert.Contains(t, out, "Run with --archive <dir> or --remove")
+
+	// Offline only inactivity counts
+	out, err = runCommand(t, staleCmd, "--offline")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No stale repositories found")
+
+	_, err = runCommand(t, staleCmd, "--archive", filepath.Join(scanned, "archive"))
+	assert.ErrorContains(t, err, "is in the scanned directory "+scanned)
+
+	_, err = runCommand(t, staleCmd, "--archive", tmpDir, "--remove")
+	assert.ErrorContains(t, err, "cannot be used together")
+
+	// Repositories with unpushed work are neither archived nor removed
+	archiveDir := filepath.Join(tmpDir, "archive")
+	out, err = runCommand(t, staleCmd, "--archive", archiveDir, "--yes")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Archived "+gone+" to "+filepath.Join(archiveDir, "gone"))
+	assert.DirExists(t, filepath.Join(archiveDir, "gone"))
+	assert.NoDirExists(t, gone)
+	assert.DirExists(t, busy)
+
+	repos, err := gitutil.NewStore(reposFile).Load()
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, busy, repos[0].Path)
+
+	out, err = runCommand(t, staleCmd, "--remove", "--yes")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Nothing to clean up")
+	repos, err = gitutil.NewStore(reposFile).Load()
+	require.NoError(t, err)
+	assert.Len(t, repos, 1)
+}
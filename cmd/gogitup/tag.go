@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+var removeTags bool
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.Flags().BoolVarP(&removeTags, "remove", "d", false, "remove the given tags instead of adding them")
+}
+
+// findRepository returns the cached repository at path
+func findRepository(repos []git.Repository, path string) (*git.Repository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+	for i := range repos {
+		if repos[i].Path == absPath {
+			return &repos[i], nil
+		}
+	}
+	return nil, fmt.Errorf("repository %s is not in the repository list. Run 'scan' first", absPath)
+}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag <path> [tag...]",
+	Short: "Add, remove or list tags of a repository",
+	Long: `Manage the free-form tags of a repository in the repository list.
+
+Tags are kept across scans and can be used with --tag to select repositories
+in every command.
+
+Examples:
+  gogitup tag ~/code/api work backend    # add tags
+  gogitup tag -d ~/code/api backend      # remove a tag
+  gogitup tag ~/code/api                 # list tags`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		tags := args[1:]
+		if len(tags) == 0 {
+			repos, err := store.Load()
+			if err != nil {
+				return err
+			}
+			repo, err := findRepository(repos, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(strings.Join(repo.Tags, "\n"))
+			return nil
+		}
+
+		// Repositories that cannot be opened right now keep their entries
+		var repo git.Repository
+		err = store.Update(func(repos []git.Repository) ([]git.Repository, error) {
+			found, err := findRepository(repos, args[0])
+			if err != nil {
+				return nil, err
+			}
+			if removeTags {
+				found.RemoveTags(tags...)
+			} else {
+				found.AddTags(tags...)
+			}
+			repo = *found
+			return repos, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Tags for %s: %s\n", repo.Path, strings.Join(repo.Tags, ", "))
+		return nil
+	},
+}
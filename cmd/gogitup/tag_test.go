@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitutil "github.com/trutx/gogitup/internal/git"
+)
+
+func TestTagCommand(t *testing.T) {
+	// Create temporary directory for test files
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	_, err = git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	missing := filepath.Join(tmpDir, "missing")
+	data, err := json.Marshal([]gitutil.Repository{
+		{Path: repoDir, LastScanned: time.Now()},
+		{Path: missing, Manual: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reposFile, data, 0644))
+
+	useFiles(t, "", reposFile)
+
+	run := func(args ...string) error {
+		cmd := &cobra.Command{Use: "tag"}
+		cmd.RunE = tagCmd.RunE
+		cmd.PreRun = tagCmd.PreRun
+		cmd.Args = tagCmd.Args
+		cmd.Flags().AddFlagSet(tagCmd.Flags())
+		cmd.PersistentFlags().AddFlagSet(rootCmd.PersistentFlags())
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			f.Changed = false
+		})
+		removeTags = false
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	loadTags := func() []string {
+		repos, err := gitutil.NewStore(reposFile).Load()
+		require.NoError(t, err)
+		require.Len(t, repos, 1)
+		return repos[0].Tags
+	}
+
+	require.NoError(t, run(repoDir, "work", "backend", "work"))
+	assert.Equal(t, []string{"backend", "work"}, loadTags())
+
+	require.NoError(t, run("--remove", repoDir, "backend"))
+	assert.Equal(t, []string{"work"}, loadTags())
+
+	err = run(filepath.Join(tmpDir, "unknown"), "work")
+	assert.ErrorContains(t, err, "is not in the repository list")
+
+	// Repository filters are refused instead of being ignored
+	err = run("-g", "work", repoDir, "frontend")
+	assert.ErrorContains(t, err, "unknown shorthand flag: 'g'")
+	assert.Equal(t, []string{"work"}, loadTags())
+
+	// Tagging leaves the entries that cannot be opened alone
+	_, broken, err := gitutil.NewStore(reposFile).Inspect()
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Equal(t, missing, broken[0].Path)
+}
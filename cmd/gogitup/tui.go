@@ -0,0 +1,532 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/logging"
+	"github.com/trutx/gogitup/internal/manifest"
+	"github.com/trutx/gogitup/internal/pool"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+// States of a repository in the update TUI
+const (
+	rowQueued  = "queued"
+	rowRunning = "running"
+	rowUpdated = gogitup.StatusUpdated
+	rowCurrent = gogitup.StatusCurrent
+	rowSkipped = gogitup.StatusSkipped
+	rowFailed  = gogitup.StatusFailed
+)
+
+// spinnerFrames animate the rows of running updates
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// tuiRow is a repository in the update TUI
+type tuiRow struct {
+	repo    *git.Repository
+	branch  string
+	state   string
+	started time.Time
+	elapsed time.Duration
+	result  gogitup.Result
+	// claimed is set by the worker starting the update or by the user
+	// skipping it, whichever comes first
+	claimed atomic.Bool
+}
+
+// Messages driving the update TUI
+type (
+	repoStartedMsg struct {
+		index int
+		at    time.Time
+	}
+	repoFinishedMsg struct {
+		index   int
+		result  gogitup.Result
+		state   string
+		elapsed time.Duration
+	}
+	tickMsg        time.Time
+	shellExitedMsg struct{ err error }
+)
+
+// tuiModel is the bubbletea model of 'update --tui'
+type tuiModel struct {
+	// updater updates the rows, stasher stashes local changes around it
+	updater *gogitup.Updater
+	stasher *gogitup.Updater
+	rows    []*tuiRow
+	events  chan tea.Msg
+	threads int
+	// ctx is cancelled when the user quits, which aborts the running
+	// updates; wg waits for them
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	cursor   int
+	offset   int
+	detail   bool
+	scroll   int
+	width    int
+	height   int
+	frame    int
+	status   string
+	quitting bool
+}
+
+// newTUIModel returns the model for updating repos with the given updater
+// options
+func newTUIModel(cfg *config.Config, repos []git.Repository, opts gogitup.Options) *tuiModel {
+	// The detail view lists the incoming commits of every update
+	opts.Commits = true
+	stashOpts := opts
+	stashOpts.Stash = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &tuiModel{
+		ctx:     ctx,
+		cancel:  cancel,
+		updater: gogitup.NewUpdater(cfg, opts),
+		stasher: gogitup.NewUpdater(cfg, stashOpts),
+		events:  make(chan tea.Msg, 2*len(repos)+1),
+		threads: opts.Threads,
+		width:   80,
+		height:  24,
+	}
+	for i := range repos {
+		branch := repos[i].CurrentBranch()
+		m.rows = append(m.rows, &tuiRow{repo: &repos[i], branch: branch, state: rowQueued})
+	}
+	return m
+}
+
+// Init starts the updates of all repositories
+func (m *tuiModel) Init() tea.Cmd {
+	indexes := make([]int, len(m.rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		results := pool.Run(indexes, m.threads, func(i *int) repoFinishedMsg {
+			return m.updateRow(*i, false)
+		})
+		for result := range results {
+			m.send(result)
+		}
+	}()
+	return tea.Batch(m.waitForEvent(), tick())
+}
+
+// waitForEvent delivers the next worker event to the model
+func (m *tuiModel) waitForEvent() tea.Cmd {
+	return func() tea.Msg { return <-m.events }
+}
+
+// send passes a worker event to the model, dropping it once the user quit
+func (m *tuiModel) send(msg tea.Msg) {
+	select {
+	case m.events <- msg:
+	case <-m.ctx.Done():
+	}
+}
+
+// quit aborts the running updates and ends the TUI
+func (m *tuiModel) quit() tea.Cmd {
+	m.cancel()
+	return tea.Quit
+}
+
+// tick refreshes the elapsed times and spinners of running updates
+func tick() tea.Cmd {
+	return tea.Tick(100*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// updateRow updates the repository of row i, stashing local changes first if
+// stash is set. It runs on a worker goroutine and only reads the row, apart
+// from claiming it.
+func (m *tuiModel) updateRow(i int, stash bool) repoFinishedMsg {
+	repo := m.rows[i].repo
+	if !m.rows[i].claimed.CompareAndSwap(false, true) {
+		return repoFinishedMsg{index: i, state: rowSkipped, result: gogitup.Result{Path: repo.Path, Warning: "skipped by user"}}
+	}
+	start := time.Now()
+	m.send(repoStartedMsg{index: i, at: start})
+
+	updater := m.updater
+	if stash {
+		updater = m.stasher
+	}
+	result := updater.Update(m.ctx, repo)
+	return repoFinishedMsg{index: i, result: result, state: result.Status(), elapsed: time.Since(start)}
+}
+
+// running returns the number of updates in progress or queued
+func (m *tuiModel) running() int {
+	count := 0
+	for _, row := range m.rows {
+		if row.state == rowRunning || row.state == rowQueued {
+			count++
+		}
+	}
+	return count
+}
+
+// results returns the final result of every row
+func (m *tuiModel) results() []gogitup.Result {
+	results := make([]gogitup.Result, 0, len(m.rows))
+	for _, row := range m.rows {
+		if row.state == rowQueued || row.state == rowRunning {
+			continue
+		}
+		results = append(results, row.result)
+	}
+	return results
+}
+
+// Update handles worker events and key presses
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case repoStartedMsg:
+		row := m.rows[msg.index]
+		row.state = rowRunning
+		row.started = msg.at
+		row.elapsed = 0
+		return m, m.waitForEvent()
+	case repoFinishedMsg:
+		row := m.rows[msg.index]
+		row.state = msg.state
+		row.result = msg.result
+		row.elapsed = msg.elapsed
+		if m.running() == 0 {
+			m.status = "All updates finished"
+		}
+		return m, m.waitForEvent()
+	case tickMsg:
+		m.frame++
+		for _, row := range m.rows {
+			if row.state == rowRunning {
+				row.elapsed = time.Since(row.started)
+			}
+		}
+		return m, tick()
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case shellExitedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Shell failed: %v", msg.err)
+		}
+		return m, nil
+	case tea.KeyMsg:
+		if m.detail {
+			return m.detailKey(msg)
+		}
+		return m.tableKey(msg)
+	}
+	return m, nil
+}
+
+// tableKey handles key presses in the repository table
+func (m *tuiModel) tableKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	row := m.rows[m.cursor]
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "q", "esc":
+		if m.running() > 0 && !m.quitting {
+			m.quitting = true
+			m.status = "Updates are still running, press q again to abort them and quit"
+			return m, nil
+		}
+		return m, m.quit()
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.rows) - 1
+	case "enter":
+		m.detail = true
+		m.scroll = 0
+	case "r":
+		m.retry(m.cursor, false)
+	case "R":
+		for i, r := range m.rows {
+			if r.state == rowFailed {
+				m.retry(i, false)
+			}
+		}
+	case "s":
+		m.retry(m.cursor, true)
+	case "x":
+		// A worker may have started the update before its event arrived
+		if row.state != rowQueued || !row.claimed.CompareAndSwap(false, true) {
+			m.status = "Only queued updates can be skipped"
+			return m, nil
+		}
+		row.state = rowSkipped
+		row.result = gogitup.Result{Path: row.repo.Path, Warning: "skipped by user"}
+	case "o":
+		return m, m.openShell(row)
+	}
+	m.quitting = false
+	return m, nil
+}
+
+// detailKey handles key presses in the detail view
+func (m *tuiModel) detailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "q", "esc", "enter":
+		m.detail = false
+	case "up", "k":
+		if m.scroll > 0 {
+			m.scroll--
+		}
+	case "down", "j":
+		m.scroll++
+	case "r", "s", "o":
+		m.detail = false
+		return m.tableKey(msg)
+	}
+	return m, nil
+}
+
+// retry runs the update of row i again in the background
+func (m *tuiModel) retry(i int, stash bool) {
+	row := m.rows[i]
+	if row.state == rowRunning || row.state == rowQueued {
+		m.status = "The update is still running"
+		return
+	}
+	row.state = rowQueued
+	row.claimed.Store(false)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.send(m.updateRow(i, stash))
+	}()
+}
+
+// openShell suspends the TUI and opens the user's shell in the repository
+func (m *tuiModel) openShell(row *tuiRow) tea.Cmd {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+		if runtime.GOOS == "windows" {
+			shell = "cmd"
+		}
+	}
+	cmd := exec.Command(shell)
+	cmd.Dir = row.repo.Path
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return shellExitedMsg{err: err} })
+}
+
+// View renders the table or the detail view
+func (m *tuiModel) View() string {
+	if m.detail {
+		return m.detailView()
+	}
+	return m.tableView()
+}
+
+// stateLabel renders the state of a row with its color
+func (m *tuiModel) stateLabel(state string) string {
+	switch state {
+	case rowRunning:
+		return color.CyanString("%s %-10s", spinnerFrames[m.frame%len(spinnerFrames)], state)
+	case rowUpdated:
+		return color.GreenString("✓ %-10s", state)
+	case rowCurrent:
+		return fmt.Sprintf("✓ %-10s", state)
+	case rowSkipped:
+		return color.YellowString("- %-10s", state)
+	case rowFailed:
+		return color.RedString("✗ %-10s", state)
+	}
+	return fmt.Sprintf("  %-10s", state)
+}
+
+// tableView renders the repository table
+func (m *tuiModel) tableView() string {
+	counts := make(map[string]int)
+	for _, row := range m.rows {
+		counts[row.state]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "gogitup update: %d repositories, %d running, %d queued, %d updated, %d up to date, %d skipped, %d failed\n\n",
+		len(m.rows), counts[rowRunning], counts[rowQueued], counts[rowUpdated], counts[rowCurrent], counts[rowSkipped], counts[rowFailed])
+
+	// Header, footer and status take five lines
+	visible := max(m.height-5, 1)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+
+	// Fixed columns: cursor, state, branch, commits and elapsed time
+	branchWidth := 20
+	pathWidth := max(m.width-2-13-branchWidth-9-9-4, 10)
+	for i := m.offset; i < len(m.rows) && i < m.offset+visible; i++ {
+		row := m.rows[i]
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		commits := ""
+		if len(row.result.Commits) > 0 {
+			commits = fmt.Sprintf("+%d", len(row.result.Commits))
+		}
+		elapsed := ""
+		if row.elapsed > 0 {
+			elapsed = row.elapsed.Round(100 * time.Millisecond).String()
+		}
+		line := fmt.Sprintf("%s%s %-*s %-*s %8s %8s", cursor, m.stateLabel(row.state),
+			pathWidth, truncate(manifest.ShortenHome(row.repo.Path), pathWidth),
+			branchWidth, truncate(row.branch, branchWidth),
+			commits, elapsed)
+		if i == m.cursor {
+			line = color.New(color.Bold).Sprint(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n" + m.status + "\n")
+	b.WriteString("↑/↓ move  enter details  r retry  R retry failed  s stash & update  o shell  x skip  q quit")
+	return b.String()
+}
+
+// detailView renders the details of the selected repository
+func (m *tuiModel) detailView() string {
+	row := m.rows[m.cursor]
+
+	var lines []string
+	lines = append(lines, color.New(color.Bold).Sprint(row.repo.Path))
+	lines = append(lines, fmt.Sprintf("State:   %s", m.stateLabel(row.state)))
+	if row.branch != "" {
+		lines = append(lines, fmt.Sprintf("Branch:  %s", row.branch))
+	}
+	if row.elapsed > 0 {
+		lines = append(lines, fmt.Sprintf("Elapsed: %s", row.elapsed.Round(100*time.Millisecond)))
+	}
+	if row.result.Err != nil {
+		lines = append(lines, "", color.RedString("Error:"))
+		lines = append(lines, strings.Split(strings.TrimSpace(row.result.Err.Error()), "\n")...)
+	}
+	if row.result.Divergence != nil {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(strings.TrimRight(formatDivergence(row.result.Divergence), "\n"), "\n")...)
+	}
+	if row.result.Warning != "" {
+		lines = append(lines, "", color.YellowString("Warning: %s", row.result.Warning))
+	}
+	if len(row.result.Commits) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Incoming commits (%d):", len(row.result.Commits)))
+		for _, c := range row.result.Commits {
+			lines = append(lines, fmt.Sprintf("  %s %s (%s)", color.YellowString(c.ShortHash()), c.Subject, c.Author))
+		}
+	}
+	if row.result.DiffStats != "" {
+		lines = append(lines, "", "Changes:")
+		lines = append(lines, strings.Split(strings.TrimRight(row.result.DiffStats, "\n"), "\n")...)
+	}
+
+	// Keep the last page on screen when scrolling past the end
+	visible := max(m.height-2, 1)
+	if m.scroll > len(lines)-visible {
+		m.scroll = max(len(lines)-visible, 0)
+	}
+	end := min(m.scroll+visible, len(lines))
+
+	return strings.Join(lines[m.scroll:end], "\n") + "\n\n" +
+		"↑/↓ scroll  r retry  s stash & update  o shell  esc back"
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(runes[:width])
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
+
+// runUpdateTUI updates repos in the interactive TUI and returns the final
+// results once the user quits. Updates still running then are aborted, and
+// waited for so that no git process outlives the command.
+func runUpdateTUI(cfg *config.Config, repos []git.Repository, opts gogitup.Options) ([]gogitup.Result, error) {
+	m := newTUIModel(cfg, repos, opts)
+	// The TUI owns the terminal and shows the failures itself, log messages
+	// for stderr are dropped unless they go to a log file
+	defer logging.RedirectStderr(io.Discard)()
+	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	m.cancel()
+	m.wg.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run the TUI: %w", err)
+	}
+	return m.results(), nil
+}
+
+// printTUISummary records the outcomes of the TUI session and lists the
+// repositories that still failed when the user quit
+func printTUISummary(cfg *config.Config, store *gogitup.Store, results []gogitup.Result) error {
+	recordRun(cfg, store, results)
+
+	var failed []gogitup.Result
+	updated, skipped := 0, 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed = append(failed, result)
+		case result.Warning != "":
+			skipped++
+		default:
+			updated++
+		}
+	}
+	fmt.Printf("Updated %d repositories, skipped %d\n", updated, skipped)
+
+	if len(failed) > 0 {
+		fmt.Printf("\nEncountered %d errors:\n", len(failed))
+		for _, result := range failed {
+			fmt.Printf("- failed to update %s: %v\n", result.Path, result.Err)
+		}
+		fmt.Printf("\nError: failed to update some repositories\n")
+		// Return error code without message since we already printed it
+		return fmt.Errorf("")
+	}
+	return nil
+}
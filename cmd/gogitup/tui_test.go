@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/config"
+	gitutil "github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+// key returns the key message for a key name as typed by the user
+func key(name string) tea.KeyMsg {
+	switch name {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+func newTestTUIModel() *tuiModel {
+	return newTUIModel(&config.Config{}, []gitutil.Repository{
+		{Path: "/src/alpha"},
+		{Path: "/src/beta"},
+		{Path: "/src/gamma"},
+	}, gogitup.Options{Threads: 1})
+}
+
+func TestTUIModel_WorkerEvents(t *testing.T) {
+	m := newTestTUIModel()
+
+	m.Update(repoStartedMsg{index: 0, at: time.Now()})
+	assert.Equal(t, rowRunning, m.rows[0].state)
+	assert.Equal(t, 3, m.running())
+
+	m.Update(repoFinishedMsg{
+		index: 0,
+		state: rowUpdated,
+		result: gogitup.Result{
+			Path:      "/src/alpha",
+			DiffStats: " a.txt | 1 +",
+			Commits:   []gitutil.Commit{{Hash: "0123456789abcdef", Author: "Jane", Subject: "Fix the build"}},
+		},
+		elapsed: 1500 * time.Millisecond,
+	})
+	m.Update(repoFinishedMsg{index: 1, state: rowFailed, result: gogitup.Result{Path: "/src/beta", Err: errors.New("remote hung up")}})
+	assert.Equal(t, rowUpdated, m.rows[0].state)
+	assert.Equal(t, rowFailed, m.rows[1].state)
+	assert.Empty(t, m.status)
+
+	// Unfinished rows are left out of the results
+	results := m.results()
+	require.Len(t, results, 2)
+	assert.Equal(t, "/src/alpha", results[0].Path)
+
+	view := m.View()
+	assert.Contains(t, view, "3 repositories, 0 running, 1 queued, 1 updated, 0 up to date, 0 skipped, 1 failed")
+	assert.Contains(t, view, "/src/alpha")
+	assert.Contains(t, view, "+1")
+	assert.Contains(t, view, "1.5s")
+
+	m.Update(repoFinishedMsg{index: 2, state: rowCurrent, result: gogitup.Result{Path: "/src/gamma"}})
+	assert.Equal(t, "All updates finished", m.status)
+}
+
+func TestTUIModel_Keys(t *testing.T) {
+	m := newTestTUIModel()
+
+	// The cursor stays within the table
+	m.Update(key("k"))
+	assert.Equal(t, 0, m.cursor)
+	m.Update(key("j"))
+	m.Update(key("j"))
+	m.Update(key("j"))
+	assert.Equal(t, 2, m.cursor)
+	m.Update(key("g"))
+	assert.Equal(t, 0, m.cursor)
+
+	// Queued updates can be skipped, running ones cannot
+	m.Update(key("x"))
+	assert.Equal(t, rowSkipped, m.rows[0].state)
+	assert.True(t, m.rows[0].claimed.Load())
+	assert.Equal(t, "skipped by user", m.rows[0].result.Warning)
+
+	m.Update(repoStartedMsg{index: 1, at: time.Now()})
+	m.Update(key("j"))
+	m.Update(key("x"))
+	assert.Equal(t, rowRunning, m.rows[1].state)
+	assert.Equal(t, "Only queued updates can be skipped", m.status)
+
+	// Running updates are not retried
+	m.Update(key("r"))
+	assert.Equal(t, rowRunning, m.rows[1].state)
+	assert.Equal(t, "The update is still running", m.status)
+
+	// Quitting while updates run needs confirmation
+	_, cmd := m.Update(key("q"))
+	assert.Nil(t, cmd)
+	assert.Contains(t, m.status, "press q again")
+	require.NoError(t, m.ctx.Err())
+	_, cmd = m.Update(key("q"))
+	require.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+
+	// Quitting aborts the running updates
+	assert.ErrorIs(t, m.ctx.Err(), context.Canceled)
+}
+
+func TestTUIModel_Detail(t *testing.T) {
+	m := newTestTUIModel()
+	m.Update(repoFinishedMsg{
+		index: 0,
+		state: rowFailed,
+		result: gogitup.Result{
+			Path:    "/src/alpha",
+			Err:     errors.New("merge conflict\nin a.txt"),
+			Commits: []gitutil.Commit{{Hash: "0123456789abcdef", Author: "Jane", Subject: "Fix the build"}},
+		},
+	})
+
+	m.Update(key("enter"))
+	assert.True(t, m.detail)
+	view := m.View()
+	assert.Contains(t, view, "/src/alpha")
+	assert.Contains(t, view, "merge conflict")
+	assert.Contains(t, view, "in a.txt")
+	assert.Contains(t, view, "0123456 Fix the build (Jane)")
+
+	// Scrolling stops at the last page
+	for range 10 {
+		m.Update(key("j"))
+	}
+	m.View()
+	assert.Zero(t, m.scroll)
+
+	m.Update(key("esc"))
+	assert.False(t, m.detail)
+	assert.Contains(t, m.View(), "enter details")
+}
+
+func TestTUIModel_UpdateRow(t *testing.T) {
+	tmpDir := t.TempDir()
+	remoteDir := createBareRemote(t, filepath.Join(tmpDir, "remote.git"))
+	localDir := filepath.Join(tmpDir, "local")
+	out, err := exec.Command("git", "clone", "-q", remoteDir, localDir).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	repo, err := gitutil.OpenRepository(localDir)
+	require.NoError(t, err)
+	resetFilters()
+
+	m := newTUIModel(&config.Config{}, []gitutil.Repository{*repo}, gogitup.Options{Threads: 1})
+	assert.NotEmpty(t, m.rows[0].branch)
+
+	msg := m.updateRow(0, false)
+	started, ok := (<-m.events).(repoStartedMsg)
+	require.True(t, ok)
+	assert.Equal(t, 0, started.index)
+	assert.NoError(t, msg.result.Err)
+	assert.Equal(t, rowCurrent, msg.state)
+	assert.Empty(t, msg.result.Commits)
+
+	// A row started by a worker before its event arrived cannot be skipped
+	assert.Equal(t, rowQueued, m.rows[0].state)
+	m.Update(key("x"))
+	assert.Equal(t, rowQueued, m.rows[0].state)
+	assert.Equal(t, "Only queued updates can be skipped", m.status)
+
+	// Skipped rows are not updated when a worker reaches them
+	m.rows[0].claimed.Store(false)
+	m.Update(key("x"))
+	assert.Equal(t, rowSkipped, m.rows[0].state)
+	msg = m.updateRow(0, false)
+	assert.Equal(t, rowSkipped, msg.state)
+	assert.Empty(t, m.events)
+
+	// Once the user quit, updates fail right away
+	m.rows[0].claimed.Store(false)
+	m.cancel()
+	msg = m.updateRow(0, false)
+	assert.ErrorIs(t, msg.result.Err, context.Canceled)
+}
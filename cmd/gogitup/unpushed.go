@@ -0,0 +1,160 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/pool"
+)
+
+var (
+	unpushedLimit   int
+	unpushedThreads int
+)
+
+type unpushedResult struct {
+	repo     *git.Repository
+	unpushed git.Unpushed
+	error    error
+}
+
+func init() {
+	rootCmd.AddCommand(unpushedCmd)
+	unpushedCmd.Flags().IntVar(&unpushedLimit, "limit", 10, "maximum number of unpushed commits listed per repository (0 lists all)")
+	unpushedCmd.Flags().IntVarP(&unpushedThreads, "threads", "t", runtime.NumCPU(), "number of repositories to inspect concurrently")
+	addFilterFlags(unpushedCmd)
+}
+
+// printUnpushed prints the unpushed work of a repository
+func printUnpushed(path string, unpushed git.Unpushed) {
+	fmt.Printf("\n%s:\n", path)
+	if len(unpushed.Branches) > 0 {
+		fmt.Println("  branches:")
+		for _, branch := range unpushed.Branches {
+			fmt.Printf("    %s: %s\n", branch.Name, branch.Reason())
+		}
+	}
+	if unpushed.CommitCount > 0 {
+		fmt.Printf("  commits on no remote (%d):\n", unpushed.CommitCount)
+		for _, c := range unpushed.Commits {
+			fmt.Printf("    %s %s\n", color.YellowString(c.ShortHash()), c.Subject)
+		}
+		if hidden := unpushed.CommitCount - len(unpushed.Commits); hidden > 0 {
+			fmt.Printf("    ... and %d more\n", hidden)
+		}
+	}
+	for _, list := range []struct {
+		title string
+		items []string
+	}{
+		{"stashes", unpushed.Stashes},
+		{"untracked files", unpushed.Untracked},
+		{"modified files", unpushed.Modified},
+	} {
+		if len(list.items) == 0 {
+			continue
+		}
+		fmt.Printf("  %s:\n", list.title)
+		for _, item := range list.items {
+			fmt.Printf("    %s\n", item)
+		}
+	}
+}
+
+var unpushedCmd = &cobra.Command{
+	Use:   "unpushed",
+	Short: "Report work that exists only locally",
+	Long: `Report the work in every repository that would be lost with it:
+
+  - local branches ahead of their upstream, without an upstream, or whose
+    upstream is gone
+  - commits of local branches, or of a detached HEAD, on no remote branch
+  - stashes
+  - untracked files, leaving out ignored ones, and uncommitted changes
+
+Remote branches are compared as of the last fetch, run 'gogitup update' first
+for an up to date report. The command fails if anything is found, so it can
+gate scripts, e.g. before wiping a machine:
+
+  gogitup unpushed && echo "everything is pushed"`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		cfg, err := loadConfig()
+		if err != nil {
+			cfg = &config.Config{}
+		}
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		repos, err := store.Load()
+		if err != nil {
+			return err
+		}
+		if len(repos) == 0 {
+			return fmt.Errorf("no repositories found. Run 'scan' first")
+		}
+		repos, err = selectRepositories(repos)
+		if err != nil {
+			return err
+		}
+
+		results := pool.Run(repos, unpushedThreads, func(repo *git.Repository) unpushedResult {
+			if settings, err := cfg.SettingsFor(repo.Path); err == nil {
+				repo.Configure(settings)
+			}
+			unpushed, err := repo.Unpushed(ctx, unpushedLimit)
+			return unpushedResult{repo: repo, unpushed: unpushed, error: err}
+		})
+
+		var found []unpushedResult
+		errors := make([]error, 0)
+		for result := range results {
+			switch {
+			case result.error != nil:
+				errors = append(errors, fmt.Errorf("failed to inspect %s: %w", result.repo.Path, result.error))
+			case !result.unpushed.Empty():
+				found = append(found, result)
+			}
+		}
+		slices.SortFunc(found, func(a, b unpushedResult) int {
+			return cmp.Compare(a.repo.Path, b.repo.Path)
+		})
+		for _, result := range found {
+			printUnpushed(result.repo.Path, result.unpushed)
+		}
+
+		if len(found) == 0 {
+			fmt.Println("\nNo unpushed work found")
+		} else {
+			fmt.Printf("\nFound unpushed work in %d of %d repositories\n", len(found), len(repos))
+		}
+
+		if len(errors) > 0 {
+			fmt.Printf("\nEncountered %d errors:\n", len(errors))
+			for _, err := range errors {
+				fmt.Printf("- %v\n", err)
+			}
+		}
+		if len(found) > 0 || len(errors) > 0 {
+			// Return error code without message since we already printed it
+			return fmt.Errorf("")
+		}
+		return nil
+	},
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpushedCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	scanned := filepath.Join(tmpDir, "src")
+	var paths []string
+	for _, name := range []string{"clean", "work"} {
+		path := filepath.Join(scanned, name)
+		remote := createBareRemote(t, filepath.Join(tmpDir, name+".git"))
+		out, err := exec.Command("git", "clone", "-q", remote, path).CombinedOutput()
+		require.NoError(t, err, string(out))
+		paths = append(paths, path)
+	}
+	clean, work := paths[0], paths[1]
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte("directories:\n  - "+scanned+"\n"), 0644))
+	useFiles(t, configFile, filepath.Join(tmpDir, "repositories.json"))
+	t.Setenv("GOGITUP_PROFILE", "")
+	_, err := runCommand(t, scanCmd)
+	require.NoError(t, err)
+
+	out, err := runCommand(t, unpushedCmd)
+	require.NoError(t, err)
+	assert.Contains(t, out, "No unpushed work found")
+
+	// Anything found fails the command
+	require.NoError(t, os.WriteFile(filepath.Join(work, "notes.txt"), []byte("notes"), 0644))
+	out, err = runCommand(t, unpushedCmd)
+	require.Error(t, err)
+	assert.Contains(t, out, work+":\n  untracked files:\n    notes.txt\n")
+	assert.NotContains(t, out, clean+":")
+	assert.Contains(t, out, "Found unpushed work in 1 of 2 repositories")
+}
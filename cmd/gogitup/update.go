@@ -1,66 +1,187 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
-	"sync"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"github.com/trutx/gogitup/internal/config"
 	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/metrics"
+	"github.com/trutx/gogitup/pkg/gogitup"
 )
 
 var (
-	showStats bool
-	threads   int
-	noScan    bool
+	showStats  bool
+	threads    int
+	noScan     bool
+	prune      bool
+	submodules bool
+	tui        bool
+	showLog    bool
+	logLimit   int
+	reportFile string
+	reportType string
+	metricsOut string
 )
 
-type updateResult struct {
-	path      string
-	error     error
-	warning   string
-	diffStats string
-}
-
 func init() {
 	rootCmd.AddCommand(updateCmd)
 	updateCmd.Flags().IntVarP(&threads, "threads", "t", runtime.NumCPU(), "number of concurrent repository updates")
 	updateCmd.Flags().BoolVarP(&showStats, "stat", "s", false, "show git diff stats for updated repositories")
 	updateCmd.Flags().BoolVar(&noScan, "no-scan", false, "disable automatic repository scan before update")
+	updateCmd.Flags().BoolVar(&prune, "prune", false, "remove remote-tracking refs of branches deleted on the remotes, --prune=false overrides the config")
+	updateCmd.Flags().BoolVar(&submodules, "submodules", false, "initialize and update submodules after updating each repository")
+	updateCmd.Flags().BoolVar(&showLog, "log", false, "list the commits pulled into every updated repository")
+	updateCmd.Flags().IntVar(&logLimit, "log-limit", 0, "maximum number of commits listed per repository with --log (0 lists all)")
+	updateCmd.Flags().StringVar(&reportFile, "report", "", "write a report of the run to this file")
+	updateCmd.Flags().StringVar(&reportType, "report-format", "", "report format: markdown, html or junit (default: from the file extension)")
+	updateCmd.Flags().StringVar(&metricsOut, "metrics-file", "", "write Prometheus metrics to this file for the node_exporter textfile collector")
+	updateCmd.Flags().BoolVar(&tui, "tui", false, "show an interactive table of the updates to review and act on the results")
+	addFilterFlags(updateCmd)
 }
 
-// runScan executes the scan command
-func runScan() error {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+// formatBytes formats a byte count using binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
 	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
 
+// scanRepositories finds the repositories in the configured directories and
+// saves them to store, keeping the user-managed fields of the previous scan
+func scanRepositories(ctx context.Context, cfg *config.Config, store *gogitup.Store, progress func(count int)) ([]git.Repository, error) {
+	scanner := gogitup.NewScanner(cfg.Directories...)
+	scanner.OnFound = progress
+	return scanner.Refresh(ctx, store)
+}
+
+// runScan scans the configured directories into store behind a spinner
+func runScan(cfg *config.Config, store *gogitup.Store) error {
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 	s.Suffix = " Found 0 repositories..."
 	s.Start()
 	defer s.Stop()
 
-	repos, err := git.FindRepositories(cfg.Directories, func(count int) {
+	repos, err := scanRepositories(context.Background(), cfg, store, func(count int) {
 		s.Suffix = fmt.Sprintf(" Found %d repositories...", count)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to find repositories: %w", err)
-	}
-
-	if err := git.SaveRepositories(repos); err != nil {
-		return fmt.Errorf("failed to save repositories: %w", err)
+		return err
 	}
 
 	fmt.Printf("\nFound %d repositories\n", len(repos))
 	return nil
 }
 
+// updateOptions returns the updater options set by the update flags and the
+// config file. pruneSet tells whether --prune was given at all.
+func updateOptions(cfg *config.Config, threads int, pruneSet bool) gogitup.Options {
+	opts := gogitup.Options{
+		Threads:    threads,
+		Submodules: submodules,
+		Commits:    showLog || cfg.Log.Enabled || reportFile != "",
+		Behind:     metricsFile(cfg) != "" || cfg.Metrics.Enabled(),
+	}
+	// Without --prune the repository settings decide, --prune=false
+	// overrides them as well
+	if pruneSet {
+		opts.Prune = &prune
+	}
+	return opts
+}
+
+// formatCommitLog lists the incoming commits of the results grouped by
+// repository, showing at most limit commits per repository unless limit is 0
+func formatCommitLog(results []gogitup.Result, limit int) string {
+	sorted := slices.Clone(results)
+	slices.SortFunc(sorted, func(a, b gogitup.Result) int { return strings.Compare(a.Path, b.Path) })
+
+	var b strings.Builder
+	for _, result := range sorted {
+		if len(result.Commits) == 0 {
+			continue
+		}
+		noun := "commits"
+		if len(result.Commits) == 1 {
+			noun = "commit"
+		}
+		fmt.Fprintf(&b, "\n%s (%d new %s):\n", result.Path, len(result.Commits), noun)
+
+		shown := result.Commits
+		if limit > 0 && len(shown) > limit {
+			shown = shown[:limit]
+		}
+		for _, c := range shown {
+			fmt.Fprintf(&b, "  %s %s (%s)\n", color.YellowString(c.ShortHash()), c.Subject, c.Author)
+		}
+		if hidden := len(result.Commits) - len(shown); hidden > 0 {
+			fmt.Fprintf(&b, "  ... and %d more\n", hidden)
+		}
+	}
+	return b.String()
+}
+
+// printDivergences describes the branches that failed to update because they
+// diverged, sorted by repository
+func printDivergences(results []gogitup.Result) {
+	sorted := slices.Clone(results)
+	slices.SortFunc(sorted, func(a, b gogitup.Result) int { return strings.Compare(a.Path, b.Path) })
+	for _, result := range sorted {
+		if result.Divergence == nil {
+			continue
+		}
+		fmt.Printf("\n%s:\n%s", result.Path, formatDivergence(result.Divergence))
+		fmt.Printf("  run 'gogitup resolve %s' to reset, rebase or merge\n", result.Path)
+	}
+}
+
+// metricsFile returns the path of the Prometheus textfile from the flag or
+// the config file, or "" if none is configured
+func metricsFile(cfg *config.Config) string {
+	if metricsOut != "" {
+		return config.ExpandPath(metricsOut)
+	}
+	if cfg.Metrics.Textfile != "" {
+		return config.ExpandPath(cfg.Metrics.Textfile)
+	}
+	return ""
+}
+
+// recordRun saves the outcomes of a run and writes the metrics textfile if
+// one is configured. Failures are reported as warnings since the updates
+// themselves are done.
+func recordRun(cfg *config.Config, store *gogitup.Store, results []gogitup.Result) {
+	repos, err := store.Record(results, time.Now())
+	if err != nil {
+		slog.Warn("failed to record update outcomes", "error", err)
+		return
+	}
+	if file := metricsFile(cfg); file != "" {
+		if err := metrics.WriteTextfile(file, repos, time.Now()); err != nil {
+			slog.Warn("failed to write metrics", "error", err)
+		}
+	}
+}
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update all found Git repositories",
@@ -71,19 +192,20 @@ Disable auto-scan with --no-scan or set auto_scan: false in the config file.
 For each repository, it will fetch and pull changes from origin, and for
 forks it will rebase onto upstream/master.
 
+Per-repository settings (strategy, branch, remotes, hooks...) are taken from
+the repositories section of the config file and from a .gogitup.yaml file at
+the root of each repository.
+
 Use the -s or --stat flag to show git diff statistics for updated repositories.`,
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	PreRun: func(cmd *cobra.Command, args []string) {
-		if err := viper.BindPFlag("config", cmd.Flags().Lookup("config")); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to bind config flag: %v\n", err)
-		}
-		if err := viper.BindPFlag("repos-file", cmd.Flags().Lookup("repos-file")); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to bind repos-file flag: %v\n", err)
-		}
-
 		// Load repositories to validate thread count
-		repos, err := git.LoadRepositories()
+		var repos []git.Repository
+		store, err := openStore()
+		if err == nil {
+			repos, err = store.Load()
+		}
 		if err == nil && len(repos) > 0 {
 			// Only validate and adjust thread count if it was explicitly set by the user
 			if cmd.Flags().Changed("threads") {
@@ -103,150 +225,170 @@ Use the -s or --stat flag to show git diff statistics for updated repositories.`
 		}
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if tui && !isatty.IsTerminal(os.Stdout.Fd()) {
+			return fmt.Errorf("--tui requires a terminal")
+		}
+
+		// Check the report format before spending time on the updates
+		var format string
+		if reportFile != "" {
+			var err error
+			if format, err = reportFormat(reportFile, reportType); err != nil {
+				return err
+			}
+		}
+
 		// Enable verbose mode if stats are requested
 		if showStats {
 			verbose = true
 		}
 
-		// Check repositories file age
-		reposFile := viper.GetString("repos-file")
-		if reposFile == "" {
-			var err error
-			reposFile, err = git.GetCacheFile()
-			if err != nil {
-				return fmt.Errorf("failed to get cache file path: %w", err)
-			}
+		store, err := openStore()
+		if err != nil {
+			return err
 		}
 
-		info, err := os.Stat(reposFile)
-		if err == nil {
-			age := time.Since(info.ModTime())
+		// The config is optional here: without it every repository is
+		// updated with the default settings, but there is nothing to scan
+		cfg, cfgErr := loadConfig()
+		if cfgErr != nil {
+			cfg = &config.Config{}
+		}
+
+		// A running watcher keeps the repository list current, so neither
+		// the age check nor the scan is needed
+		watched := watcherIsCurrent(cfg, store)
+		if watched && verbose {
+			fmt.Println("Repository list is kept current by a running watcher, skipping scan")
+		}
+
+		// Check when the repositories were last scanned. The file itself is
+		// rewritten by every update, so its age says nothing.
+		lastScan, err := store.LastScan()
+		if err == nil && !lastScan.IsZero() && !watched {
+			age := time.Since(lastScan)
 			if age > 14*24*time.Hour {
-				fmt.Fprintf(os.Stderr, "Warning: Repository list is older than 14 days.\n")
+				slog.Warn("repositories were last scanned more than 14 days ago, run 'gogitup scan'", "file", store.Path(), "age", age.Round(time.Hour))
 			}
 		}
 
 		// Determine if auto-scan should run
-		shouldScan := !noScan
-		if shouldScan {
-			cfg, err := config.LoadConfig()
-			if err == nil && cfg.AutoScan != nil && !*cfg.AutoScan {
-				shouldScan = false
-			}
+		shouldScan := !noScan && !watched
+		if shouldScan && cfg.AutoScan != nil && !*cfg.AutoScan {
+			shouldScan = false
 		}
 
 		if shouldScan {
-			if err := runScan(); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: auto-scan failed: %v\n", err)
+			err := cfgErr
+			if err == nil {
+				err = runScan(cfg, store)
+			}
+			if err != nil {
+				slog.Warn("auto-scan failed", "error", err)
 			}
-		}
-
-		var s *spinner.Spinner
-		if !verbose {
-			s = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-			s.Start()
 		}
 
 		// Load repositories from file
-		repos, err := git.LoadRepositories()
+		repos, err := store.Load()
 		if err != nil {
-			if s != nil {
-				s.Stop()
-			}
-			return fmt.Errorf("failed to load repositories: %w", err)
+			return err
 		}
 
 		if len(repos) == 0 {
-			if s != nil {
-				s.Stop()
-			}
 			return fmt.Errorf("no repositories found. Run 'scan' first")
 		}
 
-		// Create work pool
-		numWorkers := threads
-		if numWorkers < 1 {
-			numWorkers = 1
+		// Apply repository filters before dispatching work
+		repos, err = selectRepositories(repos)
+		if err != nil {
+			return err
 		}
-		if numWorkers > len(repos) {
-			numWorkers = len(repos)
+		if len(repos) == 0 {
+			return fmt.Errorf("no repositories match the given filters")
 		}
 
-		// Create channels for work distribution
-		jobs := make(chan *git.Repository, len(repos))
-		results := make(chan updateResult, len(repos))
-		var wg sync.WaitGroup
-
-		// Start worker goroutines
-		for i := 0; i < numWorkers; i++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for repo := range jobs {
-					result := updateResult{path: repo.Path}
-					err := repo.Update()
-					if err != nil {
-						if err == git.ErrUncommittedChanges {
-							result.warning = "worktree contains uncommitted changes"
-						} else {
-							result.error = err
-						}
-					} else {
-						result.diffStats = repo.DiffStats
-					}
-					results <- result
+		opts := updateOptions(cfg, threads, cmd.Flags().Changed("prune"))
+		started := time.Now()
+		if tui {
+			results, err := runUpdateTUI(cfg, repos, opts)
+			if err != nil {
+				return err
+			}
+			if reportFile != "" {
+				if err := writeReport(reportFile, format, results, started, time.Now()); err != nil {
+					return err
 				}
-			}()
-		}
-
-		// Send work to workers
-		for i := range repos {
-			jobs <- &repos[i]
+			}
+			return printTUISummary(cfg, store, results)
 		}
-		close(jobs)
 
-		// Wait for all updates to complete in a goroutine
-		go func() {
-			wg.Wait()
-			close(results)
-		}()
+		// Update repositories, following their progress. An interrupt
+		// aborts the running updates and fails the remaining ones.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		p := newProgress(os.Stdout, len(repos))
+		events := gogitup.NewUpdater(cfg, opts).Start(ctx, repos)
 
 		// Process results as they come in
-		count := 0
 		errors := make([]error, 0)
 		warnings := make(map[string]string)
-		for result := range results {
-			count++
-			if s != nil {
-				s.Suffix = fmt.Sprintf(" Updated %d/%d repositories...", count, len(repos))
-			} else if verbose {
-				fmt.Printf("Progress: %d/%d repositories\n", count, len(repos))
+		lfsObjects, lfsBytes := 0, int64(0)
+		outcomes := make([]gogitup.Result, 0, len(repos))
+		for event := range events {
+			switch event.Kind {
+			case gogitup.EventStarted:
+				p.start(event.Path)
+				continue
+			case gogitup.EventPhase:
+				p.phase(event.Path, event.Phase)
+				continue
 			}
 
-			if result.error != nil {
-				errors = append(errors, fmt.Errorf("failed to update %s: %w", result.path, result.error))
+			result := event.Result
+			p.done(result)
+			outcomes = append(outcomes, result)
+
+			if result.Err != nil {
+				errors = append(errors, fmt.Errorf("failed to update %s: %w", result.Path, result.Err))
 				if verbose {
-					fmt.Printf("\nError updating %s: %v\n", result.path, result.error)
+					p.log("\nError updating %s: %v\n", result.Path, result.Err)
 				}
-			} else if result.warning != "" {
-				warnings[result.path] = result.warning
+			} else if result.Warning != "" {
+				warnings[result.Path] = result.Warning
 				if verbose {
-					fmt.Printf("\nWarning: Skipping %s - %s\n", result.path, result.warning)
+					p.log("\nWarning: Skipping %s - %s\n", result.Path, result.Warning)
 				}
 			} else {
+				lfsObjects += result.LFSObjects
+				lfsBytes += result.LFSBytes
 				if verbose {
-					fmt.Printf("\nUpdated %s\n", result.path)
+					if result.LFSObjects > 0 {
+						p.log("\nUpdated %s (downloaded %d LFS objects, %s)\n", result.Path, result.LFSObjects, formatBytes(result.LFSBytes))
+					} else {
+						p.log("\nUpdated %s\n", result.Path)
+					}
 				}
-				if showStats && result.diffStats != "" {
-					fmt.Printf("\nChanges in %s:\n%s\n", result.path, result.diffStats)
+				if showStats && result.DiffStats != "" {
+					p.log("\nChanges in %s:\n%s\n", result.Path, result.DiffStats)
 				}
 			}
 		}
 
-		if s != nil {
-			s.Stop()
+		p.stop()
+		recordRun(cfg, store, outcomes)
+		if showLog || cfg.Log.Enabled {
+			limit := cfg.Log.MaxCommits()
+			if cmd.Flags().Changed("log-limit") {
+				limit = logLimit
+			}
+			if commitLog := formatCommitLog(outcomes, limit); commitLog != "" {
+				fmt.Printf("\nIncoming commits:\n%s", commitLog)
+			}
 		}
 		fmt.Printf("\nUpdated %d repositories\n", len(repos)-len(errors)-len(warnings))
+		if lfsObjects > 0 {
+			fmt.Printf("Downloaded %d LFS objects (%s)\n", lfsObjects, formatBytes(lfsBytes))
+		}
 
 		if len(warnings) > 0 {
 			fmt.Printf("\nWarnings for %d repositories:\n", len(warnings))
@@ -260,6 +402,21 @@ Use the -s or --stat flag to show git diff statistics for updated repositories.`
 			for _, err := range errors {
 				fmt.Printf("- %v\n", err)
 			}
+			printDivergences(outcomes)
+		}
+
+		// The report is written even if some repositories failed, it is
+		// most useful then
+		if reportFile != "" {
+			if err := writeReport(reportFile, format, outcomes, started, time.Now()); err != nil {
+				return err
+			}
+			if verbose {
+				fmt.Printf("\nWrote %s report to %s\n", format, reportFile)
+			}
+		}
+
+		if len(errors) > 0 {
 			fmt.Printf("\nError: failed to update some repositories\n")
 			// Return error code without message since we already printed it
 			return fmt.Errorf("")
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -13,10 +15,11 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
 	gitutil "github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/pkg/gogitup"
 )
 
 func TestUpdateCommand_ReposFileAge(t *testing.T) {
@@ -74,10 +77,7 @@ func TestUpdateCommand_ReposFileAge(t *testing.T) {
 			err = os.WriteFile(configFile, []byte("directories: [\""+emptyDir+"\"]"), 0644)
 			require.NoError(t, err)
 
-			// Set up viper config
-			viper.Reset()
-			viper.Set("repos-file", reposFile)
-			viper.Set("config", configFile)
+			useFiles(t, configFile, reposFile)
 
 			// Reset flags before test
 			updateCmd.Flags().VisitAll(func(f *pflag.Flag) {
@@ -251,10 +251,7 @@ func TestUpdateCommand_Flags(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			// Reset viper and set config
-			viper.Reset()
-			viper.Set("repos-file", reposFile)
-			viper.Set("config", configFile)
+			useFiles(t, configFile, reposFile)
 
 			// Create a new command instance for each test
 			cmd := &cobra.Command{Use: "update"}
@@ -355,10 +352,7 @@ func TestUpdateCommand_AutoScanConfig(t *testing.T) {
 	err = os.WriteFile(reposFile, []byte("[]"), 0644)
 	require.NoError(t, err)
 
-	// Reset viper and set config
-	viper.Reset()
-	viper.Set("repos-file", reposFile)
-	viper.Set("config", configFile)
+	useFiles(t, configFile, reposFile)
 
 	// Create a new command instance
 	cmd := &cobra.Command{Use: "update"}
@@ -392,8 +386,6 @@ func TestRunScan(t *testing.T) {
 				if err != nil {
 					return err
 				}
-				viper.Set("config", configFile)
-				viper.Set("directories", []string{"."})
 				return nil
 			},
 			expectedError: "",
@@ -405,7 +397,6 @@ func TestRunScan(t *testing.T) {
 				if err != nil {
 					return err
 				}
-				viper.Set("config", configFile)
 				return nil
 			},
 			expectedError: "failed to load config",
@@ -417,8 +408,6 @@ func TestRunScan(t *testing.T) {
 				if err != nil {
 					return err
 				}
-				viper.Set("config", configFile)
-				viper.Set("directories", []string{})
 				return nil
 			},
 			expectedError: "failed to load config: no directories configured",
@@ -440,15 +429,16 @@ func TestRunScan(t *testing.T) {
 			// Create test config file
 			configFile := filepath.Join(tmpDir, "config.yaml")
 
-			// Reset viper config before each test
-			viper.Reset()
-
 			// Set up test configuration
 			err = tt.setupConfig(configFile)
 			require.NoError(t, err)
+			useFiles(t, configFile, "")
 
 			// Run scan
-			err = runScan()
+			cfg, err := loadConfig()
+			if err == nil {
+				err = runScan(cfg, gogitup.NewStore(reposFile))
+			}
 			if tt.expectedError != "" {
 				assert.ErrorContains(t, err, tt.expectedError)
 			} else {
@@ -457,3 +447,119 @@ func TestRunScan(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 * 1024 * 1024 * 1024, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatBytes(tt.bytes))
+		})
+	}
+}
+
+func TestFormatCommitLog(t *testing.T) {
+	results := []gogitup.Result{
+		{Path: "/src/zeta", Commits: []gitutil.Commit{
+			{Hash: "aaaaaaaaaaaa", Author: "Jane", Subject: "Third"},
+			{Hash: "bbbbbbbbbbbb", Author: "John", Subject: "Second"},
+			{Hash: "cccccccccccc", Author: "Jane", Subject: "First"},
+		}},
+		{Path: "/src/alpha", Commits: []gitutil.Commit{
+			{Hash: "dddddddddddd", Author: "John", Subject: "Only change"},
+		}},
+		{Path: "/src/beta"},
+	}
+
+	assert.Equal(t, "\n/src/alpha (1 new commit):\n"+
+		"  ddddddd Only change (John)\n"+
+		"\n/src/zeta (3 new commits):\n"+
+		"  aaaaaaa Third (Jane)\n"+
+		"  bbbbbbb Second (John)\n"+
+		"  ccccccc First (Jane)\n", formatCommitLog(results, 0))
+
+	assert.Equal(t, "\n/src/alpha (1 new commit):\n"+
+		"  ddddddd Only change (John)\n"+
+		"\n/src/zeta (3 new commits):\n"+
+		"  aaaaaaa Third (Jane)\n"+
+		"  ... and 2 more\n", formatCommitLog(results, 1))
+
+	assert.Empty(t, formatCommitLog(results[2:], 0))
+}
+
+func TestUpdateOptions_Prune(t *testing.T) {
+	defer func() { prune = false }()
+	cfg := &appconfig.Config{}
+	trueVal, falseVal := true, false
+
+	tests := []struct {
+		name string
+		args []string
+		want *bool
+	}{
+		{name: "unset", want: nil},
+		{name: "on", args: []string{"--prune"}, want: &trueVal},
+		{name: "off", args: []string{"--prune=false"}, want: &falseVal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "update"}
+			cmd.Flags().AddFlagSet(updateCmd.Flags())
+			cmd.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+			prune = false
+			require.NoError(t, cmd.Flags().Parse(tt.args))
+			assert.Equal(t, tt.want, updateOptions(cfg, 1, cmd.Flags().Changed("prune")).Prune)
+		})
+	}
+}
+
+func TestUpdateOptions_Log(t *testing.T) {
+	tmpDir := t.TempDir()
+	remoteDir := createBareRemote(t, filepath.Join(tmpDir, "remote.git"))
+	localDir := filepath.Join(tmpDir, "local")
+	publishDir := filepath.Join(tmpDir, "publish")
+	for _, dir := range []string{localDir, publishDir} {
+		out, err := exec.Command("git", "clone", "-q", remoteDir, dir).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(publishDir, "new.txt"), []byte("new"), 0644))
+	for _, args := range [][]string{
+		{"add", "new.txt"},
+		{"-c", "user.name=Jane", "-c", "user.email=jane@example.com", "commit", "-q", "-m", "Add new file"},
+		{"push", "-q", "origin", "HEAD"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", publishDir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	resetFilters()
+	defer func() { showLog = false }()
+
+	repo, err := gitutil.OpenRepository(localDir)
+	require.NoError(t, err)
+	// Commits are collected when the config enables the log
+	cfg := &appconfig.Config{Log: appconfig.CommitLog{Enabled: true}}
+	updater := gogitup.NewUpdater(cfg, updateOptions(cfg, 1, false))
+	result := updater.Update(context.Background(), repo)
+	require.NoError(t, result.Err)
+	require.Len(t, result.Commits, 1)
+	assert.Equal(t, "Add new file", result.Commits[0].Subject)
+	assert.Equal(t, "Jane", result.Commits[0].Author)
+
+	// Nothing is listed once the repository is up to date
+	result = updater.Update(context.Background(), repo)
+	require.NoError(t, result.Err)
+	assert.Empty(t, result.Commits)
+}
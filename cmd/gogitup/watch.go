@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/watch"
+	"github.com/trutx/gogitup/pkg/gogitup"
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watcherIsCurrent reports whether a running watcher keeps store current for
+// the configured directories
+func watcherIsCurrent(cfg *config.Config, store *gogitup.Store) bool {
+	if len(cfg.Directories) == 0 {
+		return false
+	}
+	return watch.IsCurrent(watch.StateFile(store.Path()), cfg.Directories)
+}
+
+// newWatcher returns a watcher keeping store current for the configured
+// directories
+func newWatcher(cfg *config.Config, store *gogitup.Store, logf func(format string, args ...any)) *watch.Watcher {
+	return watch.New(watch.Options{
+		Directories: cfg.Directories,
+		Store:       git.NewStore(store.Path()),
+		StateFile:   watch.StateFile(store.Path()),
+		Logf:        logf,
+	})
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep the repository list current by watching the configured directories",
+	Long: `Watch the configured directories for repositories being cloned, created,
+moved or removed and update the repository list as they happen.
+
+The watcher starts with a full scan. While it runs, 'gogitup update' skips its
+automatic scan and the warning about an old repository list, since the list is
+known to be current. 'gogitup daemon --watch' runs the same watcher inside the
+daemon.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		store, err := openStore()
+		if err != nil {
+			return err
+		}
+		if watcherIsCurrent(cfg, store) {
+			return fmt.Errorf("a watcher is already running for %s", store.Path())
+		}
+
+		logInfoByDefault()
+		w := newWatcher(cfg, store, infof)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return w.Run(ctx)
+	},
+}
@@ -2,58 +2,228 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Directories []string `mapstructure:"directories"`
-	AutoScan    *bool    `mapstructure:"auto_scan"`
+	Directories  []string             `mapstructure:"directories"`
+	AutoScan     *bool                `mapstructure:"auto_scan"`
+	Groups       map[string]Group     `mapstructure:"groups"`
+	Repositories []RepositorySettings `mapstructure:"repositories"`
+	Daemon       Daemon               `mapstructure:"daemon"`
+	Log          CommitLog            `mapstructure:"log"`
+	Metrics      Metrics              `mapstructure:"metrics"`
+	// Defaults are the settings of every repository, overridden by the
+	// repository's own .gogitup.yaml and the repositories section
+	Defaults RepositorySettings `mapstructure:"defaults"`
+	// CacheFile is the repository list, defaults to a file in the user's
+	// cache directory
+	CacheFile string `mapstructure:"cache_file"`
+	// Include lists further config files merged into this one
+	Include []string `mapstructure:"include"`
+	// Profiles are named variants of the config, see LoadProfile
+	Profiles map[string]Config `mapstructure:"profiles"`
+	// Profile is the name of the profile applied by LoadProfile
+	Profile string `mapstructure:"-"`
 }
 
-// LoadConfig loads the configuration from the config file
-func LoadConfig() (*Config, error) {
+// DefaultPath returns the config file used when none is given:
+// $XDG_CONFIG_HOME/gogitup/config.yaml if it exists, ~/.gogitup.yaml
+// otherwise. XDG_CONFIG_HOME defaults to ~/.config.
+func DefaultPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
 
-	// Get config file path from viper
-	configFile := viper.GetString("config")
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	path := filepath.Join(xdg, "gogitup", "config.yaml")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return filepath.Join(home, ".gogitup.yaml"), nil
+}
+
+// Load reads the config file at path without applying a profile, see
+// LoadProfile
+func Load(path string) (*Config, error) {
+	return LoadProfile(path, "")
+}
+
+// LoadProfile reads the config file at path and applies the named profile,
+// if any. A leading ~/ is the home directory. Every call uses its own viper
+// instance, so several configs can be loaded in one process.
+//
+// The files listed in include are read first and the including file is
+// merged on top of them: lists are appended, other values of the including
+// file win. A profile replaces the top-level values it sets, its sections
+// such as groups or daemon are merged key by key.
+func LoadProfile(path, profile string) (*Config, error) {
+	config, _, err := load(path, profile, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.Directories) == 0 {
+		if config.Profile == "" && len(config.Profiles) > 0 {
+			return nil, fmt.Errorf("no directories configured, select one of the profiles %s", profileList(slices.Sorted(maps.Keys(config.Profiles))))
+		}
+		return nil, fmt.Errorf("no directories configured")
+	}
+
+	slog.Debug("loaded config", "file", path, "profile", config.Profile, "directories", len(config.Directories), "groups", len(config.Groups))
+	return config, nil
+}
+
+// load reads the config file at path, applies the profile and expands the
+// paths of the result. It also returns the merged settings the config was
+// decoded from. exact rejects keys that match no config field.
+func load(path, profile string, exact bool) (*Config, map[string]any, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	if path == "" {
+		return nil, nil, fmt.Errorf("no config file given")
+	}
 
 	// If config file starts with ~, replace with home directory
-	if len(configFile) >= 2 && configFile[:2] == "~/" {
-		configFile = filepath.Join(home, configFile[2:])
+	if len(path) >= 2 && path[:2] == "~/" {
+		path = filepath.Join(home, path[2:])
 	}
 
-	// Set the config file path explicitly
-	viper.SetConfigFile(configFile)
+	settings, err := readSettings(path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+	// viper lower-cases keys, so profile names are case-insensitive
+	profile = strings.ToLower(profile)
+	if profile != "" {
+		profiles, _ := settings["profiles"].(map[string]any)
+		selected, ok := profiles[profile].(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown profile %q, the config file defines %s", profile, profileList(slices.Sorted(maps.Keys(profiles))))
+		}
+		mergeSettings(settings, selected, false)
 	}
 
-	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	v := viper.New()
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to merge config: %w", err)
 	}
 
-	if len(config.Directories) == 0 {
-		return nil, fmt.Errorf("no directories configured")
+	var config Config
+	unmarshal := v.Unmarshal
+	if exact {
+		unmarshal = v.UnmarshalExact
 	}
+	if err := unmarshal(&config); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	config.Profile = profile
 
-	// Expand any environment variables or ~ in directory paths
-	for i, dir := range config.Directories {
+	// Expand any environment variables or ~ in directory paths. Included
+	// files may list the same directory again.
+	directories := make([]string, 0, len(config.Directories))
+	for _, dir := range config.Directories {
 		if dir == "~" {
-			config.Directories[i] = home
+			dir = home
 		} else if len(dir) >= 2 && dir[:2] == "~/" {
-			config.Directories[i] = filepath.Join(home, dir[2:])
+			dir = filepath.Join(home, dir[2:])
+		}
+		dir = os.ExpandEnv(dir)
+		if !slices.Contains(directories, dir) {
+			directories = append(directories, dir)
+		}
+	}
+	config.Directories = directories
+	if config.CacheFile != "" {
+		config.CacheFile = ExpandPath(config.CacheFile)
+	}
+
+	return &config, settings, nil
+}
+
+// readSettings reads the config file at path with the files it includes.
+// Relative includes are resolved against the directory of the including
+// file and may be globs. seen holds the files being read, to detect cycles.
+func readSettings(path string, seen []string) (map[string]any, error) {
+	if slices.Contains(seen, path) {
+		return nil, fmt.Errorf("config file %s includes itself", path)
+	}
+	seen = append(seen, path)
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings := make(map[string]any)
+	for _, include := range v.GetStringSlice("include") {
+		include = ExpandPath(include)
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+
+		files := []string{include}
+		if strings.ContainsAny(include, "*?[") {
+			var err error
+			if files, err = filepath.Glob(include); err != nil {
+				return nil, fmt.Errorf("invalid include pattern %s: %w", include, err)
+			}
+		}
+		for _, file := range files {
+			included, err := readSettings(file, seen)
+			if err != nil {
+				return nil, fmt.Errorf("failed to include %s: %w", file, err)
+			}
+			mergeSettings(settings, included, true)
 		}
-		config.Directories[i] = os.ExpandEnv(config.Directories[i])
 	}
 
-	return &config, nil
+	own := v.AllSettings()
+	delete(own, "include")
+	mergeSettings(settings, own, true)
+	return settings, nil
+}
+
+// mergeSettings merges src into dst. Nested maps are merged key by key,
+// lists are appended if appendLists is set, other values of src win.
+func mergeSettings(dst, src map[string]any, appendLists bool) {
+	for key, value := range src {
+		if dstMap, ok := dst[key].(map[string]any); ok {
+			if srcMap, ok := value.(map[string]any); ok {
+				mergeSettings(dstMap, srcMap, appendLists)
+				continue
+			}
+		}
+		if dstList, ok := dst[key].([]any); ok && appendLists {
+			if srcList, ok := value.([]any); ok {
+				dst[key] = slices.Concat(dstList, srcList)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
+
+// profileList formats the profile names for error messages
+func profileList(names []string) string {
+	if len(names) == 0 {
+		return "no profiles"
+	}
+	return strings.Join(names, ", ")
 }
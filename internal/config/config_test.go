@@ -4,13 +4,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestLoadConfig(t *testing.T) {
+func TestLoad(t *testing.T) {
 	// Create a temporary directory for test config
 	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
 	require.NoError(t, err)
@@ -47,7 +47,7 @@ directories:
 
 	tests := []struct {
 		name         string
-		setupConfig  func()
+		setupConfig  func() string
 		wantDirs     []string
 		wantAutoScan *bool
 		wantErr      bool
@@ -55,9 +55,8 @@ directories:
 	}{
 		{
 			name: "valid config file",
-			setupConfig: func() {
-				viper.Reset()
-				viper.SetConfigFile(configFile)
+			setupConfig: func() string {
+				return configFile
 			},
 			wantDirs: []string{
 				"/path/to/repos1",
@@ -69,9 +68,8 @@ directories:
 		},
 		{
 			name: "auto_scan false",
-			setupConfig: func() {
-				viper.Reset()
-				viper.SetConfigFile(autoScanFalseConfig)
+			setupConfig: func() string {
+				return autoScanFalseConfig
 			},
 			wantDirs:     []string{"/path/to/repos1"},
 			wantAutoScan: &falseVal,
@@ -79,9 +77,8 @@ directories:
 		},
 		{
 			name: "auto_scan true",
-			setupConfig: func() {
-				viper.Reset()
-				viper.SetConfigFile(autoScanTrueConfig)
+			setupConfig: func() string {
+				return autoScanTrueConfig
 			},
 			wantDirs:     []string{"/path/to/repos1"},
 			wantAutoScan: &trueVal,
@@ -89,33 +86,30 @@ directories:
 		},
 		{
 			name: "missing config file",
-			setupConfig: func() {
-				viper.Reset()
-				viper.SetConfigFile(filepath.Join(tmpDir, "nonexistent.yaml"))
+			setupConfig: func() string {
+				return filepath.Join(tmpDir, "nonexistent.yaml")
 			},
 			wantErr:      true,
 			wantErrMatch: "failed to read config file",
 		},
 		{
 			name: "invalid config file",
-			setupConfig: func() {
-				viper.Reset()
+			setupConfig: func() string {
 				invalidConfig := filepath.Join(tmpDir, "invalid.yaml")
 				err := os.WriteFile(invalidConfig, []byte("invalid: [yaml"), 0644)
 				require.NoError(t, err)
-				viper.SetConfigFile(invalidConfig)
+				return invalidConfig
 			},
 			wantErr:      true,
 			wantErrMatch: "failed to read config file",
 		},
 		{
 			name: "empty directories list",
-			setupConfig: func() {
-				viper.Reset()
+			setupConfig: func() string {
 				emptyConfig := filepath.Join(tmpDir, "empty.yaml")
 				err := os.WriteFile(emptyConfig, []byte("directories: []"), 0644)
 				require.NoError(t, err)
-				viper.SetConfigFile(emptyConfig)
+				return emptyConfig
 			},
 			wantErr:      true,
 			wantErrMatch: "no directories configured",
@@ -124,9 +118,7 @@ directories:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.setupConfig()
-
-			cfg, err := LoadConfig()
+			cfg, err := Load(tt.setupConfig())
 			if tt.wantErr {
 				assert.Error(t, err)
 				if tt.wantErrMatch != "" {
@@ -145,3 +137,184 @@ directories:
 		})
 	}
 }
+
+func TestLoad_Groups(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	err = os.WriteFile(configFile, []byte(`
+directories:
+  - /path/to/repos
+groups:
+  Work:
+    directories:
+      - /path/to/repos/work
+    paths:
+      - "*-service"
+  oss:
+    repositories:
+      - /path/to/repos/tool
+`), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(configFile)
+	require.NoError(t, err)
+	require.Len(t, cfg.Groups, 2)
+	assert.Equal(t, []string{"/path/to/repos/work"}, cfg.Groups["work"].Directories)
+	assert.Equal(t, []string{"*-service"}, cfg.Groups["work"].Paths)
+	assert.Equal(t, []string{"/path/to/repos/tool"}, cfg.Groups["oss"].Repositories)
+}
+
+func TestLoadProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+groups:
+  oss:
+    paths:
+      - "*-tool"
+daemon:
+  interval: 2h
+  jitter: 5m
+profiles:
+  Work:
+    directories:
+      - /work
+    cache_file: /cache/work.json
+    defaults:
+      credentials:
+        token_env: WORK_TOKEN
+    daemon:
+      interval: 30m
+  personal:
+    directories:
+      - /home/code
+    auto_scan: false
+`), 0644))
+
+	t.Run("work", func(t *testing.T) {
+		cfg, err := LoadProfile(configFile, "work")
+		require.NoError(t, err)
+		assert.Equal(t, "work", cfg.Profile)
+		assert.Equal(t, []string{"/work"}, cfg.Directories)
+		assert.Equal(t, "/cache/work.json", cfg.CacheFile)
+		assert.Equal(t, "WORK_TOKEN", cfg.Defaults.Credentials.TokenEnv)
+		// Sections are merged key by key
+		assert.Equal(t, 30*time.Minute, cfg.Daemon.Interval)
+		assert.Equal(t, 5*time.Minute, cfg.Daemon.Jitter)
+		assert.Contains(t, cfg.Groups, "oss")
+		assert.Nil(t, cfg.AutoScan)
+	})
+
+	t.Run("personal", func(t *testing.T) {
+		cfg, err := LoadProfile(configFile, "Personal")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/home/code"}, cfg.Directories)
+		assert.Empty(t, cfg.CacheFile)
+		require.NotNil(t, cfg.AutoScan)
+		assert.False(t, *cfg.AutoScan)
+		assert.Equal(t, 2*time.Hour, cfg.Daemon.Interval)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := LoadProfile(configFile, "other")
+		assert.ErrorContains(t, err, `unknown profile "other", the config file defines personal, work`)
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		_, err := Load(configFile)
+		assert.ErrorContains(t, err, "no directories configured, select one of the profiles personal, work")
+	})
+}
+
+func TestLoad_Include(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "conf.d"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "conf.d", "a.yaml"), []byte(`
+directories:
+  - /shared
+  - /a
+repositories:
+  - path: /a/*
+    strategy: rebase
+log:
+  enabled: true
+  limit: 5
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "conf.d", "b.yaml"), []byte(`
+directories:
+  - /b
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "base.yaml"), []byte(`
+include:
+  - conf.d/*.yaml
+`), 0644))
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+include:
+  - base.yaml
+directories:
+  - /shared
+  - /main
+repositories:
+  - path: /a/api
+    strategy: merge
+log:
+  limit: 10
+`), 0644))
+
+	cfg, err := Load(configFile)
+	require.NoError(t, err)
+	// Lists are appended after the included ones, other values win
+	assert.Equal(t, []string{"/shared", "/a", "/b", "/main"}, cfg.Directories)
+	require.Len(t, cfg.Repositories, 2)
+	assert.Equal(t, "/a/*", cfg.Repositories[0].Path)
+	assert.Equal(t, "/a/api", cfg.Repositories[1].Path)
+	assert.True(t, cfg.Log.Enabled)
+	assert.Equal(t, 10, cfg.Log.Limit)
+
+	t.Run("missing include", func(t *testing.T) {
+		missing := filepath.Join(tmpDir, "missing.yaml")
+		require.NoError(t, os.WriteFile(missing, []byte("include: [nonexistent.yaml]\ndirectories: [/x]\n"), 0644))
+		_, err := Load(missing)
+		assert.ErrorContains(t, err, "failed to include "+filepath.Join(tmpDir, "nonexistent.yaml"))
+	})
+
+	t.Run("include cycle", func(t *testing.T) {
+		loop := filepath.Join(tmpDir, "loop.yaml")
+		require.NoError(t, os.WriteFile(loop, []byte("include: [loop.yaml]\ndirectories: [/x]\n"), 0644))
+		_, err := Load(loop)
+		assert.ErrorContains(t, err, "includes itself")
+	})
+}
+
+func TestDefaultPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	path, err := DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".gogitup.yaml"), path)
+
+	// The XDG location wins once it exists
+	xdg := filepath.Join(home, ".config", "gogitup", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(xdg), 0755))
+	require.NoError(t, os.WriteFile(xdg, []byte("directories: [/x]\n"), 0644))
+	path, err = DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, xdg, path)
+
+	custom := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", custom)
+	path, err = DefaultPath()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".gogitup.yaml"), path)
+}
@@ -0,0 +1,49 @@
+package config
+
+import "time"
+
+// Default schedule of the background daemon
+const (
+	DefaultDaemonInterval = time.Hour
+	DefaultScanInterval   = 24 * time.Hour
+)
+
+// Daemon configures the background update daemon
+type Daemon struct {
+	// Interval is the time between two update runs
+	Interval time.Duration `mapstructure:"interval"`
+	// Jitter is the maximum random delay added to every interval
+	Jitter time.Duration `mapstructure:"jitter"`
+	// ScanInterval is the time between two repository scans, a negative
+	// value disables rescanning
+	ScanInterval time.Duration `mapstructure:"scan_interval"`
+	// QuietHours is a local time range such as "22:00-07:00" during which
+	// no scheduled updates run
+	QuietHours string `mapstructure:"quiet_hours"`
+	// Watch keeps the repository list current with a filesystem watcher
+	// instead of periodic rescans
+	Watch bool `mapstructure:"watch"`
+	// Socket is the path of the control socket, defaults to daemon.sock
+	// next to the repository list
+	Socket string `mapstructure:"socket"`
+}
+
+// UpdateInterval returns the time between two update runs
+func (d Daemon) UpdateInterval() time.Duration {
+	if d.Interval <= 0 {
+		return DefaultDaemonInterval
+	}
+	return d.Interval
+}
+
+// RescanInterval returns the time between two repository scans, or 0 when
+// rescanning is disabled
+func (d Daemon) RescanInterval() time.Duration {
+	switch {
+	case d.ScanInterval < 0:
+		return 0
+	case d.ScanInterval == 0:
+		return DefaultScanInterval
+	}
+	return d.ScanInterval
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaemon_Intervals(t *testing.T) {
+	tests := []struct {
+		name         string
+		daemon       Daemon
+		wantInterval time.Duration
+		wantRescan   time.Duration
+	}{
+		{
+			name:         "defaults",
+			wantInterval: DefaultDaemonInterval,
+			wantRescan:   DefaultScanInterval,
+		},
+		{
+			name:         "configured",
+			daemon:       Daemon{Interval: 30 * time.Minute, ScanInterval: 6 * time.Hour},
+			wantInterval: 30 * time.Minute,
+			wantRescan:   6 * time.Hour,
+		},
+		{
+			name:         "rescan disabled",
+			daemon:       Daemon{ScanInterval: -1},
+			wantInterval: DefaultDaemonInterval,
+			wantRescan:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantInterval, tt.daemon.UpdateInterval())
+			assert.Equal(t, tt.wantRescan, tt.daemon.RescanInterval())
+		})
+	}
+}
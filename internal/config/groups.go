@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Group is a named set of repositories defined in the config file. A
+// repository belongs to the group if it lives under one of Directories,
+// matches one of Paths, or is listed in Repositories.
+type Group struct {
+	Directories  []string `mapstructure:"directories"`
+	Paths        []string `mapstructure:"paths"`
+	Repositories []string `mapstructure:"repositories"`
+}
+
+// ExpandPath expands a leading ~ and any environment variables in path
+func ExpandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	return os.ExpandEnv(path)
+}
+
+// MatchPath reports whether a repository path matches pattern. Patterns
+// without a path separator are matched against the directory name only,
+// other patterns against the full path.
+func MatchPath(path, pattern string) bool {
+	if !strings.ContainsRune(pattern, filepath.Separator) && !strings.ContainsRune(pattern, '/') {
+		matched, _ := filepath.Match(pattern, filepath.Base(path))
+		return matched
+	}
+	matched, _ := filepath.Match(filepath.FromSlash(ExpandPath(pattern)), path)
+	return matched
+}
+
+// IsWithin reports whether path is dir or lives below it
+func IsWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// Contains reports whether the repository at path belongs to the group
+func (g Group) Contains(path string) bool {
+	for _, dir := range g.Directories {
+		if IsWithin(path, ExpandPath(dir)) {
+			return true
+		}
+	}
+	for _, pattern := range g.Paths {
+		if MatchPath(path, pattern) {
+			return true
+		}
+	}
+	for _, repo := range g.Repositories {
+		if filepath.Clean(ExpandPath(repo)) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
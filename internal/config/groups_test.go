@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		pattern  string
+		expected bool
+	}{
+		{name: "base name match", path: "/code/api-service", pattern: "*-service", expected: true},
+		{name: "base name no match", path: "/code/web", pattern: "*-service", expected: false},
+		{name: "full path match", path: "/code/work/api", pattern: "/code/work/*", expected: true},
+		{name: "full path no match", path: "/code/personal/api", pattern: "/code/work/*", expected: false},
+		{name: "full path does not match nested", path: "/code/work/team/api", pattern: "/code/work/*", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchPath(tt.path, tt.pattern))
+		})
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	assert.True(t, IsWithin("/repos/a", "/repos/a"))
+	assert.True(t, IsWithin("/repos/a/b", "/repos/a"))
+	assert.True(t, IsWithin("/repos/a/b", "/repos/a/"))
+	assert.False(t, IsWithin("/repos/ab", "/repos/a"))
+	assert.False(t, IsWithin("/repos", "/repos/a"))
+	assert.False(t, IsWithin("/repos/a", "repos/a"))
+}
+
+func TestGroup_Contains(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	group := Group{
+		Directories:  []string{"/code/work"},
+		Paths:        []string{"*-infra"},
+		Repositories: []string{"~/oss/tool"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{name: "directory itself", path: "/code/work", expected: true},
+		{name: "below directory", path: "/code/work/team/api", expected: true},
+		{name: "sibling with common prefix", path: "/code/workshop", expected: false},
+		{name: "glob match", path: "/code/personal/cloud-infra", expected: true},
+		{name: "explicit repository", path: filepath.Join(home, "oss/tool"), expected: true},
+		{name: "not in group", path: "/code/personal/blog", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, group.Contains(tt.path))
+		})
+	}
+}
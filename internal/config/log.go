@@ -0,0 +1,16 @@
+package config
+
+// CommitLog configures the incoming commit log printed by the update command
+type CommitLog struct {
+	// Enabled lists the commits every update brought in
+	Enabled bool `mapstructure:"enabled"`
+	// Limit is the maximum number of commits listed per repository, 0
+	// lists all of them
+	Limit int `mapstructure:"limit"`
+}
+
+// MaxCommits returns the number of commits listed per repository, or 0 for
+// no limit
+func (l CommitLog) MaxCommits() int {
+	return max(l.Limit, 0)
+}
@@ -0,0 +1,13 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitLog_MaxCommits(t *testing.T) {
+	assert.Equal(t, 0, CommitLog{}.MaxCommits())
+	assert.Equal(t, 5, CommitLog{Limit: 5}.MaxCommits())
+	assert.Equal(t, 0, CommitLog{Limit: -1}.MaxCommits())
+}
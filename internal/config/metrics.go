@@ -0,0 +1,16 @@
+package config
+
+// Metrics configures the Prometheus metrics of the update and daemon commands
+type Metrics struct {
+	// Textfile is written after every update run, for the textfile
+	// collector of node_exporter
+	Textfile string `mapstructure:"textfile"`
+	// Listen is the TCP address on which the daemon serves /metrics, e.g.
+	// "localhost:9419"
+	Listen string `mapstructure:"listen"`
+}
+
+// Enabled reports whether any metrics output is configured
+func (m Metrics) Enabled() bool {
+	return m.Textfile != "" || m.Listen != ""
+}
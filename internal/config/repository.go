@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RepositoryFile is the name of the per-repository settings file that can be
+// committed at the root of a repository
+const RepositoryFile = ".gogitup.yaml"
+
+// Update strategies
+const (
+	StrategyFastForward = "ff-only"
+	StrategyRebase      = "rebase"
+	StrategyMerge       = "merge"
+)
+
+// Hooks are shell commands run in the repository directory around an update
+type Hooks struct {
+	PreUpdate  []string `mapstructure:"pre_update"`
+	PostUpdate []string `mapstructure:"post_update"`
+}
+
+// Credentials select the token used to authenticate against the remotes
+type Credentials struct {
+	// Username sent along with the token, defaults to "git"
+	Username string `mapstructure:"username"`
+	// TokenEnv is the name of the environment variable holding the token
+	TokenEnv string `mapstructure:"token_env"`
+}
+
+// Submodules control how submodules are handled after an update
+type Submodules struct {
+	// Update initializes, syncs and updates submodules recursively after
+	// the superproject was updated
+	Update *bool `mapstructure:"update"`
+	// Depth limits the history fetched for submodules, 0 means full history
+	Depth int `mapstructure:"depth"`
+	// Jobs is the number of submodules fetched in parallel, 0 uses git's default
+	Jobs int `mapstructure:"jobs"`
+}
+
+// LFS controls how Git LFS objects are handled after an update
+type LFS struct {
+	// Include and Exclude limit the LFS objects fetched to the paths
+	// matching these patterns, like git lfs fetch -I and -X
+	Include []string `mapstructure:"include"`
+	Exclude []string `mapstructure:"exclude"`
+	// Prune removes old local LFS objects after the update
+	Prune *bool `mapstructure:"prune"`
+}
+
+// RepositorySettings holds the settings that control how a single repository
+// is updated. The zero value means "use the defaults".
+type RepositorySettings struct {
+	// Path is the repository path or glob the entry applies to. It is only
+	// used for entries in the repositories section of the config file.
+	Path        string        `mapstructure:"path"`
+	Strategy    string        `mapstructure:"strategy"`
+	Branch      string        `mapstructure:"branch"`
+	Origin      string        `mapstructure:"origin"`
+	Upstream    string        `mapstructure:"upstream"`
+	Push        *bool         `mapstructure:"push"`
+	Prune       *bool         `mapstructure:"prune"`
+	Skip        *bool         `mapstructure:"skip"`
+	Pin         *bool         `mapstructure:"pin"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	Hooks       Hooks         `mapstructure:"hooks"`
+	Submodules  Submodules    `mapstructure:"submodules"`
+	LFS         LFS           `mapstructure:"lfs"`
+	Credentials Credentials   `mapstructure:"credentials"`
+	// Trusted allows hooks and credentials from the repository's own
+	// .gogitup.yaml. It is only honoured in the user's config file.
+	Trusted *bool `mapstructure:"trusted"`
+}
+
+// UpdateStrategy returns the strategy used to integrate remote changes
+func (s RepositorySettings) UpdateStrategy() string {
+	if s.Strategy == "" {
+		return StrategyFastForward
+	}
+	return s.Strategy
+}
+
+// OriginRemote returns the name of the remote to pull from (or push to for forks)
+func (s RepositorySettings) OriginRemote() string {
+	if s.Origin == "" {
+		return "origin"
+	}
+	return s.Origin
+}
+
+// UpstreamRemote returns the name of the remote forks are synced from
+func (s RepositorySettings) UpstreamRemote() string {
+	if s.Upstream == "" {
+		return "upstream"
+	}
+	return s.Upstream
+}
+
+// ShouldPush reports whether forks are pushed to origin after syncing
+func (s RepositorySettings) ShouldPush() bool {
+	return s.Push == nil || *s.Push
+}
+
+// ShouldPrune reports whether fetches remove remote-tracking refs of
+// branches deleted on the remote
+func (s RepositorySettings) ShouldPrune() bool {
+	return s.Prune != nil && *s.Prune
+}
+
+// ShouldUpdateSubmodules reports whether submodules are updated after the
+// superproject
+func (s RepositorySettings) ShouldUpdateSubmodules() bool {
+	return s.Submodules.Update != nil && *s.Submodules.Update
+}
+
+// ShouldPruneLFS reports whether old local LFS objects are pruned after the
+// update
+func (s RepositorySettings) ShouldPruneLFS() bool {
+	return s.LFS.Prune != nil && *s.LFS.Prune
+}
+
+// IsSkipped reports whether the repository must not be updated at all
+func (s RepositorySettings) IsSkipped() bool {
+	return s.Skip != nil && *s.Skip
+}
+
+// IsPinned reports whether the repository is only fetched, never moved
+func (s RepositorySettings) IsPinned() bool {
+	return s.Pin != nil && *s.Pin
+}
+
+// isTrusted reports whether the repository's own settings file is trusted
+func (s RepositorySettings) isTrusted() bool {
+	return s.Trusted != nil && *s.Trusted
+}
+
+// Validate checks the settings for invalid values
+func (s RepositorySettings) Validate() error {
+	switch s.UpdateStrategy() {
+	case StrategyFastForward, StrategyRebase, StrategyMerge:
+	default:
+		return fmt.Errorf("invalid strategy %q (expected %s, %s or %s)", s.Strategy, StrategyFastForward, StrategyRebase, StrategyMerge)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %s", s.Timeout)
+	}
+	if s.Submodules.Depth < 0 || s.Submodules.Jobs < 0 {
+		return fmt.Errorf("submodule depth and jobs must not be negative")
+	}
+	return nil
+}
+
+// merge returns s with every field that is set in o overridden
+func (s RepositorySettings) merge(o RepositorySettings) RepositorySettings {
+	if o.Strategy != "" {
+		s.Strategy = o.Strategy
+	}
+	if o.Branch != "" {
+		s.Branch = o.Branch
+	}
+	if o.Origin != "" {
+		s.Origin = o.Origin
+	}
+	if o.Upstream != "" {
+		s.Upstream = o.Upstream
+	}
+	if o.Push != nil {
+		s.Push = o.Push
+	}
+	if o.Prune != nil {
+		s.Prune = o.Prune
+	}
+	if o.Skip != nil {
+		s.Skip = o.Skip
+	}
+	if o.Pin != nil {
+		s.Pin = o.Pin
+	}
+	if o.Timeout != 0 {
+		s.Timeout = o.Timeout
+	}
+	if len(o.Hooks.PreUpdate) > 0 {
+		s.Hooks.PreUpdate = o.Hooks.PreUpdate
+	}
+	if len(o.Hooks.PostUpdate) > 0 {
+		s.Hooks.PostUpdate = o.Hooks.PostUpdate
+	}
+	if o.Submodules.Update != nil {
+		s.Submodules.Update = o.Submodules.Update
+	}
+	if o.Submodules.Depth != 0 {
+		s.Submodules.Depth = o.Submodules.Depth
+	}
+	if o.Submodules.Jobs != 0 {
+		s.Submodules.Jobs = o.Submodules.Jobs
+	}
+	if len(o.LFS.Include) > 0 {
+		s.LFS.Include = o.LFS.Include
+	}
+	if len(o.LFS.Exclude) > 0 {
+		s.LFS.Exclude = o.LFS.Exclude
+	}
+	if o.LFS.Prune != nil {
+		s.LFS.Prune = o.LFS.Prune
+	}
+	if o.Credentials.Username != "" {
+		s.Credentials.Username = o.Credentials.Username
+	}
+	if o.Credentials.TokenEnv != "" {
+		s.Credentials.TokenEnv = o.Credentials.TokenEnv
+	}
+	if o.Trusted != nil {
+		s.Trusted = o.Trusted
+	}
+	return s
+}
+
+// matches reports whether the entry applies to the repository at path
+func (s RepositorySettings) matches(path string) bool {
+	if s.Path == "" {
+		return false
+	}
+	if filepath.Clean(ExpandPath(s.Path)) == filepath.Clean(path) {
+		return true
+	}
+	return MatchPath(path, s.Path)
+}
+
+// isGlob reports whether the entry path contains glob metacharacters
+func (s RepositorySettings) isGlob() bool {
+	return strings.ContainsAny(s.Path, "*?[")
+}
+
+// LoadRepositoryFile reads the .gogitup.yaml committed in the repository at
+// path. A missing file yields empty settings.
+func LoadRepositoryFile(path string) (RepositorySettings, error) {
+	var settings RepositorySettings
+
+	file := filepath.Join(path, RepositoryFile)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return settings, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return settings, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if err := v.Unmarshal(&settings); err != nil {
+		return settings, fmt.Errorf("failed to unmarshal %s: %w", file, err)
+	}
+
+	// The path and trust of a repository are decided by the user only
+	settings.Path = ""
+	settings.Trusted = nil
+
+	return settings, nil
+}
+
+// SettingsFor resolves the settings for the repository at path. The defaults
+// of the config are applied first, then the repository's own .gogitup.yaml,
+// then the matching entries of the repositories section from the least to
+// the most specific: globs before exact paths, shorter globs before longer
+// ones.
+func (c *Config) SettingsFor(path string) (RepositorySettings, error) {
+	var matching []RepositorySettings
+	for _, entry := range c.Repositories {
+		if entry.matches(path) {
+			matching = append(matching, entry)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		if matching[i].isGlob() != matching[j].isGlob() {
+			return matching[i].isGlob()
+		}
+		return len(matching[i].Path) < len(matching[j].Path)
+	})
+
+	var user RepositorySettings
+	for _, entry := range matching {
+		user = user.merge(entry)
+	}
+
+	settings, err := LoadRepositoryFile(path)
+	if err != nil {
+		return RepositorySettings{}, err
+	}
+	if !c.Defaults.merge(user).isTrusted() {
+		// Never run hooks or pick credentials from an untrusted repository
+		if len(settings.Hooks.PreUpdate)+len(settings.Hooks.PostUpdate) > 0 {
+			slog.Debug("ignoring hooks of untrusted repository", "path", path)
+		}
+		settings.Hooks = Hooks{}
+		settings.Credentials = Credentials{}
+	}
+
+	settings = c.Defaults.merge(settings).merge(user)
+	settings.Path = path
+	slog.Debug("resolved repository settings", "path", path, "matching_entries", len(matching))
+
+	if err := settings.Validate(); err != nil {
+		return RepositorySettings{}, fmt.Errorf("invalid settings for %s: %w", path, err)
+	}
+
+	return settings, nil
+}
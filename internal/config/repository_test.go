@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositorySettings_Defaults(t *testing.T) {
+	var s RepositorySettings
+	assert.Equal(t, StrategyFastForward, s.UpdateStrategy())
+	assert.Equal(t, "origin", s.OriginRemote())
+	assert.Equal(t, "upstream", s.UpstreamRemote())
+	assert.True(t, s.ShouldPush())
+	assert.False(t, s.IsSkipped())
+	assert.False(t, s.IsPinned())
+	assert.False(t, s.ShouldUpdateSubmodules())
+	assert.NoError(t, s.Validate())
+
+	s.Strategy = "squash"
+	assert.ErrorContains(t, s.Validate(), "invalid strategy")
+
+	s.Strategy = ""
+	s.Submodules.Depth = -1
+	assert.ErrorContains(t, s.Validate(), "submodule")
+}
+
+func TestConfig_SettingsFor(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	// Repository with its own settings file
+	repoDir := filepath.Join(tmpDir, "work", "api")
+	require.NoError(t, os.MkdirAll(repoDir, 0755))
+	err = os.WriteFile(filepath.Join(repoDir, RepositoryFile), []byte(`
+strategy: rebase
+branch: develop
+push: false
+hooks:
+  post_update:
+    - make generate
+submodules:
+  update: true
+  depth: 1
+lfs:
+  include:
+    - assets/**
+credentials:
+  token_env: API_TOKEN
+`), 0644)
+	require.NoError(t, err)
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	err = os.WriteFile(configFile, []byte(`
+directories:
+  - `+tmpDir+`
+repositories:
+  - path: `+filepath.Join(repoDir)+`
+    timeout: 30s
+    upstream: source
+    submodules:
+      jobs: 4
+    lfs:
+      prune: true
+  - path: "`+filepath.Join(tmpDir, "work")+`/*"
+    strategy: merge
+    timeout: 5m
+  - path: "`+filepath.Join(tmpDir, "legacy")+`"
+    skip: true
+`), 0644)
+	require.NoError(t, err)
+
+	cfg, err := Load(configFile)
+	require.NoError(t, err)
+
+	t.Run("untrusted repository file", func(t *testing.T) {
+		s, err := cfg.SettingsFor(repoDir)
+		require.NoError(t, err)
+
+		// Glob entry overrides the repository file, exact path overrides the glob
+		assert.Equal(t, StrategyMerge, s.UpdateStrategy())
+		assert.Equal(t, 30*time.Second, s.Timeout)
+		assert.Equal(t, "source", s.UpstreamRemote())
+		assert.Equal(t, "develop", s.Branch)
+		assert.False(t, s.ShouldPush())
+		assert.True(t, s.ShouldUpdateSubmodules())
+		assert.Equal(t, 1, s.Submodules.Depth)
+		assert.Equal(t, 4, s.Submodules.Jobs)
+		assert.Equal(t, []string{"assets/**"}, s.LFS.Include)
+		assert.True(t, s.ShouldPruneLFS())
+
+		// Hooks and credentials of untrusted repositories are ignored
+		assert.Empty(t, s.Hooks.PostUpdate)
+		assert.Empty(t, s.Credentials.TokenEnv)
+	})
+
+	t.Run("trusted repository file", func(t *testing.T) {
+		trueVal := true
+		trusted := *cfg
+		trusted.Repositories = append([]RepositorySettings{{Path: repoDir, Trusted: &trueVal}}, cfg.Repositories...)
+
+		s, err := trusted.SettingsFor(repoDir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"make generate"}, s.Hooks.PostUpdate)
+		assert.Equal(t, "API_TOKEN", s.Credentials.TokenEnv)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		trueVal := true
+		defaults := *cfg
+		defaults.Defaults = RepositorySettings{
+			Strategy:    StrategyRebase,
+			Prune:       &trueVal,
+			Trusted:     &trueVal,
+			Credentials: Credentials{TokenEnv: "WORK_TOKEN"},
+		}
+
+		// The repository file and the repositories section override the
+		// defaults, trusted defaults trust every repository file
+		s, err := defaults.SettingsFor(repoDir)
+		require.NoError(t, err)
+		assert.Equal(t, StrategyMerge, s.UpdateStrategy())
+		assert.True(t, s.ShouldPrune())
+		assert.Equal(t, "API_TOKEN", s.Credentials.TokenEnv)
+		assert.Equal(t, []string{"make generate"}, s.Hooks.PostUpdate)
+
+		s, err = defaults.SettingsFor(filepath.Join(tmpDir, "other"))
+		require.NoError(t, err)
+		assert.Equal(t, StrategyRebase, s.UpdateStrategy())
+		assert.Equal(t, "WORK_TOKEN", s.Credentials.TokenEnv)
+	})
+
+	t.Run("skipped repository", func(t *testing.T) {
+		s, err := cfg.SettingsFor(filepath.Join(tmpDir, "legacy"))
+		require.NoError(t, err)
+		assert.True(t, s.IsSkipped())
+	})
+
+	t.Run("no matching entry", func(t *testing.T) {
+		s, err := cfg.SettingsFor(filepath.Join(tmpDir, "other"))
+		require.NoError(t, err)
+		assert.Equal(t, StrategyFastForward, s.UpdateStrategy())
+		assert.Zero(t, s.Timeout)
+	})
+
+	t.Run("invalid repository file", func(t *testing.T) {
+		badDir := filepath.Join(tmpDir, "bad")
+		require.NoError(t, os.MkdirAll(badDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(badDir, RepositoryFile), []byte("strategy: [yaml"), 0644))
+
+		_, err := cfg.SettingsFor(badDir)
+		assert.ErrorContains(t, err, "failed to read")
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		invalid := Config{Repositories: []RepositorySettings{{Path: repoDir, Strategy: "squash"}}}
+		_, err := invalid.SettingsFor(repoDir)
+		assert.ErrorContains(t, err, "invalid strategy")
+	})
+}
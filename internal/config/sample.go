@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// sampleTemplate is the config file written by WriteSample, documenting the
+// optional settings in comments
+var sampleTemplate = template.Must(template.New("config").Parse(`# gogitup configuration, see 'gogitup config validate' to check it
+
+# List of directories to scan for Git repositories. A leading ~ is the home
+# directory and environment variables such as ${GOPATH} are expanded.
+directories:
+{{- range .Directories}}
+  - {{printf "%q" .}}
+{{- end}}
+
+# Scan for new repositories before every update
+auto_scan: {{.AutoScan}}
+
+# Optional: further config files merged into this one
+# include:
+#   - ~/.config/gogitup/conf.d/*.yaml
+
+# Optional: named groups of repositories, selectable with --group
+# groups:
+#   work:
+#     directories:
+#       - ~/work/projects
+#     paths:
+#       - "*-service"
+#     repositories:
+#       - ~/repos/shared-tooling
+
+# Optional: settings of every repository
+# defaults:
+#   strategy: rebase
+#   prune: true
+
+# Optional: per-repository settings, matched by exact path or glob
+# repositories:
+#   - path: "~/work/projects/*"
+#     strategy: rebase
+#     timeout: 2m
+#     submodules:
+#       update: true
+#       jobs: 4
+#   - path: ~/repos/legacy
+#     skip: true
+
+# Optional: profiles selected with --profile or GOGITUP_PROFILE, each with
+# its own repository list
+# profiles:
+#   work:
+#     directories:
+#       - ~/work/projects
+#     defaults:
+#       credentials:
+#         token_env: WORK_GIT_TOKEN
+
+# Optional: list the commits pulled by 'gogitup update', as with --log
+# log:
+#   enabled: true
+#   limit: 10
+
+# Optional: Prometheus metrics, written to a node_exporter textfile after
+# every update and served by 'gogitup daemon'
+# metrics:
+#   textfile: /var/lib/node_exporter/textfile/gogitup.prom
+#   listen: localhost:9419
+
+# Optional: schedule of 'gogitup daemon'
+# daemon:
+#   interval: 1h
+#   jitter: 5m
+#   quiet_hours: "22:00-07:00"
+#   scan_interval: 24h
+#   watch: true
+`))
+
+// WriteSample writes a commented config file scanning directories
+func WriteSample(w io.Writer, directories []string, autoScan bool) error {
+	if len(directories) == 0 {
+		return fmt.Errorf("no directories given")
+	}
+	for _, dir := range directories {
+		if strings.TrimSpace(dir) == "" {
+			return fmt.Errorf("empty directory given")
+		}
+	}
+
+	return sampleTemplate.Execute(w, struct {
+		Directories []string
+		AutoScan    bool
+	}{directories, autoScan})
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSample(t *testing.T) {
+	tmpDir := t.TempDir()
+	var buf bytes.Buffer
+	require.NoError(t, WriteSample(&buf, []string{tmpDir, "/path/with: colon"}, false))
+
+	// The sample is a valid config without any unknown key
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, buf.Bytes(), 0644))
+	cfg, issues, err := Validate(configFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{tmpDir, "/path/with: colon"}, cfg.Directories)
+	require.NotNil(t, cfg.AutoScan)
+	assert.False(t, *cfg.AutoScan)
+	assert.Equal(t, []Issue{{Message: "directory /path/with: colon does not exist"}}, issues)
+
+	assert.Error(t, WriteSample(&buf, nil, true))
+	assert.Error(t, WriteSample(&buf, []string{" "}, true))
+}
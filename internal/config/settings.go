@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Settings returns the config as a map keyed like the config file, for
+// printing it. Unset values are left out and durations are formatted like
+// in the file.
+func (c *Config) Settings() map[string]any {
+	settings, _ := toSettings(reflect.ValueOf(*c)).(map[string]any)
+	if settings == nil {
+		settings = make(map[string]any)
+	}
+	return settings
+}
+
+// durationType is the type of the duration settings
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// toSettings converts v to settings following the mapstructure tags of
+// structs. Zero values convert to nil.
+func toSettings(v reflect.Value) any {
+	if v.IsZero() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		return toSettings(v.Elem())
+	case reflect.Struct:
+		m := make(map[string]any)
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			key := field.Tag.Get("mapstructure")
+			if !field.IsExported() || key == "" || key == "-" {
+				continue
+			}
+			// Pointers distinguish false from unset, so they are kept
+			// even when they point to a zero value
+			value := v.Field(i)
+			if value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().IsZero() {
+				m[key] = value.Elem().Interface()
+			} else if setting := toSettings(value); setting != nil {
+				m[key] = setting
+			}
+		}
+		if len(m) == 0 {
+			return nil
+		}
+		return m
+	case reflect.Slice:
+		list := make([]any, 0, v.Len())
+		for i := range v.Len() {
+			if setting := toSettings(v.Index(i)); setting != nil {
+				list = append(list, setting)
+			}
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		for _, key := range v.MapKeys() {
+			if setting := toSettings(v.MapIndex(key)); setting != nil {
+				m[fmt.Sprint(key.Interface())] = setting
+			} else {
+				m[fmt.Sprint(key.Interface())] = map[string]any{}
+			}
+		}
+		return m
+	}
+
+	if v.Type() == durationType {
+		return v.Interface().(time.Duration).String()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Settings(t *testing.T) {
+	falseVal := false
+	cfg := &Config{
+		Directories: []string{"/code"},
+		AutoScan:    &falseVal,
+		Groups:      map[string]Group{"oss": {Paths: []string{"*-tool"}}, "empty": {}},
+		Repositories: []RepositorySettings{
+			{Path: "/code/api", Timeout: 2 * time.Minute, Credentials: Credentials{TokenEnv: "TOKEN"}},
+		},
+		Daemon:  Daemon{Interval: 30 * time.Minute},
+		Profile: "work",
+	}
+
+	assert.Equal(t, map[string]any{
+		"directories": []any{"/code"},
+		"auto_scan":   false,
+		"groups": map[string]any{
+			"oss":   map[string]any{"paths": []any{"*-tool"}},
+			"empty": map[string]any{},
+		},
+		"repositories": []any{
+			map[string]any{"path": "/code/api", "timeout": "2m0s", "credentials": map[string]any{"token_env": "TOKEN"}},
+		},
+		"daemon": map[string]any{"interval": "30m0s"},
+	}, cfg.Settings())
+
+	assert.Empty(t, (&Config{}).Settings())
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// Issue is a problem found in a config file by Validate
+type Issue struct {
+	// Warning is set for issues that do not keep the config from working,
+	// such as an unset environment variable
+	Warning bool
+	Message string
+}
+
+// String formats the issue with its severity
+func (i Issue) String() string {
+	if i.Warning {
+		return "warning: " + i.Message
+	}
+	return "error: " + i.Message
+}
+
+// malformedVariable matches variable references os.ExpandEnv silently drops
+var malformedVariable = regexp.MustCompile(`\$\{[^}]*$|\$\{\}`)
+
+// Validate reads the config file at path like LoadProfile, but strictly: keys
+// that match no setting, such as a misspelled auto_scan, fail the whole
+// file. The decoded config is then checked for values that would only show
+// up as confusing behaviour at runtime. The config is returned along with
+// the issues found unless it cannot be read at all.
+func Validate(path, profile string) (*Config, []Issue, error) {
+	config, settings, err := load(path, profile, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issues []Issue
+	errorf := func(format string, args ...any) {
+		issues = append(issues, Issue{Message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(format string, args ...any) {
+		issues = append(issues, Issue{Warning: true, Message: fmt.Sprintf(format, args...)})
+	}
+
+	unset, malformed := checkVariables(settings)
+	for _, name := range unset {
+		warnf("environment variable %s is not set", name)
+	}
+	for _, value := range malformed {
+		errorf("malformed variable reference in %q", value)
+	}
+
+	if len(config.Directories) == 0 {
+		if config.Profile == "" && len(config.Profiles) > 0 {
+			errorf("no directories configured, select one of the profiles %s", profileList(slices.Sorted(maps.Keys(config.Profiles))))
+		} else {
+			errorf("no directories configured")
+		}
+	}
+	for _, dir := range config.Directories {
+		if info, err := os.Stat(dir); err != nil {
+			errorf("directory %s does not exist", dir)
+		} else if !info.IsDir() {
+			errorf("%s is not a directory", dir)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(config.Groups)) {
+		for _, dir := range config.Groups[name].Directories {
+			if _, err := os.Stat(ExpandPath(dir)); err != nil {
+				warnf("directory %s of group %s does not exist", dir, name)
+			}
+		}
+	}
+
+	if err := config.Defaults.Validate(); err != nil {
+		errorf("defaults: %v", err)
+	}
+	for i, entry := range config.Repositories {
+		if entry.Path == "" {
+			errorf("repositories entry %d has no path", i+1)
+			continue
+		}
+		if err := entry.Validate(); err != nil {
+			errorf("repositories entry %s: %v", entry.Path, err)
+		}
+	}
+
+	return config, issues, nil
+}
+
+// checkVariables returns the names of the unset environment variables the
+// settings refer to and the values with malformed references, both sorted.
+// Hooks are left out since the shell expands their variables, and so are
+// the profiles since the selected one is merged into the settings.
+func checkVariables(settings map[string]any) (unset, malformed []string) {
+	var walk func(value any)
+	walk = func(value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, child := range v {
+				if key != "hooks" && key != "profiles" {
+					walk(child)
+				}
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		case string:
+			if malformedVariable.MatchString(v) {
+				malformed = append(malformed, v)
+				return
+			}
+			os.Expand(v, func(name string) string {
+				if _, ok := os.LookupEnv(name); !ok && !slices.Contains(unset, name) {
+					unset = append(unset, name)
+				}
+				return ""
+			})
+		}
+	}
+	walk(settings)
+
+	slices.Sort(unset)
+	slices.Sort(malformed)
+	return unset, malformed
+}
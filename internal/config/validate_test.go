@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tmpDir := t.TempDir()
+	reposDir := filepath.Join(tmpDir, "repos")
+	require.NoError(t, os.MkdirAll(reposDir, 0755))
+	t.Setenv("GOGITUP_TEST_SET", "set")
+
+	tests := []struct {
+		name     string
+		content  string
+		wantErr  string
+		want     []Issue
+		wantDirs []string
+	}{
+		{
+			name:     "valid",
+			content:  "directories:\n  - " + reposDir + "\nauto_scan: false\n",
+			wantDirs: []string{reposDir},
+		},
+		{
+			name:    "unknown key",
+			content: "directories:\n  - " + reposDir + "\nauto_scann: false\n",
+			wantErr: "auto_scann",
+		},
+		{
+			name:    "unknown nested key",
+			content: "directories:\n  - " + reposDir + "\ndaemon:\n  intervall: 1h\n",
+			wantErr: "intervall",
+		},
+		{
+			name:    "missing directory",
+			content: "directories:\n  - " + reposDir + "\n  - " + filepath.Join(tmpDir, "missing") + "\n",
+			want:    []Issue{{Message: "directory " + filepath.Join(tmpDir, "missing") + " does not exist"}},
+		},
+		{
+			name: "environment variables",
+			content: "directories:\n  - " + reposDir + "\n  - ${GOGITUP_TEST_SET}/../repos\n" +
+				"metrics:\n  textfile: $GOGITUP_TEST_UNSET/gogitup.prom\n" +
+				"repositories:\n  - path: ${GOGITUP_TEST_BROKEN\n    hooks:\n      post_update:\n        - echo $GOGITUP_TEST_HOOK\n",
+			want: []Issue{
+				{Warning: true, Message: "environment variable GOGITUP_TEST_UNSET is not set"},
+				{Message: `malformed variable reference in "${GOGITUP_TEST_BROKEN"`},
+				{Message: "directory set/../repos does not exist"},
+			},
+		},
+		{
+			name:    "invalid repository settings",
+			content: "directories:\n  - " + reposDir + "\ndefaults:\n  strategy: squash\nrepositories:\n  - strategy: merge\n",
+			want: []Issue{
+				{Message: `defaults: invalid strategy "squash" (expected ff-only, rebase or merge)`},
+				{Message: "repositories entry 1 has no path"},
+			},
+		},
+		{
+			name:    "no directories",
+			content: "auto_scan: true\n",
+			want:    []Issue{{Message: "no directories configured"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(tmpDir, "config.yaml")
+			require.NoError(t, os.WriteFile(configFile, []byte(tt.content), 0644))
+
+			cfg, issues, err := Validate(configFile, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, issues)
+			if tt.wantDirs != nil {
+				assert.Equal(t, tt.wantDirs, cfg.Directories)
+			}
+		})
+	}
+}
+
+func TestIssue_String(t *testing.T) {
+	assert.Equal(t, "warning: unset", Issue{Warning: true, Message: "unset"}.String())
+	assert.Equal(t, "error: missing", Issue{Message: "missing"}.String())
+}
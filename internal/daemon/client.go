@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrRunning is returned when a run is requested while one is in progress
+var ErrRunning = errors.New("an update is already running")
+
+// newClient returns an HTTP client that talks to the daemon over its socket
+func newClient(socket string) *http.Client {
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// GetStatus asks the daemon listening on socket for its status
+func GetStatus(socket string) (*Status, error) {
+	resp, err := newClient(socket).Get("http://gogitup/status")
+	if err != nil {
+		return nil, fmt.Errorf("daemon is not running (socket %s): %w", socket, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode daemon status: %w", err)
+	}
+	return &status, nil
+}
+
+// Trigger asks the daemon listening on socket to start a run now
+func Trigger(socket string) error {
+	resp, err := newClient(socket).Post("http://gogitup/trigger", "", nil)
+	if err != nil {
+		return fmt.Errorf("daemon is not running (socket %s): %w", socket, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return nil
+	case http.StatusConflict:
+		return ErrRunning
+	}
+	return responseError(resp)
+}
+
+// responseError turns an unexpected response into an error
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("daemon returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,316 @@
+// Package daemon runs scheduled repository updates in the background and
+// exposes their state over a local Unix socket.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/trutx/gogitup/internal/metrics"
+)
+
+// States reported by a running daemon
+const (
+	StateIdle    = "idle"
+	StateRunning = "running"
+	StateQuiet   = "quiet"
+)
+
+// Summary describes the outcome of a single update run
+type Summary struct {
+	Started      time.Time `json:"started"`
+	Finished     time.Time `json:"finished"`
+	Scanned      bool      `json:"scanned"`
+	Repositories int       `json:"repositories"`
+	Updated      int       `json:"updated"`
+	Warnings     int       `json:"warnings"`
+	Errors       []string  `json:"errors,omitempty"`
+}
+
+// Status is the state of a running daemon as reported over its socket
+type Status struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	State   string    `json:"state"`
+	Runs    int       `json:"runs"`
+	NextRun time.Time `json:"next_run"`
+	LastRun *Summary  `json:"last_run,omitempty"`
+	// LastError is set when the last run failed as a whole, e.g. because
+	// the config could not be loaded
+	LastError string `json:"last_error,omitempty"`
+}
+
+// RunFunc performs a single update run, rescanning the configured
+// directories first when scan is true
+type RunFunc func(ctx context.Context, scan bool) (Summary, error)
+
+// Options configure a Daemon
+type Options struct {
+	// Socket is the path of the control socket
+	Socket string
+	// Interval is the time between two runs, Jitter the maximum random
+	// delay added to it
+	Interval time.Duration
+	Jitter   time.Duration
+	// ScanInterval is the minimum time between two rescans, 0 disables them
+	ScanInterval time.Duration
+	// QuietHours suspend scheduled runs, nil means no quiet hours
+	QuietHours *QuietHours
+	// Run performs the actual scan and update
+	Run RunFunc
+	// Logf logs progress messages, it may be nil
+	Logf func(format string, args ...any)
+	// Metrics writes the Prometheus metrics of the repositories served on
+	// /metrics after the daemon's own ones, it may be nil
+	Metrics func(w io.Writer) error
+	// MetricsListen is a TCP address on which /metrics is served as well,
+	// the control socket is not reachable by Prometheus
+	MetricsListen string
+}
+
+// Daemon runs updates on a schedule until its context is cancelled. Only one
+// run is in progress at any time.
+type Daemon struct {
+	opts     Options
+	trigger  chan struct{}
+	lastScan time.Time
+
+	mu     sync.Mutex
+	status Status
+	// failures counts the runs that failed as a whole
+	failures int
+}
+
+// New returns a daemon with the given options
+func New(opts Options) *Daemon {
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+	return &Daemon{
+		opts:    opts,
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// Status returns a snapshot of the daemon's state
+func (d *Daemon) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := d.status
+	if status.LastRun != nil {
+		last := *status.LastRun
+		status.LastRun = &last
+	}
+	return status
+}
+
+// setState updates the state and the time of the next scheduled run
+func (d *Daemon) setState(state string, next time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.State = state
+	d.status.NextRun = next
+}
+
+// Trigger requests an immediate run. It returns ErrRunning if a run is in
+// progress already.
+func (d *Daemon) Trigger() error {
+	if d.Status().State == StateRunning {
+		return ErrRunning
+	}
+	select {
+	case d.trigger <- struct{}{}:
+	default:
+		// A run is queued already
+	}
+	return nil
+}
+
+// Run listens on the control socket and runs updates on schedule until ctx
+// is cancelled. The first run starts immediately unless it falls within the
+// quiet hours.
+func (d *Daemon) Run(ctx context.Context) error {
+	listener, err := listen(d.opts.Socket)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: d.handler()}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			d.opts.Logf("control socket failed: %v", err)
+		}
+	}()
+	defer func() {
+		_ = server.Close()
+		_ = os.Remove(d.opts.Socket)
+	}()
+
+	if d.opts.MetricsListen != "" {
+		metricsListener, err := net.Listen("tcp", d.opts.MetricsListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", d.opts.MetricsListen, err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /metrics", d.serveMetrics)
+		metricsServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := metricsServer.Serve(metricsListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				d.opts.Logf("metrics listener failed: %v", err)
+			}
+		}()
+		defer func() { _ = metricsServer.Close() }()
+		d.opts.Logf("serving metrics on http://%s/metrics", metricsListener.Addr())
+	}
+
+	d.mu.Lock()
+	d.status = Status{PID: os.Getpid(), Started: time.Now(), State: StateIdle}
+	d.mu.Unlock()
+	d.opts.Logf("daemon started, listening on %s", d.opts.Socket)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.opts.Logf("daemon stopped")
+			return nil
+		case <-d.trigger:
+			d.opts.Logf("update triggered")
+		case <-timer.C:
+			if q := d.opts.QuietHours; q != nil && q.Contains(time.Now()) {
+				end := q.End(time.Now())
+				d.setState(StateQuiet, end)
+				d.opts.Logf("quiet hours %s, next update at %s", q, end.Format(time.Kitchen))
+				timer.Reset(time.Until(end))
+				continue
+			}
+		}
+
+		d.runOnce(ctx)
+
+		delay := nextDelay(d.opts.Interval, d.opts.Jitter)
+		d.setState(StateIdle, time.Now().Add(delay))
+		timer.Reset(delay)
+	}
+}
+
+// runOnce performs a single run and records its outcome
+func (d *Daemon) runOnce(ctx context.Context) {
+	scan := d.opts.ScanInterval > 0 && time.Since(d.lastScan) >= d.opts.ScanInterval
+	d.setState(StateRunning, time.Time{})
+
+	summary, err := d.opts.Run(ctx, scan)
+	if summary.Scanned {
+		d.lastScan = summary.Started
+	}
+
+	d.mu.Lock()
+	d.status.Runs++
+	d.status.LastRun = &summary
+	d.status.LastError = ""
+	if err != nil {
+		d.status.LastError = err.Error()
+		d.failures++
+	}
+	d.mu.Unlock()
+
+	if err != nil {
+		d.opts.Logf("update failed: %v", err)
+		return
+	}
+	d.opts.Logf("updated %d of %d repositories (%d warnings, %d errors)",
+		summary.Updated, summary.Repositories, summary.Warnings, len(summary.Errors))
+}
+
+// handler serves the control API
+func (d *Daemon) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(d.Status())
+	})
+	mux.HandleFunc("POST /trigger", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.Trigger(); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /metrics", d.serveMetrics)
+	return mux
+}
+
+// serveMetrics writes the daemon's metrics followed by the repository
+// metrics in the Prometheus text format
+func (d *Daemon) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+	d.writeMetrics(&b)
+	if d.opts.Metrics != nil {
+		if err := d.opts.Metrics(&b); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", metrics.ContentType)
+	_, _ = w.Write(b.Bytes())
+}
+
+// writeMetrics writes the metrics of the daemon itself
+func (d *Daemon) writeMetrics(w io.Writer) {
+	d.mu.Lock()
+	status, failures := d.status, d.failures
+	d.mu.Unlock()
+
+	running := 0
+	if status.State == StateRunning {
+		running = 1
+	}
+	fmt.Fprintf(w, "# HELP gogitup_daemon_runs_total Number of update runs of the daemon.\n# TYPE gogitup_daemon_runs_total counter\n")
+	fmt.Fprintf(w, "gogitup_daemon_runs_total %d\n", status.Runs)
+	fmt.Fprintf(w, "# HELP gogitup_daemon_run_failures_total Number of update runs that failed as a whole.\n# TYPE gogitup_daemon_run_failures_total counter\n")
+	fmt.Fprintf(w, "gogitup_daemon_run_failures_total %d\n", failures)
+	fmt.Fprintf(w, "# HELP gogitup_daemon_running Whether an update run is in progress.\n# TYPE gogitup_daemon_running gauge\n")
+	fmt.Fprintf(w, "gogitup_daemon_running %d\n", running)
+	if status.LastRun != nil && !status.LastRun.Finished.IsZero() {
+		fmt.Fprintf(w, "# HELP gogitup_last_run_timestamp_seconds Unix time of the last update run.\n# TYPE gogitup_last_run_timestamp_seconds gauge\n")
+		fmt.Fprintf(w, "gogitup_last_run_timestamp_seconds %d\n", status.LastRun.Finished.Unix())
+	}
+}
+
+// listen creates the control socket, replacing a stale socket left behind by
+// a daemon that did not shut down cleanly
+func listen(socket string) (net.Listener, error) {
+	if _, err := os.Stat(socket); err == nil {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("daemon is already running (socket %s)", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,181 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startDaemon runs a daemon with the given run function until the test ends
+func startDaemon(t *testing.T, opts Options) (*Daemon, string) {
+	t.Helper()
+
+	opts.Socket = filepath.Join(t.TempDir(), "daemon.sock")
+	if opts.Interval == 0 {
+		opts.Interval = time.Hour
+	}
+	d := New(opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		_, err := os.Stat(opts.Socket)
+		assert.True(t, os.IsNotExist(err), "socket should be removed on shutdown")
+	})
+
+	require.Eventually(t, func() bool {
+		_, err := GetStatus(opts.Socket)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return d, opts.Socket
+}
+
+func TestDaemon_RunAndTrigger(t *testing.T) {
+	var runs, scans atomic.Int32
+	_, socket := startDaemon(t, Options{
+		ScanInterval: time.Hour,
+		Run: func(ctx context.Context, scan bool) (Summary, error) {
+			runs.Add(1)
+			if scan {
+				scans.Add(1)
+			}
+			return Summary{Started: time.Now(), Finished: time.Now(), Scanned: scan, Repositories: 3, Updated: 2, Warnings: 1}, nil
+		},
+	})
+
+	// The first run starts immediately
+	require.Eventually(t, func() bool {
+		status, err := GetStatus(socket)
+		return err == nil && status.Runs == 1 && status.State == StateIdle
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status, err := GetStatus(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), status.PID)
+	require.NotNil(t, status.LastRun)
+	assert.Equal(t, 2, status.LastRun.Updated)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), status.NextRun, time.Minute)
+
+	// A triggered run does not rescan within the scan interval
+	require.NoError(t, Trigger(socket))
+	require.Eventually(t, func() bool { return runs.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), scans.Load())
+}
+
+func TestDaemon_TriggerWhileRunning(t *testing.T) {
+	release := make(chan struct{})
+	_, socket := startDaemon(t, Options{
+		Run: func(ctx context.Context, scan bool) (Summary, error) {
+			<-release
+			return Summary{}, errors.New("config missing")
+		},
+	})
+
+	require.Eventually(t, func() bool {
+		status, err := GetStatus(socket)
+		return err == nil && status.State == StateRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, Trigger(socket), ErrRunning)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		status, err := GetStatus(socket)
+		return err == nil && status.Runs == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	status, err := GetStatus(socket)
+	require.NoError(t, err)
+	assert.Equal(t, "config missing", status.LastError)
+}
+
+func TestDaemon_QuietHours(t *testing.T) {
+	// Quiet hours covering the whole day except the next minute
+	now := time.Now()
+	quiet, err := ParseQuietHours(now.Add(2*time.Minute).Format("15:04") + "-" + now.Add(time.Minute).Format("15:04"))
+	require.NoError(t, err)
+	if !quiet.Contains(now) {
+		t.Skip("clock crossed a minute boundary")
+	}
+
+	var runs atomic.Int32
+	_, socket := startDaemon(t, Options{
+		QuietHours: quiet,
+		Run: func(ctx context.Context, scan bool) (Summary, error) {
+			runs.Add(1)
+			return Summary{}, nil
+		},
+	})
+
+	require.Eventually(t, func() bool {
+		status, err := GetStatus(socket)
+		return err == nil && status.State == StateQuiet
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Zero(t, runs.Load())
+
+	// Triggered runs ignore the quiet hours
+	require.NoError(t, Trigger(socket))
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDaemon_AlreadyRunning(t *testing.T) {
+	_, socket := startDaemon(t, Options{
+		Run: func(ctx context.Context, scan bool) (Summary, error) {
+			return Summary{}, nil
+		},
+	})
+
+	second := New(Options{Socket: socket, Interval: time.Hour})
+	err := second.Run(context.Background())
+	assert.ErrorContains(t, err, "already running")
+}
+
+func TestClient_NotRunning(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+
+	_, err := GetStatus(socket)
+	assert.ErrorContains(t, err, "daemon is not running")
+	assert.ErrorContains(t, Trigger(socket), "daemon is not running")
+}
+
+func TestDaemon_Metrics(t *testing.T) {
+	_, socket := startDaemon(t, Options{
+		Run: func(ctx context.Context, scan bool) (Summary, error) {
+			return Summary{}, errors.New("config missing")
+		},
+		Metrics: func(w io.Writer) error {
+			_, err := io.WriteString(w, "gogitup_repositories 2\n")
+			return err
+		},
+	})
+
+	require.Eventually(t, func() bool {
+		status, err := GetStatus(socket)
+		return err == nil && status.Runs == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resp, err := newClient(socket).Get("http://gogitup/metrics")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, string(body), "gogitup_daemon_runs_total 1\n")
+	assert.Contains(t, string(body), "gogitup_daemon_run_failures_total 1\n")
+	assert.Contains(t, string(body), "gogitup_daemon_running 0\n")
+	assert.Contains(t, string(body), "gogitup_repositories 2\n")
+}
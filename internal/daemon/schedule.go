@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily local time range during which no scheduled updates
+// run. The range may wrap around midnight.
+type QuietHours struct {
+	start, end time.Duration
+}
+
+// ParseQuietHours parses a range such as "22:00-07:00". An empty string
+// returns nil, meaning no quiet hours.
+func ParseQuietHours(s string) (*QuietHours, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: %w", s, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours %q: %w", s, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid quiet hours %q: start and end are equal", s)
+	}
+
+	return &QuietHours{start: start, end: end}, nil
+}
+
+// parseClock parses HH:MM into the offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String returns the range in the HH:MM-HH:MM form
+func (q *QuietHours) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return clock(q.start) + "-" + clock(q.end)
+}
+
+// Contains reports whether t falls within the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if q.start < q.end {
+		return offset >= q.start && offset < q.end
+	}
+	return offset >= q.start || offset < q.end
+}
+
+// End returns the end of the quiet hours that contain t
+func (q *QuietHours) End(t time.Time) time.Time {
+	end := midnight(t).Add(q.end)
+	if !end.After(t) {
+		end = midnight(t.AddDate(0, 0, 1)).Add(q.end)
+	}
+	return end
+}
+
+// sinceMidnight returns the wall clock offset of t from its local midnight
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// midnight returns the local midnight starting the day of t
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// nextDelay returns the delay until the next scheduled run: the interval plus
+// a random jitter in [0, jitter)
+func nextDelay(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + rand.N(jitter)
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantNil bool
+		wantErr string
+	}{
+		{name: "empty", input: "", wantNil: true},
+		{name: "same day", input: "12:00-13:30", want: "12:00-13:30"},
+		{name: "over midnight", input: "22:00 - 07:00", want: "22:00-07:00"},
+		{name: "missing separator", input: "22:00", wantErr: "expected HH:MM-HH:MM"},
+		{name: "invalid time", input: "25:00-07:00", wantErr: "invalid time"},
+		{name: "empty range", input: "07:00-07:00", wantErr: "start and end are equal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseQuietHours(tt.input)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, q)
+				return
+			}
+			assert.Equal(t, tt.want, q.String())
+		})
+	}
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 15, hour, minute, 0, 0, time.Local)
+	}
+
+	night, err := ParseQuietHours("22:00-07:00")
+	require.NoError(t, err)
+	lunch, err := ParseQuietHours("12:00-13:00")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		quiet *QuietHours
+		time  time.Time
+		want  bool
+	}{
+		{"before night", night, at(21, 59), false},
+		{"night start", night, at(22, 0), true},
+		{"after midnight", night, at(3, 0), true},
+		{"night end", night, at(7, 0), false},
+		{"lunch", lunch, at(12, 30), true},
+		{"after lunch", lunch, at(13, 0), false},
+		{"morning", lunch, at(9, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.quiet.Contains(tt.time))
+		})
+	}
+}
+
+func TestQuietHours_End(t *testing.T) {
+	q, err := ParseQuietHours("22:00-07:00")
+	require.NoError(t, err)
+
+	// Before midnight the quiet hours end the next morning
+	evening := time.Date(2024, 3, 15, 23, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2024, 3, 16, 7, 0, 0, 0, time.Local), q.End(evening))
+
+	// After midnight they end the same morning
+	night := time.Date(2024, 3, 16, 2, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2024, 3, 16, 7, 0, 0, 0, time.Local), q.End(night))
+}
+
+func TestNextDelay(t *testing.T) {
+	assert.Equal(t, time.Hour, nextDelay(time.Hour, 0))
+
+	for range 100 {
+		delay := nextDelay(time.Hour, 10*time.Minute)
+		assert.GreaterOrEqual(t, delay, time.Hour)
+		assert.Less(t, delay, time.Hour+10*time.Minute)
+	}
+}
@@ -0,0 +1,296 @@
+// Package doctor checks the environment updates run in: the git toolchain,
+// the SSH agent and the credentials of the remote hosts.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// MinGitVersion is the oldest git release providing every command updates
+// run, 'git stash push' being the most recent of them
+const MinGitVersion = "2.13"
+
+// accessTimeout bounds the connection to a remote host
+const accessTimeout = 30 * time.Second
+
+// gitHubAPI is the GitHub API the tokens of github.com are checked against
+var gitHubAPI = "https://api.github.com"
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	OK Status = iota
+	Warning
+	Error
+)
+
+// String returns the name of the status
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Warning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// Result is the outcome of a check, with how to fix it unless it passed
+type Result struct {
+	Name    string
+	Status  Status
+	Message string
+	Fix     string
+}
+
+// parseVersion returns the numeric components of a version such as
+// "2.39.5" or "2.45.1.windows.1", stopping at the first non-numeric one
+func parseVersion(version string) []int {
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// atLeast reports whether version is min or newer
+func atLeast(version, min string) bool {
+	return slices.Compare(parseVersion(version), parseVersion(min)) >= 0
+}
+
+// Git checks that git is installed and recent enough
+func Git(ctx context.Context) Result {
+	result := Result{Name: "git"}
+	out, err := exec.CommandContext(ctx, "git", "--version").Output()
+	if err != nil {
+		result.Status = Error
+		result.Message = fmt.Sprintf("git is not installed: %v", err)
+		result.Fix = "install git from https://git-scm.com"
+		return result
+	}
+
+	// e.g. "git version 2.39.3 (Apple Git-146)"
+	fields := strings.Fields(string(out))
+	if len(fields) < 3 || len(parseVersion(fields[2])) == 0 {
+		result.Status = Warning
+		result.Message = fmt.Sprintf("unknown git version %q", strings.TrimSpace(string(out)))
+		return result
+	}
+
+	version := fields[2]
+	if !atLeast(version, MinGitVersion) {
+		result.Status = Error
+		result.Message = fmt.Sprintf("git %s is too old, %s or newer is required", version, MinGitVersion)
+		result.Fix = "upgrade git from https://git-scm.com"
+		return result
+	}
+	result.Message = "version " + version
+	return result
+}
+
+// LFS checks that git-lfs is installed. It is only required when needed is
+// set, i.e. when a repository uses Git LFS.
+func LFS(ctx context.Context, needed bool) Result {
+	result := Result{Name: "git-lfs"}
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		result.Message = "not installed, no repository uses Git LFS"
+		if needed {
+			result.Status = Error
+			result.Message = "not installed, but repositories use Git LFS"
+			result.Fix = "install git-lfs from https://git-lfs.com and run 'git lfs install'"
+		}
+		return result
+	}
+
+	// e.g. "git-lfs/3.4.0 (GitHub; linux amd64; go 1.21.1)"
+	out, err := exec.CommandContext(ctx, "git-lfs", "version").Output()
+	if err != nil {
+		result.Status = Error
+		result.Message = fmt.Sprintf("git-lfs does not run: %v", err)
+		result.Fix = "reinstall git-lfs from https://git-lfs.com"
+		return result
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(string(out)), "git-lfs/"), " ")
+	result.Message = "version " + version
+	return result
+}
+
+// SSHAgent checks that an SSH agent is running and holds keys. Keys without
+// a passphrase work without an agent, so its problems are warnings.
+func SSHAgent(ctx context.Context) Result {
+	result := Result{Name: "ssh-agent"}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		result.Status = Warning
+		result.Message = "no SSH agent is running, SSH_AUTH_SOCK is not set"
+		result.Fix = "start one with 'eval $(ssh-agent)' and add your key with 'ssh-add'"
+		return result
+	}
+
+	out, err := exec.CommandContext(ctx, "ssh-add", "-l").Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		keys := len(strings.Split(strings.TrimSpace(string(out)), "\n"))
+		result.Message = fmt.Sprintf("%d keys loaded", keys)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		result.Status = Warning
+		result.Message = "the SSH agent has no keys"
+		result.Fix = "add your key with 'ssh-add'"
+	default:
+		result.Status = Warning
+		result.Message = fmt.Sprintf("cannot connect to the SSH agent at %s: %v", socket, err)
+		result.Fix = "start one with 'eval $(ssh-agent)' and add your key with 'ssh-add'"
+	}
+	return result
+}
+
+// GitHubToken checks that GitHub accepts token. Classic tokens must have
+// the repo scope, which private repositories and pushes to forks need.
+func GitHubToken(ctx context.Context, token string) Result {
+	result := Result{Name: "github.com token"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gitHubAPI+"/user", nil)
+	if err != nil {
+		result.Status = Error
+		result.Message = err.Error()
+		return result
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		result.Status = Warning
+		result.Message = fmt.Sprintf("cannot reach the GitHub API: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		result.Status = Error
+		result.Message = "GitHub rejects the token, it is invalid or expired"
+		result.Fix = "create a new token at https://github.com/settings/tokens"
+		return result
+	case resp.StatusCode != http.StatusOK:
+		result.Status = Warning
+		result.Message = fmt.Sprintf("the GitHub API returned %s", resp.Status)
+		return result
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&user)
+
+	// Only classic tokens have scopes, fine-grained ones are granted access
+	// per repository
+	scopes, classic := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]
+	if !classic {
+		result.Message = fmt.Sprintf("fine-grained token of %s", user.Login)
+		return result
+	}
+	var granted []string
+	for _, scope := range strings.Split(strings.Join(scopes, ","), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			granted = append(granted, scope)
+		}
+	}
+	if !slices.Contains(granted, "repo") {
+		result.Status = Warning
+		result.Message = fmt.Sprintf("the token of %s lacks the repo scope, private repositories cannot be fetched and forks cannot be pushed", user.Login)
+		result.Fix = "grant it the repo scope at https://github.com/settings/tokens"
+		return result
+	}
+	result.Message = fmt.Sprintf("token of %s with scopes %s", user.Login, strings.Join(granted, ", "))
+	return result
+}
+
+// Host is a remote host the repositories are updated from
+type Host struct {
+	Name string
+	SSH  bool
+	// Repository is the first repository updated from the host, used to
+	// check access to it
+	Repository *git.Repository
+}
+
+// Hosts returns the hosts repos are updated from, sorted by name. The
+// repositories must be configured since the remote depends on the settings.
+func Hosts(repos []*git.Repository) []Host {
+	var hosts []Host
+	seen := make(map[string]bool)
+	for _, repo := range repos {
+		name, _, ssh := git.ParseRemoteURL(repo.RemoteURL(repo.FetchRemote()))
+		key := fmt.Sprintf("%s %t", name, ssh)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		hosts = append(hosts, Host{Name: name, SSH: ssh, Repository: repo})
+	}
+
+	slices.SortFunc(hosts, func(a, b Host) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		if a.SSH == b.SSH {
+			return 0
+		}
+		if a.SSH {
+			return 1
+		}
+		return -1
+	})
+	return hosts
+}
+
+// Check connects to the host with the credentials of its repository and,
+// for github.com, checks the token
+func (h Host) Check(ctx context.Context) []Result {
+	ctx, cancel := context.WithTimeout(ctx, accessTimeout)
+	defer cancel()
+
+	name := h.Name
+	if h.SSH {
+		name += " (ssh)"
+	}
+	result := Result{Name: name}
+	if err := h.Repository.CheckAccess(ctx); err != nil {
+		result.Status = Error
+		result.Message = fmt.Sprintf("%s: %v", h.Repository.Path, err)
+		if h.SSH {
+			result.Fix = fmt.Sprintf("check that your SSH key is loaded with 'ssh-add -l' and registered on %s", h.Name)
+		} else {
+			result.Fix = "set a token with access to the repository in GITHUB_TOKEN for GitHub, or in the variable named by credentials.token_env in the repository settings"
+		}
+	} else {
+		result.Message = "reachable from " + h.Repository.Path
+	}
+
+	results := []Result{result}
+	if h.Name == "github.com" && !h.SSH {
+		if token := h.Repository.Token(h.Repository.RemoteURL(h.Repository.FetchRemote())); token != "" {
+			results = append(results, GitHubToken(ctx, token))
+		}
+	}
+	return results
+}
@@ -0,0 +1,143 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"2.39.5", true},
+		{"2.13", true},
+		{"2.13.0", true},
+		{"2.45.1.windows.1", true},
+		{"2.12.5", false},
+		{"1.9", false},
+		{"10.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.want, atLeast(tt.version, MinGitVersion))
+		})
+	}
+}
+
+func TestGit(t *testing.T) {
+	result := Git(context.Background())
+	assert.Equal(t, OK, result.Status, result.Message)
+	assert.True(t, strings.HasPrefix(result.Message, "version "), result.Message)
+}
+
+func TestSSHAgent_NotRunning(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	result := SSHAgent(context.Background())
+	assert.Equal(t, Warning, result.Status)
+	assert.Contains(t, result.Message, "SSH_AUTH_SOCK is not set")
+	assert.NotEmpty(t, result.Fix)
+}
+
+func TestGitHubToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		scopes  *string
+		want    Status
+		message string
+	}{
+		{name: "classic token", status: http.StatusOK, scopes: ptr("repo, read:org"), want: OK, message: "token of octocat with scopes repo, read:org"},
+		{name: "missing repo scope", status: http.StatusOK, scopes: ptr("read:org"), want: Warning, message: "lacks the repo scope"},
+		{name: "no scopes", status: http.StatusOK, scopes: ptr(""), want: Warning, message: "lacks the repo scope"},
+		{name: "fine-grained token", status: http.StatusOK, want: OK, message: "fine-grained token of octocat"},
+		{name: "rejected", status: http.StatusUnauthorized, want: Error, message: "GitHub rejects the token"},
+		{name: "server error", status: http.StatusBadGateway, want: Warning, message: "the GitHub API returned 502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/user", r.URL.Path)
+				assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+				if tt.scopes != nil {
+					w.Header().Set("X-OAuth-Scopes", *tt.scopes)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"login": "octocat"}`))
+			}))
+			defer server.Close()
+
+			previous := gitHubAPI
+			gitHubAPI = server.URL
+			defer func() { gitHubAPI = previous }()
+
+			result := GitHubToken(context.Background(), "secret")
+			assert.Equal(t, tt.want, result.Status, result.Message)
+			assert.Contains(t, result.Message, tt.message)
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+// initRepository creates a repository with the given origin URL
+func initRepository(t *testing.T, origin string) *git.Repository {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", origin}} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	repo, err := git.OpenRepository(dir)
+	require.NoError(t, err)
+	repo.Configure(config.RepositorySettings{})
+	return repo
+}
+
+func TestHosts(t *testing.T) {
+	repos := []*git.Repository{
+		initRepository(t, "https://github.com/trutx/a.git"),
+		initRepository(t, "git@github.com:trutx/b.git"),
+		initRepository(t, "https://github.com/trutx/c.git"),
+		initRepository(t, "https://gitlab.com/trutx/d.git"),
+		initRepository(t, "/srv/git/e.git"),
+	}
+
+	hosts := Hosts(repos)
+	require.Len(t, hosts, 3)
+	assert.Equal(t, Host{Name: "github.com", Repository: repos[0]}, hosts[0])
+	assert.Equal(t, Host{Name: "github.com", SSH: true, Repository: repos[1]}, hosts[1])
+	assert.Equal(t, Host{Name: "gitlab.com", Repository: repos[3]}, hosts[2])
+}
+
+func TestHost_Check(t *testing.T) {
+	remote := t.TempDir()
+	out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	host := Host{Name: "example.com", Repository: initRepository(t, remote)}
+	results := host.Check(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, OK, results[0].Status, results[0].Message)
+
+	host = Host{Name: "example.com", SSH: true, Repository: initRepository(t, filepath.Join(remote, "missing"))}
+	results = host.Check(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, Error, results[0].Status)
+	assert.Equal(t, "example.com (ssh)", results[0].Name)
+	assert.Contains(t, results[0].Fix, "ssh-add -l")
+}
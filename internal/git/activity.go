@@ -0,0 +1,40 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LastActivity returns when the repository was last worked on: the last
+// checkout, clone or commit recorded in the reflog of HEAD, or the newest
+// commit of a local branch committed by the configured user, whichever is
+// later. Updates fast-forward, merge and rebase branches without counting as
+// activity. It is zero when neither is known, e.g. once the reflog expired.
+func (r *Repository) LastActivity(ctx context.Context) time.Time {
+	var last time.Time
+
+	// Reflog selectors are formatted as HEAD@{<unix time>} with --date=unix
+	out, err := r.gitCommand(ctx, "log", "-g", "-1", "--date=unix", "--format=%gd",
+		"--grep-reflog=^checkout:", "--grep-reflog=^clone:", "--grep-reflog=^commit", "HEAD").Output()
+	if err == nil {
+		selector := strings.TrimSpace(string(out))
+		selector = strings.TrimSuffix(strings.TrimPrefix(selector, "HEAD@{"), "}")
+		if seconds, err := strconv.ParseInt(selector, 10, 64); err == nil {
+			last = time.Unix(seconds, 0)
+		}
+	}
+
+	out, err = r.gitCommand(ctx, "config", "user.email").Output()
+	if email := strings.TrimSpace(string(out)); err == nil && email != "" {
+		out, err := r.gitCommand(ctx, "log", "--branches", "--fixed-strings", "--committer="+email, "-1", "--format=%ct").Output()
+		if seconds, parseErr := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil && parseErr == nil {
+			if committed := time.Unix(seconds, 0); committed.After(last) {
+				last = committed
+			}
+		}
+	}
+
+	return last
+}
@@ -0,0 +1,53 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+func TestRepository_LastActivity(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	// The test repository is created by go-git, which writes no reflog
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+	r.Configure(appconfig.RepositorySettings{})
+	assert.True(t, r.LastActivity(context.Background()).IsZero())
+
+	// A commit of the configured user
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old.txt"), []byte("old"), 0644))
+	runGit(t, dir, "add", "old.txt")
+	cmd := exec.Command("git", "commit", "-q", "-m", "Old change")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+old.Format(time.RFC3339))
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	runGit(t, dir, "push", "-q", "origin", "master")
+	assert.True(t, old.Equal(r.LastActivity(context.Background())))
+
+	// Updating from the remote is no activity
+	pushToOrigin(t, dir, "remote.txt", "Remote change")
+	runGit(t, dir, "pull", "-q", "--ff-only", "origin", "master")
+	assert.True(t, old.Equal(r.LastActivity(context.Background())))
+
+	// Checking out a branch is
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	assert.WithinDuration(t, time.Now(), r.LastActivity(context.Background()), time.Minute)
+}
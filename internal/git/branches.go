@@ -0,0 +1,138 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StaleBranch is a local branch that can be cleaned up
+type StaleBranch struct {
+	Name string
+	// Merged is set when the branch is fully merged into the default branch
+	Merged bool
+	// UpstreamGone is set when the branch tracked a remote branch that no
+	// longer exists
+	UpstreamGone bool
+	// Unpushed counts the commits of the branch that no remote branch
+	// contains, they are lost when the branch is deleted
+	Unpushed int
+}
+
+// Reason returns a human readable description of why the branch is stale
+func (b StaleBranch) Reason() string {
+	switch {
+	case b.Merged && b.UpstreamGone:
+		return "merged, upstream gone"
+	case b.Merged:
+		return "merged"
+	case b.Unpushed > 0:
+		return fmt.Sprintf("upstream gone, %d commits on no remote", b.Unpushed)
+	default:
+		return "upstream gone, not merged"
+	}
+}
+
+// DefaultBranch returns the remote-tracking ref of the default branch, e.g.
+// "origin/main". Forks use the upstream remote. It falls back to main or
+// master when the remote HEAD is unknown.
+func (r *Repository) DefaultBranch(ctx context.Context) (string, error) {
+	remote := r.settings.OriginRemote()
+	if r.hasUpstream() {
+		remote = r.settings.UpstreamRemote()
+	}
+
+	out, err := r.gitCommand(ctx, "symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD").Output()
+	if err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	for _, name := range []string{"main", "master"} {
+		ref := remote + "/" + name
+		if err := r.gitCommand(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/"+ref).Run(); err == nil {
+			return ref, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to determine default branch of %s", remote)
+}
+
+// StaleBranches returns the local branches that are merged into the default
+// branch or whose upstream branch is gone. The current branch and the local
+// default branch are never included. Branches with commits no remote branch
+// contains have Unpushed set.
+func (r *Repository) StaleBranches(ctx context.Context) ([]StaleBranch, error) {
+	defaultRef, err := r.DefaultBranch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defaultName := defaultRef[strings.Index(defaultRef, "/")+1:]
+
+	out, err := r.gitCommand(ctx, "for-each-ref", "--format=%(refname:short)%09%(HEAD)%09%(upstream:track)", "refs/heads").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %s: %w", string(out), err)
+	}
+
+	merged, err := r.gitCommand(ctx, "for-each-ref", "--format=%(refname:short)", "--merged", defaultRef, "refs/heads").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merged branches: %s: %w", string(merged), err)
+	}
+	isMerged := make(map[string]bool)
+	for _, name := range strings.Split(strings.TrimSpace(string(merged)), "\n") {
+		isMerged[name] = true
+	}
+
+	var branches []StaleBranch
+	// Only trim newlines: the fields are tab separated and may be empty
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		name, current, track := fields[0], fields[1] == "*", fields[2]
+		if current || name == defaultName {
+			continue
+		}
+
+		branch := StaleBranch{
+			Name:         name,
+			Merged:       isMerged[name],
+			UpstreamGone: track == "[gone]",
+		}
+		if !branch.Merged && !branch.UpstreamGone {
+			continue
+		}
+		if !branch.Merged {
+			count, err := r.gitCommand(ctx, "rev-list", "--count", "refs/heads/"+name, "--not", "--remotes").CombinedOutput()
+			if err != nil {
+				return nil, fmt.Errorf("failed to count unpushed commits of %s: %s: %w", name, strings.TrimSpace(string(count)), err)
+			}
+			branch.Unpushed, _ = strconv.Atoi(strings.TrimSpace(string(count)))
+		}
+		branches = append(branches, branch)
+	}
+
+	return branches, nil
+}
+
+// DeleteBranches force-deletes the given local branches, including commits
+// no remote has, see StaleBranch.Unpushed. The current branch is refused.
+func (r *Repository) DeleteBranches(ctx context.Context, names ...string) error {
+	if current := r.CurrentBranch(); current != "" {
+		for _, name := range names {
+			if name == current {
+				return fmt.Errorf("refusing to delete the current branch %s", name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	args := append([]string{"branch", "-D", "--"}, names...)
+	if out, err := r.gitCommand(ctx, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete branches: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+// commitFile creates and commits a file with native git
+func commitFile(t *testing.T, dir, name, message string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(message), 0644))
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "-m", message)
+}
+
+func TestRepository_StaleBranches(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Branch that points into master is merged
+	runGit(t, dir, "branch", "feature-merged")
+
+	// Branch whose upstream was deleted on the remote
+	runGit(t, dir, "checkout", "-q", "-b", "feature-gone")
+	commitFile(t, dir, "gone.txt", "Gone work")
+	runGit(t, dir, "push", "-q", "-u", "origin", "feature-gone")
+	runGit(t, dir, "push", "-q", "origin", "--delete", "feature-gone")
+
+	// Unmerged branch without upstream is kept
+	runGit(t, dir, "checkout", "-q", "master")
+	runGit(t, dir, "checkout", "-q", "-b", "feature-active")
+	commitFile(t, dir, "active.txt", "Active work")
+	runGit(t, dir, "checkout", "-q", "master")
+
+	runGit(t, dir, "fetch", "-q", "--prune", "origin")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+	r.Configure(appconfig.RepositorySettings{})
+
+	defaultBranch, err := r.DefaultBranch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "origin/master", defaultBranch)
+
+	branches, err := r.StaleBranches(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []StaleBranch{
+		{Name: "feature-gone", UpstreamGone: true, Unpushed: 1},
+		{Name: "feature-merged", Merged: true},
+	}, branches)
+	assert.Equal(t, "upstream gone, 1 commits on no remote", branches[0].Reason())
+	assert.Equal(t, "merged", branches[1].Reason())
+	assert.Equal(t, "upstream gone, not merged", StaleBranch{Name: "pushed", UpstreamGone: true}.Reason())
+
+	// The current branch is never deleted
+	assert.ErrorContains(t, r.DeleteBranches(context.Background(), "master"), "refusing to delete the current branch")
+
+	require.NoError(t, r.DeleteBranches(context.Background(), "feature-gone", "feature-merged"))
+	assert.Equal(t, "feature-active\nmaster", runGit(t, dir, "for-each-ref", "--format=%(refname:short)", "refs/heads"))
+}
+
+func TestRepository_Update_Prune(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Create a remote branch, fetch it and delete it on the remote
+	runGit(t, dir, "push", "-q", "origin", "master:old-feature")
+	runGit(t, dir, "fetch", "-q", "origin")
+	originDir := runGit(t, dir, "remote", "get-url", "origin")
+	runGit(t, originDir, "branch", "-D", "old-feature")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+
+	// Without prune the remote-tracking ref is kept
+	require.NoError(t, r.Update(appconfig.RepositorySettings{}))
+	assert.Contains(t, runGit(t, dir, "branch", "-r"), "origin/old-feature")
+
+	trueVal := true
+	require.NoError(t, r.Update(appconfig.RepositorySettings{Prune: &trueVal}))
+	assert.NotContains(t, runGit(t, dir, "branch", "-r"), "origin/old-feature")
+}
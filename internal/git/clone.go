@@ -0,0 +1,112 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CloneOptions describe a repository to clone
+type CloneOptions struct {
+	// URL of the origin remote
+	URL string
+	// Path to clone the repository to
+	Path string
+	// Branch to check out, defaults to the remote's default branch
+	Branch string
+	// Upstream is the URL of the upstream remote for forks
+	Upstream string
+}
+
+// credentialArgs returns the git arguments that pass token to git through
+// a credential helper. With a scope URL, e.g. "https://github.com", git only
+// uses the helper for remotes matching it.
+func credentialArgs(scope, username, token string) []string {
+	key := "credential.helper"
+	if scope != "" {
+		key = "credential." + scope + ".helper"
+	}
+	credHelper := fmt.Sprintf("!f() { echo \"username=%s\"; echo \"password=%s\"; }; f", username, token)
+	return []string{"-c", key + "=" + credHelper}
+}
+
+// Clone clones a repository with native git and adds the upstream remote for
+// forks. If a Git repository already exists at the target path it is left as
+// is, apart from adding a missing upstream remote. The returned bool reports
+// whether the repository was cloned.
+func Clone(ctx context.Context, opts CloneOptions) (*Repository, bool, error) {
+	cloned := false
+
+	if _, err := os.Stat(filepath.Join(opts.Path, ".git")); err != nil {
+		if entries, err := os.ReadDir(opts.Path); err == nil && len(entries) > 0 {
+			return nil, false, fmt.Errorf("%s exists and is not a git repository", opts.Path)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+			return nil, false, fmt.Errorf("failed to create parent directory: %w", err)
+		}
+
+		var args []string
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" && IsGitHubURL(opts.URL) {
+			args = append(args, credentialArgs(githubCredentialURL, "git", token)...)
+		}
+		args = append(args, "clone")
+		if opts.Branch != "" {
+			args = append(args, "--branch", opts.Branch)
+		}
+		args = append(args, opts.URL, opts.Path)
+
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Env = os.Environ()
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, false, fmt.Errorf("failed to clone %s: %s: %w", opts.URL, strings.TrimSpace(string(out)), err)
+		}
+		cloned = true
+	}
+
+	repo, err := OpenRepository(opts.Path)
+	if err != nil {
+		return nil, cloned, err
+	}
+
+	if opts.Upstream != "" && !repo.HasUpstream {
+		if out, err := repo.gitCommand(ctx, "remote", "add", "upstream", opts.Upstream).CombinedOutput(); err != nil {
+			return nil, cloned, fmt.Errorf("failed to add upstream remote: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+		if err := repo.runGitCommand(ctx, "fetch", "upstream"); err != nil {
+			return nil, cloned, fmt.Errorf("failed to fetch from upstream: %w", err)
+		}
+		repo.HasUpstream = true
+	}
+
+	return repo, cloned, nil
+}
+
+// RemoteURL returns the first URL of the named remote, or an empty string if
+// the remote does not exist
+func (r *Repository) RemoteURL(name string) string {
+	if r.repo == nil {
+		return ""
+	}
+	remote, err := r.repo.Remote(name)
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+	return remote.Config().URLs[0]
+}
+
+// CurrentBranch returns the name of the checked out branch, or an empty
+// string for a detached HEAD
+func (r *Repository) CurrentBranch() string {
+	if r.repo == nil {
+		return ""
+	}
+	head, err := r.repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return ""
+	}
+	return head.Name().Short()
+}
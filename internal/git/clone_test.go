@@ -0,0 +1,54 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	_, originDir, upstreamDir, cleanup := setupTestRepoWithRemotes(t)
+	defer cleanup()
+
+	workspace, err := os.MkdirTemp("", "gogitup-test-clone-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(workspace); err != nil {
+			t.Errorf("Failed to remove workspace: %v", err)
+		}
+	}()
+
+	target := filepath.Join(workspace, "nested", "fork")
+	opts := CloneOptions{URL: originDir, Path: target, Branch: "master", Upstream: upstreamDir}
+
+	// First run clones the repository and adds the upstream remote
+	repo, cloned, err := Clone(context.Background(), opts)
+	require.NoError(t, err)
+	assert.True(t, cloned)
+	assert.True(t, repo.HasUpstream)
+	assert.Equal(t, originDir, repo.RemoteURL("origin"))
+	assert.Equal(t, upstreamDir, repo.RemoteURL("upstream"))
+	assert.Equal(t, "master", repo.CurrentBranch())
+	assert.Equal(t, runGit(t, upstreamDir, "rev-parse", "HEAD"), runGit(t, target, "rev-parse", "upstream/master"))
+
+	// Second run leaves the existing repository alone
+	repo, cloned, err = Clone(context.Background(), opts)
+	require.NoError(t, err)
+	assert.False(t, cloned)
+	assert.True(t, repo.HasUpstream)
+
+	// A non-empty directory that is not a repository is an error
+	notRepo := filepath.Join(workspace, "not-a-repo")
+	require.NoError(t, os.MkdirAll(notRepo, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(notRepo, "file"), []byte("x"), 0644))
+	_, _, err = Clone(context.Background(), CloneOptions{URL: originDir, Path: notRepo})
+	assert.ErrorContains(t, err, "is not a git repository")
+
+	// Invalid URL
+	_, _, err = Clone(context.Background(), CloneOptions{URL: filepath.Join(workspace, "missing.git"), Path: filepath.Join(workspace, "missing")})
+	assert.ErrorContains(t, err, "failed to clone")
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit of a repository, e.g. one brought in by an update
+type Commit struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// commitFormat separates the fields with unit separators so that subjects
+// can contain any printable character
+const commitFormat = "%H%x1f%an%x1f%at%x1f%s"
+
+// Heads returns the HEAD before and after the last update. Both are empty if
+// the update did not get that far.
+func (r *Repository) Heads() (string, string) {
+	return r.oldHead, r.newHead
+}
+
+// IncomingCommits returns the commits the last update brought in, newest
+// first. A limit of 0 returns all of them.
+func (r *Repository) IncomingCommits(limit int) ([]Commit, error) {
+	if r.oldHead == "" || r.newHead == "" || r.oldHead == r.newHead {
+		return nil, nil
+	}
+
+	args := []string{"log", "--format=" + commitFormat}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	args = append(args, r.oldHead+".."+r.newHead, "--")
+
+	out, err := r.gitCommand(context.Background(), args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %s: %w", string(out), err)
+	}
+
+	return parseCommits(string(out)), nil
+}
+
+// parseCommits parses git log output formatted with commitFormat
+func parseCommits(out string) []Commit {
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		fields := strings.SplitN(line, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Date:    time.Unix(seconds, 0),
+			Subject: fields[3],
+		})
+	}
+	return commits
+}
+
+// CommitsBehind returns the number of commits of the remote branch the
+// repository is updated from that are missing from HEAD, as of the last fetch
+func (r *Repository) CommitsBehind() (int, error) {
+	if r.repo == nil {
+		return 0, fmt.Errorf("repository is not open")
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	ref := r.FetchRemote() + "/" + r.trackedBranch(head)
+	_, behind, err := r.aheadBehind(context.Background(), ref)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits behind %s: %w", ref, err)
+	}
+	return behind, nil
+}
+
+// aheadBehind counts the commits of HEAD and of ref that the other does not
+// have
+func (r *Repository) aheadBehind(ctx context.Context, ref string) (int, int, error) {
+	out, err := r.gitCommand(ctx, "rev-list", "--left-right", "--count", "HEAD..."+ref, "--").CombinedOutput()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare HEAD with %s: %s: %w", ref, strings.TrimSpace(string(out)), err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("failed to compare HEAD with %s: unexpected output %q", ref, strings.TrimSpace(string(out)))
+	}
+	ahead, _ := strconv.Atoi(fields[0])
+	behind, _ := strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c Commit) ShortHash() string {
+	return shortHash(c.Hash)
+}
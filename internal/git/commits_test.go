@@ -0,0 +1,94 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+// pushToOrigin publishes commits changing file with the given subjects to
+// the origin of dir from a separate clone
+func pushToOrigin(t *testing.T, dir, file string, subjects ...string) {
+	t.Helper()
+	originDir := runGit(t, dir, "remote", "get-url", "origin")
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	runGit(t, dir, "clone", "-q", originDir, cloneDir)
+	for _, subject := range subjects {
+		require.NoError(t, os.WriteFile(filepath.Join(cloneDir, file), []byte(subject), 0644))
+		runGit(t, cloneDir, "add", file)
+		runGit(t, cloneDir, "-c", "user.name=Remote User", "-c", "user.email=remote@example.com", "commit", "-q", "-m", subject)
+	}
+	runGit(t, cloneDir, "push", "-q", "origin", "HEAD:master")
+}
+
+func TestRepository_IncomingCommits(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+
+	// Nothing came in before the first update
+	commits, err := r.IncomingCommits(0)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	pushToOrigin(t, dir, "incoming.txt", "First change", "Second change", "Third change")
+	require.NoError(t, r.Update(appconfig.RepositorySettings{}))
+
+	oldHead, newHead := r.Heads()
+	assert.NotEqual(t, oldHead, newHead)
+
+	commits, err = r.IncomingCommits(0)
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	assert.Equal(t, "Third change", commits[0].Subject)
+	assert.Equal(t, "First change", commits[2].Subject)
+	assert.Equal(t, "Remote User", commits[0].Author)
+	assert.Equal(t, newHead, commits[0].Hash)
+	assert.Len(t, commits[0].ShortHash(), 7)
+	assert.False(t, commits[0].Date.IsZero())
+
+	commits, err = r.IncomingCommits(2)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "Second change", commits[1].Subject)
+
+	// An update without changes brings nothing in
+	require.NoError(t, r.Update(appconfig.RepositorySettings{}))
+	commits, err = r.IncomingCommits(0)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestRepository_CommitsBehind(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+
+	pushToOrigin(t, dir, "incoming.txt", "First change", "Second change")
+	runGit(t, dir, "fetch", "-q", "origin")
+
+	behind, err := r.CommitsBehind()
+	require.NoError(t, err)
+	assert.Equal(t, 2, behind)
+
+	require.NoError(t, r.Update(appconfig.RepositorySettings{}))
+	behind, err = r.CommitsBehind()
+	require.NoError(t, err)
+	assert.Zero(t, behind)
+
+	// Branches that do not exist on the remote cannot be compared
+	runGit(t, dir, "checkout", "-q", "-b", "local-only")
+	_, err = r.CommitsBehind()
+	assert.ErrorContains(t, err, "failed to count commits behind origin/local-only")
+}
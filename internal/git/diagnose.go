@@ -0,0 +1,211 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Problem is something keeping a repository from being updated, found by
+// Diagnose
+type Problem struct {
+	Message string
+	// Fix tells how to solve the problem
+	Fix string
+}
+
+// FetchRemote returns the remote the repository is updated from: upstream
+// for forks, origin otherwise
+func (r *Repository) FetchRemote() string {
+	if r.hasUpstream() {
+		return r.settings.UpstreamRemote()
+	}
+	return r.settings.OriginRemote()
+}
+
+// Token returns the token used to authenticate against the remote URL, or
+// an empty string when no credentials apply
+func (r *Repository) Token(remoteURL string) string {
+	_, token := r.token(remoteURL)
+	return token
+}
+
+// Errors CheckRemote wraps when the remote answers conclusively
+var (
+	// ErrRemoteNotFound means the host has no repository at the remote URL
+	ErrRemoteNotFound = errors.New("remote repository not found")
+	// ErrHostNotFound means the host of the remote URL does not resolve
+	ErrHostNotFound = errors.New("remote host not found")
+)
+
+// UpdateRemotes returns the remotes an update fetches: origin, and upstream
+// for forks
+func (r *Repository) UpdateRemotes() []string {
+	if r.hasUpstream() {
+		return []string{r.settings.OriginRemote(), r.settings.UpstreamRemote()}
+	}
+	return []string{r.settings.OriginRemote()}
+}
+
+// CheckAccess connects to the remote the repository is updated from with
+// the credentials an update uses. It fails instead of asking for a password
+// or passphrase.
+func (r *Repository) CheckAccess(ctx context.Context) error {
+	return r.CheckRemote(ctx, r.FetchRemote())
+}
+
+// CheckRemote connects to the named remote like CheckAccess. Errors wrap
+// ErrRemoteNotFound or ErrHostNotFound when the remote repository or its
+// host are gone.
+func (r *Repository) CheckRemote(ctx context.Context, remote string) error {
+	args := append(r.authArgs(), "ls-remote", remote, "HEAD")
+
+	cmd := r.gitCommand(ctx, args...)
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv("GIT_SSH_COMMAND") == "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	output := strings.TrimSpace(string(out))
+	if cause := remoteFailure(output); cause != nil {
+		return fmt.Errorf("failed to connect to %s: %w: %s", remote, cause, output)
+	}
+	return fmt.Errorf("failed to connect to %s: %s: %w", remote, output, err)
+}
+
+// remoteFailure returns the conclusive failure reported by git ls-remote,
+// nil for failures that may go away, such as rejected credentials or an
+// unreachable network
+func remoteFailure(output string) error {
+	lower := strings.ToLower(output)
+	for _, message := range []string{"could not resolve host", "could not resolve hostname", "name or service not known", "nodename nor servname provided"} {
+		if strings.Contains(lower, message) {
+			return ErrHostNotFound
+		}
+	}
+	// GitHub answers "Repository not found" for repositories that are
+	// private to others as well, which the credentials cannot tell apart
+	for _, message := range []string{"repository not found", "does not appear to be a git repository", "could not be found", "does not exist"} {
+		if strings.Contains(lower, message) {
+			return ErrRemoteNotFound
+		}
+	}
+	return nil
+}
+
+// negativeRefSpecs returns the negative refspecs (e.g. "^refs/heads/wip/*")
+// of the repository's remotes by remote name. go-git cannot parse them and
+// fails to open the repository, so they are read with native git.
+func (r *Repository) negativeRefSpecs(ctx context.Context) map[string][]string {
+	out, err := r.gitCommand(ctx, "config", "--get-regexp", `^remote\..*\.fetch$`).Output()
+	if err != nil {
+		return nil
+	}
+
+	refSpecs := make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok || !strings.HasPrefix(value, "^") {
+			continue
+		}
+		remote := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".fetch")
+		refSpecs[remote] = append(refSpecs[remote], value)
+	}
+	return refSpecs
+}
+
+// Diagnose looks for the problems that make updates of the repository fail
+// or do nothing: remotes go-git cannot read, a detached HEAD, a branch
+// without its remote counterpart and LFS content without git-lfs. The
+// repository is opened if it was not, so entries Store.Inspect could not
+// open can be diagnosed as well.
+func (r *Repository) Diagnose(ctx context.Context) []Problem {
+	if _, err := os.Stat(r.Path); err != nil {
+		return []Problem{{
+			Message: "repository no longer exists",
+			Fix:     "run 'gogitup scan' to refresh the repository list",
+		}}
+	}
+
+	var problems []Problem
+	refSpecs := r.negativeRefSpecs(ctx)
+	for _, remote := range slices.Sorted(maps.Keys(refSpecs)) {
+		for _, spec := range refSpecs[remote] {
+			problems = append(problems, Problem{
+				Message: fmt.Sprintf("remote %s has the negative refspec %s, which go-git cannot parse", remote, spec),
+				Fix:     fmt.Sprintf("remove it with 'git -C %s config --unset --fixed-value remote.%s.fetch %q'", r.Path, remote, spec),
+			})
+		}
+	}
+
+	if r.repo == nil {
+		repo, err := git.PlainOpen(r.Path)
+		if err != nil {
+			if len(refSpecs) == 0 {
+				problems = append(problems, Problem{
+					Message: fmt.Sprintf("repository cannot be opened: %v", err),
+					Fix:     fmt.Sprintf("check it with 'git -C %s fsck'", r.Path),
+				})
+			}
+			return problems
+		}
+		r.repo = repo
+	}
+
+	if r.isLFSRepository() {
+		if _, err := exec.LookPath("git-lfs"); err != nil {
+			problems = append(problems, Problem{
+				Message: "repository uses Git LFS but git-lfs is not installed",
+				Fix:     "install git-lfs from https://git-lfs.com and run 'git lfs install'",
+			})
+		}
+	}
+
+	remote := r.FetchRemote()
+	if _, err := r.repo.Remote(remote); err != nil {
+		return append(problems, Problem{
+			Message: fmt.Sprintf("repository has no remote %s", remote),
+			Fix:     fmt.Sprintf("add it with 'git -C %s remote add %s <url>', or set origin in the repository settings", r.Path, remote),
+		})
+	}
+
+	head, err := r.repo.Head()
+	if err != nil {
+		return append(problems, Problem{
+			Message: fmt.Sprintf("HEAD cannot be resolved: %v", err),
+			Fix:     fmt.Sprintf("commit or check out a branch with 'git -C %s switch <branch>'", r.Path),
+		})
+	}
+	if !head.Name().IsBranch() {
+		// Pinned repositories are only fetched, their HEAD is left alone
+		if !r.settings.IsPinned() {
+			problems = append(problems, Problem{
+				Message: fmt.Sprintf("HEAD is detached at %s", shortHash(head.Hash().String())),
+				Fix:     fmt.Sprintf("check out a branch with 'git -C %s switch <branch>', or set pin in the repository settings", r.Path),
+			})
+		}
+		return problems
+	}
+
+	branch := r.trackedBranch(head)
+	if _, err := r.repo.Reference(plumbing.NewRemoteReferenceName(remote, branch), true); err != nil {
+		problems = append(problems, Problem{
+			Message: fmt.Sprintf("branch %s has no remote-tracking branch %s/%s", head.Name().Short(), remote, branch),
+			Fix:     fmt.Sprintf("fetch it with 'git -C %s fetch %s' if %s has it, or set branch in the repository settings to the branch to update from", r.Path, remote, remote),
+		})
+	}
+
+	return problems
+}
@@ -0,0 +1,160 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+func TestRepository_Diagnose(t *testing.T) {
+	trueVal := true
+
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dir string)
+		settings appconfig.RepositorySettings
+		want     []string
+	}{
+		{
+			name: "healthy",
+		},
+		{
+			name: "detached HEAD",
+			setup: func(t *testing.T, dir string) {
+				runGit(t, dir, "checkout", "-q", "--detach")
+			},
+			want: []string{"HEAD is detached at "},
+		},
+		{
+			name: "detached HEAD of pinned repository",
+			setup: func(t *testing.T, dir string) {
+				runGit(t, dir, "checkout", "-q", "--detach")
+			},
+			settings: appconfig.RepositorySettings{Pin: &trueVal},
+		},
+		{
+			name: "branch missing on the remote",
+			setup: func(t *testing.T, dir string) {
+				runGit(t, dir, "checkout", "-q", "-b", "feature")
+			},
+			want: []string{"branch feature has no remote-tracking branch origin/feature"},
+		},
+		{
+			name: "configured branch",
+			setup: func(t *testing.T, dir string) {
+				runGit(t, dir, "checkout", "-q", "-b", "feature")
+			},
+			settings: appconfig.RepositorySettings{Branch: "master"},
+		},
+		{
+			name:     "missing remote",
+			settings: appconfig.RepositorySettings{Origin: "fork"},
+			want:     []string{"repository has no remote fork"},
+		},
+		{
+			name: "negative refspec",
+			setup: func(t *testing.T, dir string) {
+				runGit(t, dir, "config", "--add", "remote.origin.fetch", "^refs/heads/wip/*")
+			},
+			want: []string{"remote origin has the negative refspec ^refs/heads/wip/*, which go-git cannot parse"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+			runGit(t, dir, "fetch", "-q", "origin")
+			if tt.setup != nil {
+				tt.setup(t, dir)
+			}
+
+			r := &Repository{Path: dir}
+			r.Configure(tt.settings)
+			problems := r.Diagnose(context.Background())
+
+			require.Len(t, problems, len(tt.want), "%v", problems)
+			for i, want := range tt.want {
+				assert.Contains(t, problems[i].Message, want)
+				assert.Contains(t, problems[i].Fix, dir)
+			}
+		})
+	}
+
+	t.Run("missing repository", func(t *testing.T) {
+		r := &Repository{Path: filepath.Join(t.TempDir(), "gone")}
+		problems := r.Diagnose(context.Background())
+		require.Len(t, problems, 1)
+		assert.Equal(t, "repository no longer exists", problems[0].Message)
+	})
+}
+
+func TestRepository_CheckAccess(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	r := &Repository{Path: dir}
+	r.Configure(appconfig.RepositorySettings{})
+	assert.Equal(t, "origin", r.FetchRemote())
+	assert.NoError(t, r.CheckAccess(context.Background()))
+
+	runGit(t, dir, "remote", "set-url", "origin", filepath.Join(t.TempDir(), "missing"))
+	err := r.CheckAccess(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to origin")
+	assert.ErrorIs(t, err, ErrRemoteNotFound)
+}
+
+func TestRemoteFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   error
+	}{
+		{"fatal: unable to access 'https://gone.invalid/x/': Could not resolve host: gone.invalid", ErrHostNotFound},
+		{"ssh: Could not resolve hostname gone.invalid: Name or service not known", ErrHostNotFound},
+		{"remote: Repository not found.\nfatal: repository 'https://github.com/a/b/' not found", ErrRemoteNotFound},
+		{"fatal: '/srv/git/x.git' does not appear to be a git repository", ErrRemoteNotFound},
+		{"remote: The project you were looking for could not be found or you don't have permission to view it.", ErrRemoteNotFound},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", nil},
+		{"git@github.com: Permission denied (publickey).", nil},
+		{"fatal: unable to access 'https://github.com/a/b/': Failed to connect to github.com port 443", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			assert.Equal(t, tt.want, remoteFailure(tt.output))
+		})
+	}
+}
+
+func TestStore_Inspect(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	runGit(t, dir, "config", "--add", "remote.origin.fetch", "^refs/heads/wip/*")
+
+	valid, cleanupValid := setupTestRepo(t)
+	defer cleanupValid()
+
+	reposFile := filepath.Join(t.TempDir(), "repos.json")
+	data, err := json.Marshal([]Repository{{Path: valid}, {Path: dir}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(reposFile, data, 0644))
+
+	repos, broken, err := NewStore(reposFile).Inspect()
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, valid, repos[0].Path)
+	require.Len(t, broken, 1)
+	assert.Equal(t, dir, broken[0].Path)
+
+	// Load leaves the broken repository out
+	repos, err = NewStore(reposFile).Load()
+	require.NoError(t, err)
+	assert.Len(t, repos, 1)
+}
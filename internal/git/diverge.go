@@ -0,0 +1,222 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDivergedCommits bounds the commits of each side a Divergence lists
+const maxDivergedCommits = 20
+
+// Ways to resolve a diverged branch, see Resolve
+const (
+	ResolveReset  = "reset"
+	ResolveRebase = "rebase"
+	ResolveMerge  = "merge"
+)
+
+// DivergedCommit is a commit of a diverged branch that the remote branch
+// does not have
+type DivergedCommit struct {
+	Commit
+	// Merged is set when the remote branch has a commit with the same changes
+	// (patch ID), e.g. because it was cherry-picked or rebased there
+	Merged bool
+}
+
+// Divergence describes a branch and the remote branch it is updated from when
+// both have commits the other does not have
+type Divergence struct {
+	Branch string
+	// RemoteBranch is the remote-tracking branch, e.g. "upstream/main"
+	RemoteBranch string
+	// MergeBase is the newest commit both have, empty for unrelated histories
+	MergeBase string
+
+	// Local are the newest commits only the branch has. LocalCount counts
+	// all of them, Merged the ones whose changes the remote branch has too.
+	Local      []DivergedCommit
+	LocalCount int
+	Merged     int
+
+	// Remote are the newest commits only the remote branch has, RemoteCount
+	// counts all of them
+	Remote      []Commit
+	RemoteCount int
+}
+
+// AlreadyMerged reports whether the remote branch has the changes of every
+// local commit, so resetting the branch to it loses nothing
+func (d Divergence) AlreadyMerged() bool {
+	return d.LocalCount > 0 && d.Merged == d.LocalCount
+}
+
+// Summary describes the divergence in a few words, e.g. "2 local and 5
+// upstream/main commits since 1a2b3c4"
+func (d Divergence) Summary() string {
+	summary := fmt.Sprintf("%d local and %d %s commits", d.LocalCount, d.RemoteCount, d.RemoteBranch)
+	if d.MergeBase != "" {
+		summary += " since " + shortHash(d.MergeBase)
+	}
+	switch {
+	case d.AlreadyMerged():
+		summary += ", all local commits are already merged"
+	case d.Merged > 0:
+		summary += fmt.Sprintf(", %d of the local commits are already merged", d.Merged)
+	}
+	return summary
+}
+
+// DivergedError is returned by updates that cannot fast-forward the branch
+// because it diverged from the remote branch
+type DivergedError struct {
+	Divergence Divergence
+	// Remote is the name of the remote the branch is updated from
+	Remote string
+}
+
+func (e *DivergedError) Error() string {
+	return fmt.Sprintf("cannot fast-forward to %s: local branch has diverged from %s: %s, see 'gogitup resolve'",
+		e.Divergence.RemoteBranch, e.Remote, e.Divergence.Summary())
+}
+
+// divergedError returns the DivergedError of the current branch and ref, or
+// a plain error when the divergence cannot be described
+func (r *Repository) divergedError(ctx context.Context, remote, ref string) error {
+	d, err := r.divergence(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("cannot fast-forward to %s: local branch has diverged from %s: %w", ref, remote, err)
+	}
+	return &DivergedError{Divergence: d, Remote: remote}
+}
+
+// Fetch fetches the remote the repository is updated from
+func (r *Repository) Fetch(ctx context.Context) error {
+	if r.repo == nil {
+		return fmt.Errorf("repository is not open")
+	}
+	return r.fetch(ctx, r.FetchRemote())
+}
+
+// Diverged compares the current branch with the remote branch it is updated
+// from, as of the last fetch. It returns nil when the branch can be
+// fast-forwarded or has nothing to fetch.
+func (r *Repository) Diverged(ctx context.Context) (*Divergence, error) {
+	if r.repo == nil {
+		return nil, fmt.Errorf("repository is not open")
+	}
+	head, err := r.repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return nil, fmt.Errorf("HEAD is detached at %s", shortHash(head.Hash().String()))
+	}
+
+	ref := r.FetchRemote() + "/" + r.trackedBranch(head)
+	if _, err := r.revParse(ctx, ref); err != nil {
+		return nil, err
+	}
+	d, err := r.divergence(ctx, ref)
+	if err != nil || d.LocalCount == 0 || d.RemoteCount == 0 {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// divergence describes how the current branch and ref diverged
+func (r *Repository) divergence(ctx context.Context, ref string) (Divergence, error) {
+	d := Divergence{Branch: r.CurrentBranch(), RemoteBranch: ref}
+
+	// Unrelated histories have no merge base, git exits with 1 then
+	if out, err := r.gitCommand(ctx, "merge-base", "HEAD", ref).Output(); err == nil {
+		d.MergeBase = strings.TrimSpace(string(out))
+	}
+
+	var err error
+	if d.LocalCount, d.RemoteCount, err = r.aheadBehind(ctx, ref); err != nil {
+		return d, err
+	}
+
+	// git cherry marks the local commits whose patch ID matches a commit of
+	// ref with "-", the others with "+"
+	out, err := r.gitCommand(ctx, "cherry", ref, "HEAD").CombinedOutput()
+	if err != nil {
+		return d, fmt.Errorf("failed to compare patches: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	merged := make(map[string]bool)
+	for _, line := range lines(string(out)) {
+		if hash, ok := strings.CutPrefix(line, "- "); ok {
+			merged[hash] = true
+		}
+	}
+	d.Merged = len(merged)
+
+	local, err := r.commitsBetween(ctx, ref, "HEAD")
+	if err != nil {
+		return d, err
+	}
+	for _, c := range local {
+		d.Local = append(d.Local, DivergedCommit{Commit: c, Merged: merged[c.Hash]})
+	}
+	d.Remote, err = r.commitsBetween(ctx, "HEAD", ref)
+	return d, err
+}
+
+// commitsBetween returns the newest commits of to that from does not have
+func (r *Repository) commitsBetween(ctx context.Context, from, to string) ([]Commit, error) {
+	out, err := r.gitCommand(ctx, "log", "--format="+commitFormat, "-n", strconv.Itoa(maxDivergedCommits), from+".."+to, "--").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return parseCommits(string(out)), nil
+}
+
+// Resolve brings a diverged branch up to date with its remote branch:
+//
+//   - ResolveReset moves the branch to the remote branch, after keeping the
+//     local commits in a backup branch whose name is returned
+//   - ResolveRebase replays the local commits on top of the remote branch
+//   - ResolveMerge merges the remote branch into the branch
+//
+// Failed rebases and merges are aborted, leaving the branch as it was.
+func (r *Repository) Resolve(ctx context.Context, d Divergence, how string) (string, error) {
+	if r.CurrentBranch() != d.Branch {
+		return "", fmt.Errorf("branch %s is no longer checked out", d.Branch)
+	}
+	out, err := r.gitCommand(ctx, "status", "--porcelain", "--untracked-files=no", "--ignore-submodules=all").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to check for changes: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	if len(strings.TrimSpace(string(out))) > 0 {
+		return "", ErrUncommittedChanges
+	}
+
+	switch how {
+	case ResolveReset:
+		backup := fmt.Sprintf("%s-backup-%s", d.Branch, time.Now().Format("20060102-150405"))
+		if out, err := r.gitCommand(ctx, "branch", backup, "HEAD").CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to create backup branch %s: %s: %w", backup, strings.TrimSpace(string(out)), err)
+		}
+		if out, err := r.gitCommand(ctx, "reset", "--hard", d.RemoteBranch).CombinedOutput(); err != nil {
+			return backup, fmt.Errorf("failed to reset to %s: %s: %w", d.RemoteBranch, strings.TrimSpace(string(out)), err)
+		}
+		return backup, nil
+	case ResolveRebase:
+		if out, err := r.gitCommand(ctx, "rebase", d.RemoteBranch).CombinedOutput(); err != nil {
+			_ = r.gitCommand(context.Background(), "rebase", "--abort").Run()
+			return "", fmt.Errorf("failed to rebase onto %s: %s: %w", d.RemoteBranch, strings.TrimSpace(string(out)), err)
+		}
+	case ResolveMerge:
+		if out, err := r.gitCommand(ctx, "merge", "--no-edit", d.RemoteBranch).CombinedOutput(); err != nil {
+			_ = r.gitCommand(context.Background(), "merge", "--abort").Run()
+			return "", fmt.Errorf("failed to merge %s: %s: %w", d.RemoteBranch, strings.TrimSpace(string(out)), err)
+		}
+	default:
+		return "", fmt.Errorf("unknown resolution %q, must be one of %s, %s or %s", how, ResolveReset, ResolveRebase, ResolveMerge)
+	}
+	return "", nil
+}
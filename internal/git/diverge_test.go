@@ -0,0 +1,157 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+// divergedFork returns a fork whose branch has a commit adding file while
+// upstream has one adding upstream.txt
+func divergedFork(t *testing.T, file, content string) *Repository {
+	t.Helper()
+	dir, _, _, cleanup := setupTestRepoWithRemotes(t)
+	t.Cleanup(cleanup)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	runGit(t, dir, "add", file)
+	runGit(t, dir, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "commit", "-q", "-m", "Local commit")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	return &Repository{Path: dir, repo: repo, HasUpstream: true}
+}
+
+func TestRepository_Update_Diverged(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		merged  int
+		summary string
+	}{
+		{name: "local changes", content: "local content", summary: "1 local and 1 upstream/master commits since"},
+		{name: "already merged", content: "upstream content", merged: 1, summary: "all local commits are already merged"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := divergedFork(t, "upstream.txt", tt.content)
+			err := r.Update(appconfig.RepositorySettings{})
+
+			var diverged *DivergedError
+			require.True(t, errors.As(err, &diverged), "unexpected error %v", err)
+			assert.ErrorContains(t, err, "local branch has diverged from upstream")
+			assert.ErrorContains(t, err, tt.summary)
+
+			d := diverged.Divergence
+			assert.Equal(t, "upstream", diverged.Remote)
+			assert.Equal(t, "master", d.Branch)
+			assert.Equal(t, "upstream/master", d.RemoteBranch)
+			assert.Equal(t, runGit(t, r.Path, "merge-base", "HEAD", "upstream/master"), d.MergeBase)
+			assert.Equal(t, 1, d.LocalCount)
+			assert.Equal(t, 1, d.RemoteCount)
+			assert.Equal(t, tt.merged, d.Merged)
+			assert.Equal(t, tt.merged == 1, d.AlreadyMerged())
+			require.Len(t, d.Local, 1)
+			assert.Equal(t, "Local commit", d.Local[0].Subject)
+			assert.Equal(t, tt.merged == 1, d.Local[0].Merged)
+			require.Len(t, d.Remote, 1)
+			assert.Equal(t, "Upstream commit", d.Remote[0].Subject)
+		})
+	}
+}
+
+func TestRepository_Update_DivergedOrigin(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	pushToOrigin(t, dir, "remote.txt", "Remote change")
+	commitFile(t, dir, "local.txt", "Local change")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+
+	err = r.Update(appconfig.RepositorySettings{})
+	var diverged *DivergedError
+	require.True(t, errors.As(err, &diverged), "unexpected error %v", err)
+	assert.Equal(t, "origin/master", diverged.Divergence.RemoteBranch)
+	assert.Equal(t, 1, diverged.Divergence.LocalCount)
+	assert.Equal(t, 1, diverged.Divergence.RemoteCount)
+}
+
+func TestRepository_Resolve(t *testing.T) {
+	tests := []struct {
+		how   string
+		check func(t *testing.T, dir, backup string)
+	}{
+		{
+			how: ResolveReset,
+			check: func(t *testing.T, dir, backup string) {
+				assert.Regexp(t, `^master-backup-\d{8}-\d{6}$`, backup)
+				assert.Equal(t, runGit(t, dir, "rev-parse", "upstream/master"), runGit(t, dir, "rev-parse", "HEAD"))
+				assert.Equal(t, "Local commit", runGit(t, dir, "log", "-1", "--format=%s", backup))
+			},
+		},
+		{
+			how: ResolveRebase,
+			check: func(t *testing.T, dir, backup string) {
+				assert.Empty(t, backup)
+				assert.Equal(t, "Local commit", runGit(t, dir, "log", "-1", "--format=%s"))
+				assert.Equal(t, runGit(t, dir, "rev-parse", "upstream/master"), runGit(t, dir, "rev-parse", "HEAD^"))
+			},
+		},
+		{
+			how: ResolveMerge,
+			check: func(t *testing.T, dir, backup string) {
+				assert.Empty(t, backup)
+				assert.Equal(t, runGit(t, dir, "rev-parse", "upstream/master"), runGit(t, dir, "rev-parse", "HEAD^2"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.how, func(t *testing.T) {
+			r := divergedFork(t, "local.txt", "local content")
+			runGit(t, r.Path, "config", "user.name", "Test User")
+			runGit(t, r.Path, "config", "user.email", "test@example.com")
+			r.Configure(appconfig.RepositorySettings{})
+			runGit(t, r.Path, "fetch", "-q", "upstream")
+
+			d, err := r.Diverged(context.Background())
+			require.NoError(t, err)
+			require.NotNil(t, d)
+
+			backup, err := r.Resolve(context.Background(), *d, tt.how)
+			require.NoError(t, err)
+			tt.check(t, r.Path, backup)
+
+			d, err = r.Diverged(context.Background())
+			require.NoError(t, err)
+			assert.Nil(t, d)
+		})
+	}
+}
+
+func TestRepository_Resolve_Errors(t *testing.T) {
+	r := divergedFork(t, "local.txt", "local content")
+	r.Configure(appconfig.RepositorySettings{})
+	runGit(t, r.Path, "fetch", "-q", "upstream")
+	d, err := r.Diverged(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, d)
+
+	_, err = r.Resolve(context.Background(), *d, "squash")
+	assert.ErrorContains(t, err, `unknown resolution "squash"`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(r.Path, "test.txt"), []byte("changed"), 0644))
+	_, err = r.Resolve(context.Background(), *d, ResolveReset)
+	assert.ErrorIs(t, err, ErrUncommittedChanges)
+	assert.Equal(t, "Local commit", runGit(t, r.Path, "log", "-1", "--format=%s"))
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// ExecResult holds the outcome of a command run inside a repository
+type ExecResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// shellCommand returns the shell invocation used to run command
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "/bin/sh", "-c", command)
+}
+
+// Exec runs a shell command in the repository directory, capturing stdout and
+// stderr separately. A non-zero exit status is reported through ExitCode; an
+// error is only returned when the command could not be run at all.
+func (r *Repository) Exec(command string) (*ExecResult, error) {
+	return r.run(shellCommand(context.Background(), command))
+}
+
+// ExecArgs runs name with args in the repository directory without a shell,
+// so every argument reaches the command unchanged. The outcome is reported
+// like Exec's.
+func (r *Repository) ExecArgs(name string, args ...string) (*ExecResult, error) {
+	return r.run(exec.CommandContext(context.Background(), name, args...))
+}
+
+// run runs cmd in the repository directory, capturing its output
+func (r *Repository) run(cmd *exec.Cmd) (*ExecResult, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd.Dir = r.Path
+	cmd.Env = os.Environ()
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	result := &ExecResult{
+		Stdout: stdout.Bytes(),
+		Stderr: stderr.Bytes(),
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+			return result, nil
+		}
+		return result, fmt.Errorf("failed to run command: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell commands in this test require a POSIX shell")
+	}
+
+	tests := []struct {
+		name         string
+		command      string
+		wantStdout   string
+		wantStderr   string
+		wantExitCode int
+	}{
+		{
+			name:       "stdout only",
+			command:    "echo hello",
+			wantStdout: "hello\n",
+		},
+		{
+			name:       "stderr only",
+			command:    "echo oops >&2",
+			wantStderr: "oops\n",
+		},
+		{
+			name:         "non-zero exit code",
+			command:      "echo partial; exit 3",
+			wantStdout:   "partial\n",
+			wantExitCode: 3,
+		},
+		{
+			name:       "runs in repository directory",
+			command:    "ls test.txt",
+			wantStdout: "test.txt\n",
+		},
+	}
+
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	repo := &Repository{Path: dir}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.Exec(tt.command)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStdout, string(result.Stdout))
+			assert.Equal(t, tt.wantStderr, string(result.Stderr))
+			assert.Equal(t, tt.wantExitCode, result.ExitCode)
+		})
+	}
+
+	t.Run("arguments without shell", func(t *testing.T) {
+		result, err := repo.ExecArgs("printf", "[%s]\n", "two words", "$HOME")
+		require.NoError(t, err)
+		assert.Equal(t, "[two words]\n[$HOME]\n", string(result.Stdout))
+
+		result, err = repo.ExecArgs("false")
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.ExitCode)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		missing := &Repository{Path: dir + "-missing"}
+		_, err := os.Stat(missing.Path)
+		require.True(t, os.IsNotExist(err))
+
+		_, err = missing.Exec("true")
+		assert.ErrorContains(t, err, "failed to run command")
+	})
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info describes the current state of a repository, for listing it
+type Info struct {
+	Path string
+	// Branch is the checked out branch, empty for a detached HEAD
+	Branch string
+	// Remotes maps the remote names to their first URL
+	Remotes map[string]string
+	Fork    bool
+	LFS     bool
+	Tags    []string
+
+	// Outcome of the last update, from the repository list
+	LastUpdated time.Time
+	LastOutcome string
+	LastError   string
+
+	// LastCommit is the commit date of HEAD
+	LastCommit time.Time
+	// LastActivity is the last checkout or commit, see
+	// Repository.LastActivity
+	LastActivity time.Time
+	// Size is the disk size of the object database in bytes
+	Size int64
+	// Dirty is set when tracked files have uncommitted changes
+	Dirty bool
+	// Ahead and Behind count the commits of the branch and of the remote
+	// branch it is updated from that the other does not have
+	Ahead  int
+	Behind int
+}
+
+// Diverged reports whether the branch and its remote branch both have
+// commits the other does not have
+func (i Info) Diverged() bool {
+	return i.Ahead > 0 && i.Behind > 0
+}
+
+// Failed reports whether the last update failed
+func (i Info) Failed() bool {
+	return i.LastOutcome == OutcomeFailed
+}
+
+// Info collects the state of the repository. It is best effort: values
+// that cannot be determined, such as the last commit of a repository
+// without commits, are left zero.
+func (r *Repository) Info(ctx context.Context) Info {
+	info := Info{
+		Path:        r.Path,
+		Branch:      r.CurrentBranch(),
+		Remotes:     make(map[string]string),
+		Fork:        r.hasUpstream(),
+		LFS:         r.isLFSRepository(),
+		Tags:        r.Tags,
+		LastUpdated: r.LastUpdated,
+		LastOutcome: r.LastOutcome,
+		LastError:   r.LastError,
+	}
+	if r.repo == nil {
+		return info
+	}
+
+	if remotes, err := r.repo.Remotes(); err == nil {
+		for _, remote := range remotes {
+			if urls := remote.Config().URLs; len(urls) > 0 {
+				info.Remotes[remote.Config().Name] = urls[0]
+			}
+		}
+	}
+
+	if head, err := r.repo.Head(); err == nil {
+		if commit, err := r.repo.CommitObject(head.Hash()); err == nil {
+			info.LastCommit = commit.Committer.When
+		}
+		if info.Branch != "" {
+			ref := r.FetchRemote() + "/" + r.trackedBranch(head)
+			info.Ahead, info.Behind, _ = r.aheadBehind(ctx, ref)
+		}
+	}
+
+	info.LastActivity = r.LastActivity(ctx)
+	info.Size = r.objectsSize(ctx)
+
+	// Like updates, only changes to tracked files count and drifted
+	// submodule pointers do not
+	out, err := r.gitCommand(ctx, "status", "--porcelain", "--untracked-files=no", "--ignore-submodules=all").Output()
+	info.Dirty = err == nil && len(strings.TrimSpace(string(out))) > 0
+
+	return info
+}
+
+// objectsSize returns the disk size of the object database in bytes, loose
+// objects and packs
+func (r *Repository) objectsSize(ctx context.Context) int64 {
+	out, err := r.gitCommand(ctx, "count-objects", "-v").Output()
+	if err != nil {
+		return 0
+	}
+
+	// Sizes are reported in KiB, e.g. "size: 12" and "size-pack: 340"
+	var size int64
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || (key != "size" && key != "size-pack") {
+			continue
+		}
+		kib, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		size += kib * 1024
+	}
+	return size
+}
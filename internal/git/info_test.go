@@ -0,0 +1,52 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+func TestRepository_Info(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// One commit on each side and a change to a tracked file
+	pushToOrigin(t, dir, "remote.txt", "Remote change")
+	commitFile(t, dir, "local.txt", "Local change")
+	runGit(t, dir, "fetch", "-q", "origin")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte("changed"), 0644))
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo, Tags: []string{"work"}, LastOutcome: OutcomeFailed}
+	r.Configure(appconfig.RepositorySettings{})
+
+	info := r.Info(context.Background())
+	assert.Equal(t, dir, info.Path)
+	assert.Equal(t, "master", info.Branch)
+	assert.Len(t, info.Remotes, 1)
+	assert.NotEmpty(t, info.Remotes["origin"])
+	assert.False(t, info.Fork)
+	assert.False(t, info.LFS)
+	assert.Equal(t, []string{"work"}, info.Tags)
+	assert.False(t, info.LastCommit.IsZero())
+	assert.Positive(t, info.Size)
+	assert.True(t, info.Dirty)
+	assert.Equal(t, 1, info.Ahead)
+	assert.Equal(t, 1, info.Behind)
+	assert.True(t, info.Diverged())
+	assert.True(t, info.Failed())
+
+	// A detached HEAD is not compared with the remote
+	runGit(t, dir, "checkout", "-q", "--detach")
+	info = r.Info(context.Background())
+	assert.Empty(t, info.Branch)
+	assert.Zero(t, info.Ahead)
+	assert.False(t, info.Diverged())
+}
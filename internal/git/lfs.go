@@ -0,0 +1,148 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// lfsOIDLength is the length of the SHA-256 object IDs used by Git LFS
+const lfsOIDLength = 64
+
+// hasLFSFilter reports whether the attributes file at path routes files
+// through the LFS filter (e.g. "*.bin filter=lfs diff=lfs merge=lfs")
+func hasLFSFilter(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), "filter=lfs")
+}
+
+// isLFSRepository checks if the repository uses Git LFS. It looks at every
+// attributes file (the root one, nested tracked ones and .git/info/attributes),
+// at .lfsconfig and at the lfs section git-lfs writes to the repository config.
+func (r *Repository) isLFSRepository() bool {
+	if hasLFSFilter(filepath.Join(r.Path, ".gitattributes")) ||
+		hasLFSFilter(filepath.Join(r.Path, ".git", "info", "attributes")) {
+		return true
+	}
+
+	if _, err := os.Stat(filepath.Join(r.Path, ".lfsconfig")); err == nil {
+		return true
+	}
+
+	if r.repo == nil {
+		return false
+	}
+
+	if idx, err := r.repo.Storer.Index(); err == nil {
+		for _, entry := range idx.Entries {
+			if path.Base(entry.Name) != ".gitattributes" || !strings.Contains(entry.Name, "/") {
+				continue
+			}
+			if hasLFSFilter(filepath.Join(r.Path, filepath.FromSlash(entry.Name))) {
+				return true
+			}
+		}
+	}
+
+	cfg, err := r.repo.Config()
+	return err == nil && cfg.Raw.HasSection("lfs")
+}
+
+// lfsFetchArgs returns the git lfs fetch arguments for the new HEAD, limited
+// by the configured include and exclude patterns
+func (r *Repository) lfsFetchArgs(remote string) []string {
+	args := []string{"lfs", "fetch"}
+	if include := r.settings.LFS.Include; len(include) > 0 {
+		args = append(args, "--include", strings.Join(include, ","))
+	}
+	if exclude := r.settings.LFS.Exclude; len(exclude) > 0 {
+		args = append(args, "--exclude", strings.Join(exclude, ","))
+	}
+	return append(args, remote, "HEAD")
+}
+
+// pullLFS downloads the LFS objects of the new HEAD from remote, replaces
+// the pointer files in the worktree with their content and optionally prunes
+// old objects. The downloaded objects are recorded in LFSObjects and LFSBytes.
+func (r *Repository) pullLFS(ctx context.Context, remote string) error {
+	r.phase(PhaseLFS)
+	before, err := r.lfsObjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := r.runGitCommand(ctx, r.lfsFetchArgs(remote)...); err != nil {
+		return fmt.Errorf("failed to fetch LFS objects from %s: %w", remote, err)
+	}
+	if out, err := r.gitCommand(ctx, "lfs", "checkout").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to check out LFS objects: %s: %w", string(out), err)
+	}
+
+	after, err := r.lfsObjects(ctx)
+	if err != nil {
+		return err
+	}
+	for oid, size := range after {
+		if _, ok := before[oid]; !ok {
+			r.LFSObjects++
+			r.LFSBytes += size
+		}
+	}
+
+	if r.settings.ShouldPruneLFS() {
+		if out, err := r.gitCommand(ctx, "lfs", "prune").CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to prune LFS objects: %s: %w", string(out), err)
+		}
+	}
+
+	return nil
+}
+
+// lfsObjects returns the size of every LFS object in the local store, keyed
+// by object ID
+func (r *Repository) lfsObjects(ctx context.Context) (map[string]int64, error) {
+	out, err := r.gitCommand(ctx, "rev-parse", "--git-common-dir").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate git directory: %s: %w", string(out), err)
+	}
+	gitDir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(r.Path, gitDir)
+	}
+
+	objects := make(map[string]int64)
+	err = filepath.WalkDir(filepath.Join(gitDir, "lfs", "objects"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || len(d.Name()) != lfsOIDLength {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects[d.Name()] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LFS objects: %w", err)
+	}
+	return objects, nil
+}
+
+// UsesLFS reports whether the repository uses Git LFS, which updates it
+// with git-lfs
+func (r *Repository) UsesLFS() bool {
+	return r.isLFSRepository()
+}
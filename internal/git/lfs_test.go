@@ -0,0 +1,60 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+func TestRepository_lfsFetchArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings appconfig.LFS
+		want     []string
+	}{
+		{
+			name: "no filters",
+			want: []string{"lfs", "fetch", "origin", "HEAD"},
+		},
+		{
+			name:     "include and exclude",
+			settings: appconfig.LFS{Include: []string{"assets/**", "*.psd"}, Exclude: []string{"videos/**"}},
+			want:     []string{"lfs", "fetch", "--include", "assets/**,*.psd", "--exclude", "videos/**", "origin", "HEAD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repository{settings: appconfig.RepositorySettings{LFS: tt.settings}}
+			assert.Equal(t, tt.want, r.lfsFetchArgs("origin"))
+		})
+	}
+}
+
+func TestRepository_lfsObjects(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	r := &Repository{Path: dir}
+
+	// A repository that never used LFS has an empty store
+	objects, err := r.lfsObjects(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	oid := strings.Repeat("ab", lfsOIDLength/2)
+	objectDir := filepath.Join(dir, ".git", "lfs", "objects", "ab", "ab")
+	require.NoError(t, os.MkdirAll(objectDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(objectDir, oid), make([]byte, 1024), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(objectDir, "partial"), []byte("x"), 0644))
+
+	objects, err = r.lfsObjects(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{oid: 1024}, objects)
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ParseRemoteURL returns the host and path of a remote URL and whether it is
+// reached over SSH. Local paths have no host.
+func ParseRemoteURL(remoteURL string) (host, path string, ssh bool) {
+	if !strings.Contains(remoteURL, "://") {
+		// scp-like syntax, e.g. git@github.com:trutx/gogitup.git. A colon
+		// after a slash belongs to a local path.
+		host, path, ok := strings.Cut(remoteURL, ":")
+		if !ok || strings.Contains(host, "/") {
+			return "", "", false
+		}
+		if _, after, ok := strings.Cut(host, "@"); ok {
+			host = after
+		}
+		return host, path, true
+	}
+
+	u, err := url.Parse(remoteURL)
+	if err != nil || u.Scheme == "file" {
+		return "", "", false
+	}
+	return u.Hostname(), u.Path, strings.Contains(u.Scheme, "ssh")
+}
+
+// IsGitHubURL reports whether a remote URL points to github.com. The host is
+// compared exactly, so that credentials meant for GitHub are never sent to
+// hosts that merely contain its name.
+func IsGitHubURL(remoteURL string) bool {
+	host, _, _ := ParseRemoteURL(remoteURL)
+	return strings.EqualFold(host, "github.com")
+}
+
+// GitHubRepository returns the owner/name of a github.com remote URL, or ""
+// for other remotes
+func GitHubRepository(remoteURL string) string {
+	if !IsGitHubURL(remoteURL) {
+		return ""
+	}
+	_, path, _ := ParseRemoteURL(remoteURL)
+
+	name := strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if owner, repo, ok := strings.Cut(name, "/"); !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		host string
+		path string
+		ssh  bool
+	}{
+		{"https://github.com/trutx/gogitup.git", "github.com", "/trutx/gogitup.git", false},
+		{"https://user@gitlab.example.com:8443/group/project.git", "gitlab.example.com", "/group/project.git", false},
+		{"git@github.com:trutx/gogitup.git", "github.com", "trutx/gogitup.git", true},
+		{"github.com:trutx/gogitup.git", "github.com", "trutx/gogitup.git", true},
+		{"ssh://git@git.example.com:2222/project.git", "git.example.com", "/project.git", true},
+		{"file:///srv/git/project.git", "", "", false},
+		{"/srv/git/project.git", "", "", false},
+		{"../project.git", "", "", false},
+		{"./dir:with/colon", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			host, path, ssh := ParseRemoteURL(tt.url)
+			assert.Equal(t, tt.host, host)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.ssh, ssh)
+		})
+	}
+}
+
+func TestIsGitHubURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://github.com/trutx/gogitup.git", true},
+		{"https://GitHub.com/trutx/gogitup.git", true},
+		{"git@github.com:trutx/gogitup.git", true},
+		{"ssh://git@github.com/trutx/gogitup.git", true},
+		{"https://github.com.evil.example/trutx/gogitup.git", false},
+		{"https://evil.example/github.com/trutx/gogitup.git", false},
+		{"git@evil.example:github.com/gogitup.git", false},
+		{"https://gist.github.com/trutx/1234.git", false},
+		{"/srv/github.com/gogitup.git", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsGitHubURL(tt.url))
+		})
+	}
+}
+
+func TestGitHubRepository(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/trutx/gogitup.git", "trutx/gogitup"},
+		{"https://user@github.com/trutx/gogitup", "trutx/gogitup"},
+		{"git@github.com:trutx/gogitup.git", "trutx/gogitup"},
+		{"ssh://git@github.com/trutx/gogitup.git", "trutx/gogitup"},
+		{"https://gitlab.com/trutx/gogitup.git", ""},
+		{"https://github.com.evil.example/trutx/gogitup.git", ""},
+		{"https://github.com/trutx", ""},
+		{"https://github.com/trutx/gogitup/tree/main", ""},
+		{"/srv/git/gogitup.git", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, GitHubRepository(tt.url))
+		})
+	}
+}
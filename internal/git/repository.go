@@ -1,131 +1,246 @@
 package git
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/format/diff"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/mattn/go-isatty"
-	"github.com/spf13/viper"
+	appconfig "github.com/trutx/gogitup/internal/config"
 	"golang.org/x/term"
 )
 
 // Common errors
 var (
 	ErrUncommittedChanges = fmt.Errorf("worktree contains uncommitted changes to tracked files")
+	ErrSkipped            = fmt.Errorf("repository is skipped by configuration")
+)
+
+// Outcomes of the last update recorded in the repository list
+const (
+	OutcomeUpdated = "updated"
+	OutcomeSkipped = "skipped"
+	OutcomeFailed  = "failed"
+)
+
+// Phases of an update reported to the function set with OnPhase
+const (
+	PhaseChecking   = "checking"
+	PhaseFetching   = "fetching"
+	PhaseMerging    = "merging"
+	PhasePushing    = "pushing"
+	PhaseLFS        = "lfs"
+	PhaseSubmodules = "submodules"
+	PhaseHooks      = "hooks"
 )
 
 // Repository represents a Git repository
 type Repository struct {
 	Path        string          `json:"path"`
 	HasUpstream bool            `json:"has_upstream"`
+	Tags        []string        `json:"tags,omitempty"`
 	LastScanned time.Time       `json:"last_scanned"`
 	DiffStats   string          `json:"-"`
 	repo        *git.Repository `json:"-"`
+
+	// Manual is set for repositories added by hand, which are kept when a
+	// scan does not find them
+	Manual bool `json:"manual,omitempty"`
+
+	// Outcome of the last update, persisted in the repository list
+	LastUpdated time.Time `json:"last_updated,omitzero"`
+	LastOutcome string    `json:"last_outcome,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	// State seen by the last update and the number of updates per outcome,
+	// exported as metrics
+	LastDuration time.Duration  `json:"last_duration,omitempty"`
+	Dirty        bool           `json:"dirty,omitempty"`
+	Behind       int            `json:"commits_behind,omitempty"`
+	Outcomes     map[string]int `json:"outcomes,omitempty"`
+
+	// LFSObjects and LFSBytes count the LFS objects downloaded by the last
+	// update
+	LFSObjects int   `json:"-"`
+	LFSBytes   int64 `json:"-"`
+
+	// Settings and HEADs of the current update
+	settings   appconfig.RepositorySettings
+	oldHead    string
+	newHead    string
+	skipSmudge bool
+	onPhase    func(phase string)
+	// lastPhase and lastRemote tell where the current update is, they are
+	// logged when it fails
+	lastPhase  string
+	lastRemote string
 }
 
-// GetCacheFile returns the default path to the cache file
-func GetCacheFile() (string, error) {
-	cacheDir, err := os.UserCacheDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get cache directory: %w", err)
+// MergeRepositories returns the freshly found repositories, carrying over the
+// user-managed fields (such as tags) from the previously cached entries.
+// Manually added repositories are kept even if they were not found.
+func MergeRepositories(previous, found []Repository) []Repository {
+	byPath := make(map[string]*Repository, len(previous))
+	for i := range previous {
+		byPath[previous[i].Path] = &previous[i]
 	}
 
-	// Create gogitup cache directory if it doesn't exist
-	gogitupCache := filepath.Join(cacheDir, "gogitup")
-	if err := os.MkdirAll(gogitupCache, 0755); err != nil {
-		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	for i := range found {
+		if prev, ok := byPath[found[i].Path]; ok {
+			delete(byPath, found[i].Path)
+			found[i].Manual = prev.Manual
+			found[i].Tags = prev.Tags
+			found[i].LastUpdated = prev.LastUpdated
+			found[i].LastOutcome = prev.LastOutcome
+			found[i].LastError = prev.LastError
+			found[i].LastDuration = prev.LastDuration
+			found[i].Dirty = prev.Dirty
+			found[i].Behind = prev.Behind
+			found[i].Outcomes = prev.Outcomes
+		}
 	}
 
-	return filepath.Join(gogitupCache, "repositories.json"), nil
-}
-
-// SaveRepositories saves the repository list to the specified file
-func SaveRepositories(repositories []Repository) error {
-	reposFile := viper.GetString("repos-file")
-	if reposFile == "" {
-		var err error
-		reposFile, err = GetCacheFile()
-		if err != nil {
-			return err
+	for _, prev := range previous {
+		if _, missing := byPath[prev.Path]; missing && prev.Manual {
+			found = append(found, prev)
 		}
 	}
 
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(reposFile), 0755); err != nil {
-		return fmt.Errorf("failed to create directory for repos file: %w", err)
-	}
+	return found
+}
 
-	// Add scan timestamp
-	for i := range repositories {
-		repositories[i].LastScanned = time.Now()
+// RecordOutcome stores the outcome of an update finished at the given time
+// and counts it. LastUpdated only moves on successful updates.
+func (r *Repository) RecordOutcome(outcome string, err error, at time.Time) {
+	r.LastOutcome = outcome
+	if r.Outcomes == nil {
+		r.Outcomes = make(map[string]int)
 	}
-
-	data, err := json.MarshalIndent(repositories, "", "  ")
+	r.Outcomes[outcome]++
+	r.LastError = ""
 	if err != nil {
-		return fmt.Errorf("failed to marshal repositories: %w", err)
+		r.LastError = err.Error()
 	}
+	if outcome == OutcomeUpdated {
+		r.LastUpdated = at
+	}
+}
 
-	if err := os.WriteFile(reposFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write repos file: %w", err)
+// HasTag reports whether the repository has the given tag
+func (r *Repository) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
 	}
+	return false
+}
 
-	return nil
+// AddTags adds the given tags to the repository, ignoring duplicates
+func (r *Repository) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if tag != "" && !r.HasTag(tag) {
+			r.Tags = append(r.Tags, tag)
+		}
+	}
+	sort.Strings(r.Tags)
 }
 
-// LoadRepositories loads the repository list from the specified file
-func LoadRepositories() ([]Repository, error) {
-	reposFile := viper.GetString("repos-file")
-	if reposFile == "" {
-		var err error
-		reposFile, err = GetCacheFile()
-		if err != nil {
-			return nil, err
+// RemoveTags removes the given tags from the repository
+func (r *Repository) RemoveTags(tags ...string) {
+	kept := r.Tags[:0]
+	for _, t := range r.Tags {
+		remove := false
+		for _, tag := range tags {
+			if t == tag {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			kept = append(kept, t)
 		}
 	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	r.Tags = kept
+}
 
-	data, err := os.ReadFile(reposFile)
+// OpenRepository opens the Git repository at path and detects whether it
+// has an upstream remote
+func OpenRepository(path string) (*Repository, error) {
+	repo, err := git.PlainOpen(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
+		return nil, fmt.Errorf("failed to open repository %s: %w", path, err)
+	}
+
+	// Check for upstream remote
+	// Note: This may fail for repos with negative refspecs (^refs/...),
+	// which are valid in native Git but not supported by go-git.
+	// We still want to include the repository even if this fails.
+	hasUpstream := false
+	remotes, err := repo.Remotes()
+	if err == nil {
+		for _, remote := range remotes {
+			if remote.Config().Name == "upstream" {
+				hasUpstream = true
+				break
+			}
 		}
-		return nil, fmt.Errorf("failed to read repos file: %w", err)
 	}
+	// If err != nil, we just treat it as not having upstream
 
-	var repositories []Repository
-	if err := json.Unmarshal(data, &repositories); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal repositories: %w", err)
+	return &Repository{
+		Path:        path,
+		HasUpstream: hasUpstream,
+		repo:        repo,
+	}, nil
+}
+
+// AppendRepositories adds the given repositories to the list, skipping the
+// ones whose path is already present
+func AppendRepositories(repositories []Repository, added ...Repository) []Repository {
+	known := make(map[string]bool, len(repositories))
+	for _, repo := range repositories {
+		known[repo.Path] = true
 	}
 
-	// Filter out repositories that can't be opened
-	validRepos := make([]Repository, 0, len(repositories))
-	for i := range repositories {
-		repo, err := git.PlainOpen(repositories[i].Path)
-		if err != nil {
-			// Skip repositories that can't be opened
-			continue
+	for _, repo := range added {
+		if !known[repo.Path] {
+			repositories = append(repositories, repo)
+			known[repo.Path] = true
 		}
-		repositories[i].repo = repo
-		validRepos = append(validRepos, repositories[i])
 	}
 
-	return validRepos, nil
+	return repositories
 }
 
 // FindRepositories searches for Git repositories in the given directories
 func FindRepositories(directories []string, onFound func(count int)) ([]Repository, error) {
+	return FindRepositoriesContext(context.Background(), directories, onFound)
+}
+
+// FindRepositoriesContext is FindRepositories with a context that stops the
+// search when it is cancelled
+func FindRepositoriesContext(ctx context.Context, directories []string, onFound func(count int)) ([]Repository, error) {
 	var repositories []Repository
 	count := 0
+	scanned := time.Now()
 
 	for _, dir := range directories {
 		// Expand home directory if path starts with ~
@@ -143,6 +258,9 @@ func FindRepositories(directories []string, onFound func(count int)) ([]Reposito
 		}
 
 		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
 			if err != nil {
 				if os.IsPermission(err) {
 					// Skip directories we can't access
@@ -162,32 +280,13 @@ func FindRepositories(directories []string, onFound func(count int)) ([]Reposito
 			// Check for .git directory
 			gitDir := filepath.Join(path, ".git")
 			if stat, err := os.Stat(gitDir); err == nil && stat.IsDir() {
-				repo, err := git.PlainOpen(path)
+				repo, err := OpenRepository(path)
 				if err != nil {
 					return nil // Skip invalid repositories
 				}
 
-				// Check for upstream remote
-				// Note: This may fail for repos with negative refspecs (^refs/...),
-				// which are valid in native Git but not supported by go-git.
-				// We still want to include the repository even if this fails.
-				hasUpstream := false
-				remotes, err := repo.Remotes()
-				if err == nil {
-					for _, remote := range remotes {
-						if remote.Config().Name == "upstream" {
-							hasUpstream = true
-							break
-						}
-					}
-				}
-				// If err != nil, we just treat it as not having upstream
-
-				repositories = append(repositories, Repository{
-					Path:        path,
-					HasUpstream: hasUpstream,
-					repo:        repo,
-				})
+				repo.LastScanned = scanned
+				repositories = append(repositories, *repo)
 				count++
 				if onFound != nil {
 					onFound(count)
@@ -207,38 +306,54 @@ func FindRepositories(directories []string, onFound func(count int)) ([]Reposito
 	return repositories, nil
 }
 
-// isGitHubRepository checks if any remote URL points to GitHub
-func (r *Repository) isGitHubRepository() bool {
-	if r.repo == nil {
-		return false
+// githubCredentialURL is the URL GITHUB_TOKEN is scoped to, so that native
+// git commands only send it to github.com
+const githubCredentialURL = "https://github.com"
+
+// token returns the username and token used to authenticate against the
+// remote URL. An explicitly configured token applies to every remote,
+// GITHUB_TOKEN only to github.com. The token is empty when no credentials
+// apply.
+func (r *Repository) token(remoteURL string) (string, string) {
+	username := r.settings.Credentials.Username
+	if username == "" {
+		username = "git" // This can be anything except empty
 	}
 
-	remotes, err := r.repo.Remotes()
-	if err != nil {
-		return false
+	if env := r.settings.Credentials.TokenEnv; env != "" {
+		return username, os.Getenv(env)
+	}
+	if IsGitHubURL(remoteURL) {
+		return username, os.Getenv("GITHUB_TOKEN")
 	}
+	return username, ""
+}
 
-	for _, remote := range remotes {
-		for _, url := range remote.Config().URLs {
-			if strings.Contains(url, "github.com") {
-				return true
-			}
-		}
+// authArgs returns the git arguments passing the credentials to native git.
+// A configured token is offered to every remote, GITHUB_TOKEN only to the
+// github.com ones, whichever remote or submodule the command contacts.
+func (r *Repository) authArgs() []string {
+	scope := ""
+	if r.settings.Credentials.TokenEnv == "" {
+		scope = githubCredentialURL
 	}
-	return false
+	username, token := r.token(githubCredentialURL)
+	if token == "" {
+		return nil
+	}
+	return credentialArgs(scope, username, token)
 }
 
-func (r *Repository) getAuth() transport.AuthMethod {
-	// Check if this is a GitHub repository
-	if r.isGitHubRepository() {
-		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-			return &http.BasicAuth{
-				Username: "git", // This can be anything except empty
-				Password: token,
-			}
-		}
+// getAuth returns the go-git credentials for the named remote
+func (r *Repository) getAuth(remote string) transport.AuthMethod {
+	username, token := r.token(r.RemoteURL(remote))
+	if token == "" {
+		return nil
+	}
+	return &http.BasicAuth{
+		Username: username,
+		Password: token,
 	}
-	return nil
 }
 
 // getTerminalWidth returns the terminal width, defaulting to 80 if not in a terminal
@@ -290,64 +405,212 @@ func formatDiffStat(path string, added, removed int, maxPathLen int, maxNumLen i
 	)
 }
 
-// isLFSRepository checks if the repository uses Git LFS by looking for .gitattributes with LFS entries
-func (r *Repository) isLFSRepository() bool {
-	// Check .gitattributes file
-	gitattributes := filepath.Join(r.Path, ".gitattributes")
-	data, err := os.ReadFile(gitattributes)
+// OnPhase sets a function that is called whenever an update enters a new
+// phase, e.g. to show progress. It is called from the updating goroutine.
+func (r *Repository) OnPhase(fn func(phase string)) {
+	r.onPhase = fn
+}
+
+// phase reports the current update phase
+func (r *Repository) phase(phase string) {
+	r.lastPhase = phase
+	r.logger().Debug("update phase", "phase", phase)
+	if r.onPhase != nil {
+		r.onPhase(phase)
+	}
+}
+
+// logger returns the default logger with the repository path attached
+func (r *Repository) logger() *slog.Logger {
+	return slog.Default().With("path", r.Path)
+}
+
+// logArgs returns git arguments for logging, without credentials
+func logArgs(args []string) []string {
+	logged := make([]string, len(args))
+	for i, arg := range args {
+		// The helper is credential.helper or credential.<url>.helper
+		if key, _, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(key, "credential.") && strings.HasSuffix(key, ".helper") {
+			arg = key + "=<redacted>"
+		}
+		logged[i] = arg
+	}
+	return logged
+}
+
+// gitCommand returns a native git command that runs in the repository directory
+func (r *Repository) gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	r.logger().Debug("running git", "args", logArgs(args))
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Path
+	cmd.Env = os.Environ()
+	if r.skipSmudge {
+		// LFS objects are fetched and checked out separately
+		cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
+	}
+	return cmd
+}
+
+// runGitCommand executes a git command in the repository directory. When a
+// token is available it is passed to git through a credential helper, see
+// authArgs.
+func (r *Repository) runGitCommand(ctx context.Context, args ...string) error {
+	args = append(r.authArgs(), args...)
+
+	output, err := r.gitCommand(ctx, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git command failed: %s: %w", string(output), err)
+	}
+	return nil
+}
+
+// revParse resolves a revision to a commit hash using native git
+func (r *Repository) revParse(ctx context.Context, rev string) (string, error) {
+	out, err := r.gitCommand(ctx, "rev-parse", rev).CombinedOutput()
 	if err != nil {
-		return false
+		return "", fmt.Errorf("failed to resolve %s: %s: %w", rev, string(out), err)
 	}
+	return strings.TrimSpace(string(out)), nil
+}
 
-	// Look for LFS patterns (e.g., "*.bin filter=lfs diff=lfs merge=lfs")
-	return strings.Contains(string(data), "filter=lfs")
+// hasUpstream reports whether the repository is a fork synced from an upstream remote
+func (r *Repository) hasUpstream() bool {
+	if r.settings.Upstream == "" || r.repo == nil {
+		return r.HasUpstream
+	}
+	_, err := r.repo.Remote(r.settings.Upstream)
+	return err == nil
 }
 
-// runGitCommand executes a git command in the repository directory
-func (r *Repository) runGitCommand(args ...string) error {
-	return r.runGitCommandWithAuth(args...)
+// trackedBranch returns the remote branch the current branch is updated from
+func (r *Repository) trackedBranch(head *plumbing.Reference) string {
+	if r.settings.Branch != "" {
+		return r.settings.Branch
+	}
+	return head.Name().Short()
+}
+
+// fetch fetches all branches of the given remote
+func (r *Repository) fetch(ctx context.Context, remote string) error {
+	r.phase(PhaseFetching)
+	r.lastRemote = remote
+	r.logger().Debug("fetching", "remote", remote)
+	err := r.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remote,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", remote))},
+		Auth:       r.getAuth(remote),
+		Prune:      r.settings.ShouldPrune(),
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		if err == transport.ErrAuthenticationRequired {
+			return fmt.Errorf("authentication required: set GITHUB_TOKEN environment variable for GitHub repositories")
+		}
+		return fmt.Errorf("failed to fetch from %s: %w", remote, err)
+	}
+	return nil
 }
 
-func (r *Repository) runGitCommandWithAuth(args ...string) error {
-	// If this is a GitHub repository and GITHUB_TOKEN is set, configure credential helper
-	if r.isGitHubRepository() {
-		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
-			// Use git credential helper with the token
-			// We pass the token via git's credential.helper configuration
-			credHelper := fmt.Sprintf("!f() { echo \"username=git\"; echo \"password=%s\"; }; f", token)
-			allArgs := append([]string{"-c", "credential.helper=" + credHelper}, args...)
+// fetchArgs returns the native git arguments to fetch the given remote
+func (r *Repository) fetchArgs(remote string) []string {
+	if r.settings.ShouldPrune() {
+		return []string{"fetch", "--prune", remote}
+	}
+	return []string{"fetch", remote}
+}
 
-			cmd := exec.Command("git", allArgs...)
-			cmd.Dir = r.Path
-			cmd.Env = os.Environ()
+// fetchRemotes fetches origin, and upstream for forks, without touching the
+// worktree. It is used for pinned repositories.
+func (r *Repository) fetchRemotes(ctx context.Context) error {
+	if err := r.fetch(ctx, r.settings.OriginRemote()); err != nil {
+		return err
+	}
+	if r.hasUpstream() {
+		return r.fetch(ctx, r.settings.UpstreamRemote())
+	}
+	return nil
+}
 
-			output, err := cmd.CombinedOutput()
-			if err != nil {
-				return fmt.Errorf("git command failed: %s: %w", string(output), err)
+// integrate brings the current branch up to date with remote/branch using
+// the configured strategy. Failed rebases and merges are aborted so the
+// worktree is left as it was.
+func (r *Repository) integrate(ctx context.Context, remote, branch string) error {
+	ref := remote + "/" + branch
+	r.phase(PhaseMerging)
+	r.lastRemote = remote
+	r.logger().Debug("integrating", "ref", ref, "strategy", r.settings.UpdateStrategy())
+
+	switch r.settings.UpdateStrategy() {
+	case appconfig.StrategyRebase:
+		if out, err := r.gitCommand(ctx, "rebase", ref).CombinedOutput(); err != nil {
+			_ = r.gitCommand(context.Background(), "rebase", "--abort").Run()
+			return fmt.Errorf("failed to rebase onto %s: %s: %w", ref, string(out), err)
+		}
+	case appconfig.StrategyMerge:
+		if out, err := r.gitCommand(ctx, "merge", "--no-edit", ref).CombinedOutput(); err != nil {
+			_ = r.gitCommand(context.Background(), "merge", "--abort").Run()
+			return fmt.Errorf("failed to merge %s: %s: %w", ref, string(out), err)
+		}
+	default:
+		out, err := r.gitCommand(ctx, "merge", "--ff-only", ref).CombinedOutput()
+		if err != nil {
+			outputStr := string(out)
+			// Check if it's a non-fast-forward error
+			if strings.Contains(outputStr, "Not possible to fast-forward") ||
+				strings.Contains(outputStr, "not possible to fast-forward") {
+				return r.divergedError(ctx, remote, ref)
 			}
-			return nil
+			return fmt.Errorf("failed to merge %s: %s: %w", ref, outputStr, err)
 		}
 	}
 
-	// No authentication needed or not a GitHub repository
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.Path
-	cmd.Env = os.Environ()
+	return nil
+}
 
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("git command failed: %s: %w", string(output), err)
+// runHooks runs the given shell commands in the repository directory,
+// stopping at the first one that fails
+func (r *Repository) runHooks(ctx context.Context, hooks []string, env ...string) error {
+	if len(hooks) > 0 {
+		r.phase(PhaseHooks)
+	}
+	for _, hook := range hooks {
+		cmd := shellCommand(ctx, hook)
+		cmd.Dir = r.Path
+		cmd.Env = append(append(os.Environ(), "GOGITUP_REPO="+r.Path), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %s: %w", hook, strings.TrimSpace(string(out)), err)
+		}
 	}
 	return nil
 }
 
+// gitDiffStats returns the native git diff stats between oldHead and HEAD
+func (r *Repository) gitDiffStats(ctx context.Context, oldHead string) (string, error) {
+	// Get diff stats with proper width for the graph
+	termWidth := getTerminalWidth()
+	statWidth := fmt.Sprintf("--stat=%d", termWidth)
+	stats, err := r.gitCommand(ctx, "diff", statWidth, "--color=always", oldHead+"..HEAD").CombinedOutput()
+	if err != nil {
+		// If the diff command fails, try using git show instead
+		stats, err = r.gitCommand(ctx, "show", statWidth, "--color=always", "HEAD").CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("failed to get diff stats: %s: %w", string(stats), err)
+		}
+	}
+	return string(stats), nil
+}
+
 // updateLFSRepository updates an LFS-enabled repository using native git commands
-func (r *Repository) updateLFSRepository() error {
+func (r *Repository) updateLFSRepository(ctx context.Context) error {
 	// Check if git-lfs is installed
 	if _, err := exec.LookPath("git-lfs"); err != nil {
 		return fmt.Errorf("git-lfs is not installed: %w", err)
 	}
 
+	// Move the branch without downloading LFS content, objects are fetched
+	// with the configured filters once the new HEAD is known
+	r.skipSmudge = true
+	defer func() { r.skipSmudge = false }()
+
 	// Get current branch
 	head, err := r.repo.Head()
 	if err != nil {
@@ -356,9 +619,7 @@ func (r *Repository) updateLFSRepository() error {
 
 	// Check for uncommitted changes to tracked files only
 	// First, get the list of tracked files
-	cmd := exec.Command("git", "ls-files")
-	cmd.Dir = r.Path
-	trackedFiles, err := cmd.CombinedOutput()
+	trackedFiles, err := r.gitCommand(ctx, "ls-files").CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to get tracked files: %s: %w", string(trackedFiles), err)
 	}
@@ -369,9 +630,7 @@ func (r *Repository) updateLFSRepository() error {
 	}
 
 	// Check for staged changes to tracked files
-	cmd = exec.Command("git", "diff-index", "--quiet", "HEAD", "--")
-	cmd.Dir = r.Path
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := r.gitCommand(ctx, "diff-index", "--quiet", "--ignore-submodules=all", "HEAD", "--").CombinedOutput(); err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return ErrUncommittedChanges
 		}
@@ -379,9 +638,7 @@ func (r *Repository) updateLFSRepository() error {
 	}
 
 	// Check for unstaged changes to tracked files
-	cmd = exec.Command("git", "diff-files", "--quiet", "--")
-	cmd.Dir = r.Path
-	if out, err := cmd.CombinedOutput(); err != nil {
+	if out, err := r.gitCommand(ctx, "diff-files", "--quiet", "--ignore-submodules=all", "--").CombinedOutput(); err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
 			return ErrUncommittedChanges
 		}
@@ -389,76 +646,186 @@ func (r *Repository) updateLFSRepository() error {
 	}
 
 	// Store the current HEAD for diff stats
-	cmd = exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = r.Path
-	oldHead, err := cmd.CombinedOutput()
+	oldHead, err := r.revParse(ctx, "HEAD")
 	if err != nil {
-		return fmt.Errorf("failed to get current HEAD: %s: %w", string(oldHead), err)
+		return fmt.Errorf("failed to get current HEAD: %w", err)
 	}
-	oldHeadStr := strings.TrimSpace(string(oldHead))
+	r.oldHead = oldHead
+
+	branch := r.trackedBranch(head)
+	origin := r.settings.OriginRemote()
+	lfsRemote := origin
+
+	if r.hasUpstream() {
+		upstream := r.settings.UpstreamRemote()
+		lfsRemote = upstream
 
-	if r.HasUpstream {
 		// Fetch from upstream
-		if err := r.runGitCommand("fetch", "upstream"); err != nil {
-			return fmt.Errorf("failed to fetch from upstream: %w", err)
+		r.phase(PhaseFetching)
+		if err := r.runGitCommand(ctx, r.fetchArgs(upstream)...); err != nil {
+			return fmt.Errorf("failed to fetch from %s: %w", upstream, err)
 		}
-
-		// Merge upstream changes with fast-forward only
-		currentBranch := head.Name().Short()
-		cmd = exec.Command("git", "merge", "--ff-only", "upstream/"+currentBranch)
-		cmd.Dir = r.Path
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			outputStr := string(output)
-			// Check if it's a non-fast-forward error
-			if strings.Contains(outputStr, "Not possible to fast-forward") ||
-			   strings.Contains(outputStr, "not possible to fast-forward") {
-				return fmt.Errorf("cannot fast-forward to upstream/%s: local branch has diverged from upstream. Please resolve manually (consider rebasing or merging manually)", currentBranch)
+		if r.settings.ShouldPrune() {
+			if err := r.runGitCommand(ctx, r.fetchArgs(origin)...); err != nil {
+				return fmt.Errorf("failed to fetch from %s: %w", origin, err)
 			}
-			return fmt.Errorf("failed to merge upstream/%s: %s: %w", currentBranch, outputStr, err)
+		}
+
+		if err := r.integrate(ctx, upstream, branch); err != nil {
+			return err
 		}
 
 		// Push to origin to keep fork in sync
-		if err := r.runGitCommand("push", "origin", head.Name().Short()); err != nil {
-			return fmt.Errorf("failed to push to origin: %w", err)
+		if r.settings.ShouldPush() {
+			r.phase(PhasePushing)
+			if err := r.runGitCommand(ctx, "push", origin, head.Name().Short()); err != nil {
+				return fmt.Errorf("failed to push to %s: %w", origin, err)
+			}
 		}
 	} else {
 		// Fetch from origin
-		if err := r.runGitCommand("fetch", "origin"); err != nil {
-			return fmt.Errorf("failed to fetch from origin: %w", err)
+		r.phase(PhaseFetching)
+		if err := r.runGitCommand(ctx, r.fetchArgs(origin)...); err != nil {
+			return fmt.Errorf("failed to fetch from %s: %w", origin, err)
 		}
 
-		// Fast-forward to origin
-		if err := r.runGitCommand("merge", "--ff-only", "origin/"+head.Name().Short()); err != nil {
-			return fmt.Errorf("failed to merge origin changes: %w", err)
+		if err := r.integrate(ctx, origin, branch); err != nil {
+			return err
 		}
 	}
 
-	// Get diff stats with proper width for the graph
-	termWidth := getTerminalWidth()
-	statWidth := fmt.Sprintf("--stat=%d", termWidth)
-	cmd = exec.Command("git", "diff", statWidth, "--color=always", oldHeadStr+"..HEAD")
-	cmd.Dir = r.Path
-	stats, err := cmd.CombinedOutput()
+	newHead, err := r.revParse(ctx, "HEAD")
 	if err != nil {
-		// If the diff command fails, try using git show instead
-		cmd = exec.Command("git", "show", statWidth, "--color=always", "HEAD")
-		cmd.Dir = r.Path
-		stats, err = cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to get diff stats: %s: %w", string(stats), err)
+		return fmt.Errorf("failed to get new HEAD: %w", err)
+	}
+	r.newHead = newHead
+
+	if err := r.pullLFS(ctx, lfsRemote); err != nil {
+		return err
+	}
+
+	stats, err := r.gitDiffStats(ctx, oldHead)
+	if err != nil {
+		return err
+	}
+	r.DiffStats = stats
+
+	return nil
+}
+
+// Configure sets the per-repository settings used by the repository's
+// operations, such as the remote names
+func (r *Repository) Configure(settings appconfig.RepositorySettings) {
+	r.settings = settings
+}
+
+// Update updates the repository by fetching and pulling changes, following
+// the given per-repository settings
+func (r *Repository) Update(settings appconfig.RepositorySettings) error {
+	return r.UpdateContext(context.Background(), settings)
+}
+
+// UpdateContext is Update with a context that aborts the git commands of the
+// update when it is cancelled
+func (r *Repository) UpdateContext(ctx context.Context, settings appconfig.RepositorySettings) (err error) {
+	r.Configure(settings)
+	r.DiffStats = ""
+	r.LFSObjects, r.LFSBytes = 0, 0
+	r.oldHead, r.newHead = "", ""
+	r.lastPhase, r.lastRemote = "", ""
+
+	if settings.IsSkipped() {
+		r.logger().Debug("repository skipped by configuration")
+		return ErrSkipped
+	}
+
+	started := time.Now()
+	r.logger().Debug("update started")
+	defer func() { r.logUpdate(started, err) }()
+
+	if settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.Timeout)
+		defer cancel()
+	}
+
+	r.phase(PhaseChecking)
+	if err := r.runHooks(ctx, settings.Hooks.PreUpdate); err != nil {
+		return fmt.Errorf("pre-update hook failed: %w", err)
+	}
+
+	err = r.update(ctx)
+	if err == nil && !settings.IsPinned() {
+		err = r.finishSubmodules(ctx)
+	}
+	if err == nil {
+		if hookErr := r.runHooks(ctx, settings.Hooks.PostUpdate,
+			"GOGITUP_OLD_HEAD="+r.oldHead,
+			"GOGITUP_NEW_HEAD="+r.newHead,
+		); hookErr != nil {
+			err = fmt.Errorf("post-update hook failed: %w", hookErr)
 		}
 	}
-	r.DiffStats = string(stats)
 
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("update timed out after %s: %w", settings.Timeout, err)
+	}
+	return err
+}
+
+// logUpdate logs the outcome of an update that started at started, with the
+// phase it failed in
+func (r *Repository) logUpdate(started time.Time, err error) {
+	log := r.logger().With("duration", time.Since(started))
+	switch {
+	case err == nil:
+		log.Info("repository updated", "old_head", r.oldHead, "new_head", r.newHead)
+	case errors.Is(err, ErrUncommittedChanges):
+		log.Info("repository skipped", "reason", err)
+	default:
+		log.Warn("repository update failed", "phase", r.lastPhase, "remote", r.lastRemote, "error", err)
+	}
+}
+
+// finishSubmodules updates the submodules if requested and adds the moved
+// submodule pointers to the diff stats
+func (r *Repository) finishSubmodules(ctx context.Context) error {
+	if !r.hasSubmodules() {
+		return nil
+	}
+
+	if r.settings.ShouldUpdateSubmodules() {
+		if err := r.updateSubmodules(ctx); err != nil {
+			return err
+		}
+	}
+
+	if r.oldHead == "" || r.newHead == "" || r.oldHead == r.newHead {
+		return nil
+	}
+	changes, err := r.submoduleChanges(ctx, r.oldHead, r.newHead)
+	if err != nil {
+		return err
+	}
+	if changes != "" {
+		if r.DiffStats != "" {
+			r.DiffStats = strings.TrimRight(r.DiffStats, "\n") + "\n"
+		}
+		r.DiffStats += changes
+	}
 	return nil
 }
 
-// Update updates the repository by fetching and pulling changes
-func (r *Repository) Update() error {
+// update performs the actual update once settings and hooks are handled
+func (r *Repository) update(ctx context.Context) error {
+	// Pinned repositories are only fetched
+	if r.settings.IsPinned() {
+		return r.fetchRemotes(ctx)
+	}
+
 	// Check if this is an LFS repository
 	if r.isLFSRepository() {
-		return r.updateLFSRepository()
+		return r.updateLFSRepository(ctx)
 	}
 
 	// Get worktree and check for unstaged changes first
@@ -473,8 +840,13 @@ func (r *Repository) Update() error {
 		return fmt.Errorf("failed to get worktree status: %w", err)
 	}
 
-	// Check only tracked files for changes
-	for _, fileStatus := range status {
+	// Check only tracked files for changes. Submodule pointers that drifted
+	// from the recorded commit are not local changes.
+	submodules := r.submodulePaths()
+	for path, fileStatus := range status {
+		if submodules[path] {
+			continue
+		}
 		if fileStatus.Staging != git.Untracked && fileStatus.Worktree != git.Untracked {
 			if fileStatus.Staging != git.Unmodified || fileStatus.Worktree != git.Unmodified {
 				return ErrUncommittedChanges
@@ -488,13 +860,14 @@ func (r *Repository) Update() error {
 		return fmt.Errorf("failed to get HEAD: %w", err)
 	}
 	oldHead := head.Hash()
+	r.oldHead = oldHead.String()
 
 	// Perform update
 	var updateErr error
-	if r.HasUpstream {
-		updateErr = r.updateWithUpstream()
+	if r.hasUpstream() {
+		updateErr = r.updateWithUpstream(ctx)
 	} else {
-		updateErr = r.updateOrigin()
+		updateErr = r.updateOrigin(ctx)
 	}
 
 	// If update was successful, get diff stats
@@ -510,6 +883,7 @@ func (r *Repository) Update() error {
 		if err != nil {
 			return fmt.Errorf("failed to get new HEAD: %w", err)
 		}
+		r.newHead = newHead.Hash().String()
 
 		// Only get diff stats if HEAD changed
 		if oldHead != newHead.Hash() {
@@ -624,35 +998,39 @@ func (r *Repository) Update() error {
 	return updateErr
 }
 
-func (r *Repository) updateOrigin() error {
+func (r *Repository) updateOrigin(ctx context.Context) error {
 	// Get current branch
 	head, err := r.repo.Head()
 	if err != nil {
 		return fmt.Errorf("failed to get HEAD: %w", err)
 	}
 
-	w, err := r.repo.Worktree()
-	if err != nil {
-		return fmt.Errorf("failed to get worktree: %w", err)
+	origin := r.settings.OriginRemote()
+	branch := r.trackedBranch(head)
+
+	// Fetch from origin
+	if err := r.fetch(ctx, origin); err != nil {
+		return err
 	}
 
-	auth := r.getAuth()
+	// Rebase and merge strategies are handled by native git, and so are
+	// repositories with submodules since go-git treats drifted submodule
+	// pointers as unstaged changes
+	if r.settings.UpdateStrategy() != appconfig.StrategyFastForward || r.hasSubmodules() {
+		return r.integrate(ctx, origin, branch)
+	}
 
-	// Fetch from origin
-	err = r.repo.Fetch(&git.FetchOptions{
-		RemoteName: "origin",
-		RefSpecs:   []config.RefSpec{config.RefSpec("+refs/heads/*:refs/remotes/origin/*")},
-		Auth:       auth,
-	})
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to fetch from origin: %w", err)
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
 	// Pull changes
-	err = w.Pull(&git.PullOptions{
-		RemoteName:    "origin",
-		ReferenceName: head.Name(),
-		Auth:          auth,
+	r.phase(PhaseMerging)
+	err = w.PullContext(ctx, &git.PullOptions{
+		RemoteName:    origin,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		Auth:          r.getAuth(origin),
 	})
 	if err != nil {
 		if err == git.NoErrAlreadyUpToDate {
@@ -661,81 +1039,64 @@ func (r *Repository) updateOrigin() error {
 		if err == git.ErrUnstagedChanges {
 			return ErrUncommittedChanges
 		}
+		if errors.Is(err, git.ErrNonFastForwardUpdate) {
+			return r.divergedError(ctx, origin, origin+"/"+branch)
+		}
 		if err == transport.ErrAuthenticationRequired {
 			return fmt.Errorf("authentication required: set GITHUB_TOKEN environment variable for GitHub repositories")
 		}
-		return fmt.Errorf("failed to pull from origin: %w", err)
+		return fmt.Errorf("failed to pull from %s: %w", origin, err)
 	}
 
 	return nil
 }
 
-func (r *Repository) updateWithUpstream() error {
+func (r *Repository) updateWithUpstream(ctx context.Context) error {
 	// Get current branch
 	head, err := r.repo.Head()
 	if err != nil {
 		return fmt.Errorf("failed to get HEAD: %w", err)
 	}
 
-	auth := r.getAuth()
-
 	// Store the current HEAD for diff stats
-	cmd := exec.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = r.Path
-	oldHead, err := cmd.CombinedOutput()
+	oldHead, err := r.revParse(ctx, "HEAD")
 	if err != nil {
-		return fmt.Errorf("failed to get current HEAD: %s: %w", string(oldHead), err)
+		return fmt.Errorf("failed to get current HEAD: %w", err)
 	}
-	oldHeadStr := strings.TrimSpace(string(oldHead))
 
 	// Fetch from upstream
-	err = r.repo.Fetch(&git.FetchOptions{
-		RemoteName: "upstream",
-		RefSpecs:   []config.RefSpec{config.RefSpec("+refs/heads/*:refs/remotes/upstream/*")},
-		Auth:       auth,
-	})
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("failed to fetch from upstream: %w", err)
+	upstream := r.settings.UpstreamRemote()
+	if err := r.fetch(ctx, upstream); err != nil {
+		return err
 	}
 
-	// Get the current branch name from the fork
-	currentBranchName := head.Name().Short()
-
-	// Merge upstream changes with fast-forward only
-	cmd = exec.Command("git", "merge", "--ff-only", "upstream/"+currentBranchName)
-	cmd.Dir = r.Path
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		outputStr := string(output)
-		// Check if it's a non-fast-forward error
-		if strings.Contains(outputStr, "Not possible to fast-forward") ||
-		   strings.Contains(outputStr, "not possible to fast-forward") {
-			return fmt.Errorf("cannot fast-forward to upstream/%s: local branch has diverged from upstream. Please resolve manually (consider rebasing or merging manually)", currentBranchName)
+	// Origin is not needed to sync a fork; fetch it only when pruning so
+	// its stale remote-tracking refs are removed as well
+	if r.settings.ShouldPrune() {
+		if err := r.fetch(ctx, r.settings.OriginRemote()); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to merge upstream/%s: %s: %w", currentBranchName, outputStr, err)
+	}
+
+	// Bring the current branch up to date with upstream
+	if err := r.integrate(ctx, upstream, r.trackedBranch(head)); err != nil {
+		return err
 	}
 
 	// Push to origin to keep fork in sync
-	if err := r.runGitCommand("push", "origin", currentBranchName); err != nil {
-		return fmt.Errorf("failed to push to origin: %w", err)
+	if r.settings.ShouldPush() {
+		r.phase(PhasePushing)
+		origin := r.settings.OriginRemote()
+		if err := r.runGitCommand(ctx, "push", origin, head.Name().Short()); err != nil {
+			return fmt.Errorf("failed to push to %s: %w", origin, err)
+		}
 	}
 
-	// Get diff stats with proper width for the graph
-	termWidth := getTerminalWidth()
-	statWidth := fmt.Sprintf("--stat=%d", termWidth)
-	cmd = exec.Command("git", "diff", statWidth, "--color=always", oldHeadStr+"..HEAD")
-	cmd.Dir = r.Path
-	stats, err := cmd.CombinedOutput()
+	stats, err := r.gitDiffStats(ctx, oldHead)
 	if err != nil {
-		// If the diff command fails, try using git show instead
-		cmd = exec.Command("git", "show", statWidth, "--color=always", "HEAD")
-		cmd.Dir = r.Path
-		stats, err = cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("failed to get diff stats: %s: %w", string(stats), err)
-		}
+		return err
 	}
-	r.DiffStats = string(stats)
+	r.DiffStats = stats
 
 	return nil
 }
@@ -1,8 +1,10 @@
 package git
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,9 +17,9 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
 )
 
 func setupTestRepo(t *testing.T) (string, func()) {
@@ -346,7 +348,7 @@ func TestRepository_Update(t *testing.T) {
 			}
 
 			t.Log("Updating repository")
-			err := repo.Update()
+			err := repo.Update(appconfig.RepositorySettings{})
 			if tt.wantErr {
 				assert.Error(t, err)
 				if tt.wantErrType != nil {
@@ -410,6 +412,26 @@ func TestRepository_GetAuth(t *testing.T) {
 			},
 			wantAuth: false,
 		},
+		{
+			name: "non-github origin with a github upstream",
+			setup: func() *Repository {
+				err := os.Setenv("GITHUB_TOKEN", "test-token")
+				require.NoError(t, err)
+				tmpDir, err := os.MkdirTemp("", "gogitup-test-*")
+				require.NoError(t, err)
+				gitRepo, err := git.PlainInit(tmpDir, false)
+				require.NoError(t, err)
+				for name, url := range map[string]string{
+					"origin":   "https://gitlab.com/user/repo.git",
+					"upstream": "https://github.com/user/repo.git",
+				} {
+					_, err = gitRepo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+					require.NoError(t, err)
+				}
+				return &Repository{Path: tmpDir, repo: gitRepo}
+			},
+			wantAuth: false,
+		},
 		{
 			name: "non-github repository",
 			setup: func() *Repository {
@@ -434,7 +456,7 @@ func TestRepository_GetAuth(t *testing.T) {
 			defer func() {
 				_ = os.RemoveAll(repo.Path)
 			}()
-			auth := repo.getAuth()
+			auth := repo.getAuth("origin")
 			if tt.wantAuth {
 				assert.NotNil(t, auth)
 				if basicAuth, ok := auth.(*githttp.BasicAuth); ok {
@@ -519,7 +541,7 @@ func TestRepository_Update_Errors(t *testing.T) {
 			repo, cleanup := tt.setup(t)
 			defer cleanup()
 
-			err := repo.Update()
+			err := repo.Update(appconfig.RepositorySettings{})
 			if tt.expectError != "" {
 				assert.ErrorContains(t, err, tt.expectError)
 			} else {
@@ -573,118 +595,13 @@ func TestGetCacheFile(t *testing.T) {
 	// Verify directory was created
 	_, err = os.Stat(filepath.Dir(cacheFile))
 	assert.NoError(t, err)
-}
-
-func TestSaveRepositories(t *testing.T) {
-	// Create temporary directory for test
-	tmpDir, err := os.MkdirTemp("", "gogitup-test-save-*")
-	require.NoError(t, err)
-	defer func() {
-		if err := os.RemoveAll(tmpDir); err != nil {
-			t.Errorf("Failed to remove temp directory: %v", err)
-		}
-	}()
-
-	reposFile := filepath.Join(tmpDir, "repos.json")
-	viper.Set("repos-file", reposFile)
-	defer viper.Reset()
-
-	// Test saving empty list
-	err = SaveRepositories([]Repository{})
-	require.NoError(t, err)
-	assertFileExists(t, reposFile)
-
-	// Test saving with repositories
-	repos := []Repository{
-		{Path: "/path/to/repo1", HasUpstream: true},
-		{Path: "/path/to/repo2", HasUpstream: false},
-	}
-	err = SaveRepositories(repos)
-	require.NoError(t, err)
-
-	// Verify file contents
-	data, err := os.ReadFile(reposFile)
-	require.NoError(t, err)
-
-	var savedRepos []Repository
-	err = json.Unmarshal(data, &savedRepos)
-	require.NoError(t, err)
-
-	assert.Equal(t, 2, len(savedRepos))
-	assert.Equal(t, "/path/to/repo1", savedRepos[0].Path)
-	assert.Equal(t, true, savedRepos[0].HasUpstream)
-	assert.Equal(t, "/path/to/repo2", savedRepos[1].Path)
-	assert.Equal(t, false, savedRepos[1].HasUpstream)
-	assert.False(t, savedRepos[0].LastScanned.IsZero())
-	assert.False(t, savedRepos[1].LastScanned.IsZero())
-
-	// Test saving to invalid path
-	viper.Set("repos-file", "/invalid/path/repos.json")
-	err = SaveRepositories(repos)
-	assert.Error(t, err)
-}
-
-func TestLoadRepositories(t *testing.T) {
-	// Create temporary directory for test
-	tmpDir, err := os.MkdirTemp("", "gogitup-test-load-*")
-	require.NoError(t, err)
-	defer func() {
-		if err := os.RemoveAll(tmpDir); err != nil {
-			t.Errorf("Failed to remove temp directory: %v", err)
-		}
-	}()
-
-	reposFile := filepath.Join(tmpDir, "repos.json")
-	viper.Set("repos-file", reposFile)
-	defer viper.Reset()
-
-	// Test loading non-existent file
-	repos, err := LoadRepositories()
-	require.NoError(t, err)
-	assert.Empty(t, repos)
 
-	// Create test repository
-	repoDir, cleanup := setupTestRepo(t)
-	defer cleanup()
-
-	// Save test repository
-	testRepos := []Repository{
-		{Path: repoDir, HasUpstream: false, LastScanned: time.Now()},
-	}
-	data, err := json.MarshalIndent(testRepos, "", "  ")
-	require.NoError(t, err)
-	err = os.WriteFile(reposFile, data, 0644)
-	require.NoError(t, err)
-
-	// Test loading valid repository
-	repos, err = LoadRepositories()
+	// Every profile has a directory of its own
+	profileFile, err := ProfileCacheFile("work")
 	require.NoError(t, err)
-	assert.Equal(t, 1, len(repos))
-	assert.Equal(t, repoDir, repos[0].Path)
-	assert.NotNil(t, repos[0].repo)
-
-	// Test loading invalid repository path
-	invalidPath := filepath.Join(tmpDir, "invalid")
-	testRepos = []Repository{
-		{Path: invalidPath, HasUpstream: false, LastScanned: time.Now()},
-	}
-	data, err = json.MarshalIndent(testRepos, "", "  ")
-	require.NoError(t, err)
-	err = os.WriteFile(reposFile, data, 0644)
-	require.NoError(t, err)
-
-	// LoadRepositories should skip invalid repositories
-	repos, err = LoadRepositories()
-	require.NoError(t, err)
-	assert.Empty(t, repos)
-
-	// Test loading invalid JSON
-	err = os.WriteFile(reposFile, []byte("invalid json"), 0644)
-	require.NoError(t, err)
-
-	repos, err = LoadRepositories()
-	assert.Error(t, err)
-	assert.Nil(t, repos)
+	assert.Equal(t, filepath.Join(filepath.Dir(expected), "profiles", "work", "repositories.json"), profileFile)
+	_, err = os.Stat(filepath.Dir(profileFile))
+	assert.NoError(t, err)
 }
 
 func TestFindRepositories_EdgeCases(t *testing.T) {
@@ -789,6 +706,9 @@ func TestRepository_isLFSRepository(t *testing.T) {
 	tests := []struct {
 		name          string
 		gitattributes string
+		files         map[string]string
+		track         []string
+		lfsConfig     bool
 		expectUsesLFS bool
 	}{
 		{
@@ -815,6 +735,32 @@ func TestRepository_isLFSRepository(t *testing.T) {
 			gitattributes: "*.data filter=lfs\n",
 			expectUsesLFS: true,
 		},
+		{
+			name:          "tracked nested gitattributes with LFS",
+			files:         map[string]string{"assets/.gitattributes": "*.psd filter=lfs diff=lfs merge=lfs -text\n"},
+			track:         []string{"assets/.gitattributes"},
+			expectUsesLFS: true,
+		},
+		{
+			name:          "untracked nested gitattributes with LFS",
+			files:         map[string]string{"assets/.gitattributes": "*.psd filter=lfs diff=lfs merge=lfs -text\n"},
+			expectUsesLFS: false,
+		},
+		{
+			name:          "info attributes with LFS",
+			files:         map[string]string{".git/info/attributes": "*.iso filter=lfs\n"},
+			expectUsesLFS: true,
+		},
+		{
+			name:          "lfsconfig file",
+			files:         map[string]string{".lfsconfig": "[lfs]\n\turl = https://lfs.example.com\n"},
+			expectUsesLFS: true,
+		},
+		{
+			name:          "lfs section in repository config",
+			lfsConfig:     true,
+			expectUsesLFS: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -837,6 +783,22 @@ func TestRepository_isLFSRepository(t *testing.T) {
 				err = os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(tt.gitattributes), 0644)
 				require.NoError(t, err)
 			}
+			for name, content := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(name))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+			}
+			if len(tt.track) > 0 {
+				w, err := repo.Worktree()
+				require.NoError(t, err)
+				for _, name := range tt.track {
+					_, err = w.Add(name)
+					require.NoError(t, err)
+				}
+			}
+			if tt.lfsConfig {
+				runGit(t, dir, "config", "lfs.repositoryformatversion", "0")
+			}
 
 			// Create repository instance
 			r := &Repository{
@@ -1287,7 +1249,7 @@ func TestRepository_UpdateLFS(t *testing.T) {
 			}
 
 			// Update repository
-			err = r.Update()
+			err = r.Update(appconfig.RepositorySettings{})
 			if tt.expectError {
 				assert.Error(t, err)
 				assert.Equal(t, ErrUncommittedChanges, err)
@@ -1302,3 +1264,336 @@ func TestRepository_UpdateLFS(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeRepositories(t *testing.T) {
+	previous := []Repository{
+		{Path: "/repo/kept", Tags: []string{"work"}, LastOutcome: OutcomeFailed, LastError: "boom", Behind: 3, Outcomes: map[string]int{OutcomeFailed: 2}},
+		{Path: "/repo/removed", Tags: []string{"old"}},
+	}
+	found := []Repository{
+		{Path: "/repo/kept", HasUpstream: true},
+		{Path: "/repo/new"},
+	}
+
+	merged := MergeRepositories(previous, found)
+	require.Len(t, merged, 2)
+	assert.Equal(t, []string{"work"}, merged[0].Tags)
+	assert.True(t, merged[0].HasUpstream)
+	assert.Equal(t, OutcomeFailed, merged[0].LastOutcome)
+	assert.Equal(t, "boom", merged[0].LastError)
+	assert.Equal(t, 3, merged[0].Behind)
+	assert.Equal(t, map[string]int{OutcomeFailed: 2}, merged[0].Outcomes)
+	assert.Empty(t, merged[1].Tags)
+}
+
+func TestMergeRepositories_Manual(t *testing.T) {
+	previous := []Repository{
+		{Path: "/repo/scanned", Manual: true},
+		{Path: "/elsewhere/checkout", Manual: true, Tags: []string{"tools"}},
+		{Path: "/repo/deleted"},
+	}
+	found := []Repository{{Path: "/repo/scanned"}}
+
+	merged := MergeRepositories(previous, found)
+	require.Len(t, merged, 2)
+	assert.Equal(t, "/repo/scanned", merged[0].Path)
+	assert.True(t, merged[0].Manual)
+	assert.Equal(t, "/elsewhere/checkout", merged[1].Path)
+	assert.Equal(t, []string{"tools"}, merged[1].Tags)
+}
+
+func TestRepository_RecordOutcome(t *testing.T) {
+	r := &Repository{Path: "/repo"}
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	r.RecordOutcome(OutcomeUpdated, nil, updated)
+	assert.Equal(t, OutcomeUpdated, r.LastOutcome)
+	assert.Equal(t, updated, r.LastUpdated)
+	assert.Empty(t, r.LastError)
+
+	r.RecordOutcome(OutcomeFailed, fmt.Errorf("fetch failed"), updated.Add(time.Hour))
+	assert.Equal(t, OutcomeFailed, r.LastOutcome)
+	assert.Equal(t, "fetch failed", r.LastError)
+	assert.Equal(t, updated, r.LastUpdated)
+
+	r.RecordOutcome(OutcomeFailed, fmt.Errorf("fetch failed"), updated.Add(2*time.Hour))
+	assert.Equal(t, map[string]int{OutcomeUpdated: 1, OutcomeFailed: 2}, r.Outcomes)
+}
+
+func TestRepository_Tags(t *testing.T) {
+	r := &Repository{Path: "/repo"}
+
+	r.AddTags("work", "backend", "work", "")
+	assert.Equal(t, []string{"backend", "work"}, r.Tags)
+	assert.True(t, r.HasTag("work"))
+	assert.False(t, r.HasTag("ops"))
+
+	r.RemoveTags("work", "ops")
+	assert.Equal(t, []string{"backend"}, r.Tags)
+
+	r.RemoveTags("backend")
+	assert.Nil(t, r.Tags)
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), string(out))
+	return strings.TrimSpace(string(out))
+}
+
+func TestRepository_Update_Settings(t *testing.T) {
+	trueVal := true
+	falseVal := false
+
+	tests := []struct {
+		name        string
+		settings    func(t *testing.T, dir string) appconfig.RepositorySettings
+		hasUpstream bool
+		prepare     func(t *testing.T, dir string)
+		wantErr     string
+		wantErrIs   error
+		check       func(t *testing.T, dir, originDir string)
+	}{
+		{
+			name: "skip",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{Skip: &trueVal}
+			},
+			hasUpstream: true,
+			wantErrIs:   ErrSkipped,
+			check: func(t *testing.T, dir, originDir string) {
+				_, err := os.Stat(filepath.Join(dir, "upstream.txt"))
+				assert.True(t, os.IsNotExist(err))
+			},
+		},
+		{
+			name: "pin fetches without moving HEAD",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{Pin: &trueVal}
+			},
+			hasUpstream: true,
+			check: func(t *testing.T, dir, originDir string) {
+				_, err := os.Stat(filepath.Join(dir, "upstream.txt"))
+				assert.True(t, os.IsNotExist(err))
+				assert.NotEqual(t, runGit(t, dir, "rev-parse", "HEAD"), runGit(t, dir, "rev-parse", "upstream/master"))
+			},
+		},
+		{
+			name: "no push to origin",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{Push: &falseVal}
+			},
+			hasUpstream: true,
+			check: func(t *testing.T, dir, originDir string) {
+				assertFileExists(t, filepath.Join(dir, "upstream.txt"))
+				assert.NotEqual(t, runGit(t, dir, "rev-parse", "HEAD"), runGit(t, originDir, "rev-parse", "master"))
+			},
+		},
+		{
+			name: "custom upstream remote name",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				runGit(t, dir, "remote", "rename", "upstream", "source")
+				return appconfig.RepositorySettings{Upstream: "source"}
+			},
+			check: func(t *testing.T, dir, originDir string) {
+				assertFileExists(t, filepath.Join(dir, "upstream.txt"))
+				assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD"), runGit(t, originDir, "rev-parse", "master"))
+			},
+		},
+		{
+			name: "rebase strategy with local commits",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{Strategy: appconfig.StrategyRebase}
+			},
+			hasUpstream: true,
+			prepare: func(t *testing.T, dir string) {
+				runGit(t, dir, "config", "user.name", "Test User")
+				runGit(t, dir, "config", "user.email", "test@example.com")
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("local"), 0644))
+				runGit(t, dir, "add", "local.txt")
+				runGit(t, dir, "commit", "-m", "Local commit")
+			},
+			check: func(t *testing.T, dir, originDir string) {
+				assertFileExists(t, filepath.Join(dir, "upstream.txt"))
+				assertFileExists(t, filepath.Join(dir, "local.txt"))
+				assert.Equal(t, "Local commit", runGit(t, dir, "log", "-1", "--format=%s"))
+			},
+		},
+		{
+			name: "fast-forward only with local commits",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{}
+			},
+			hasUpstream: true,
+			prepare: func(t *testing.T, dir string) {
+				runGit(t, dir, "config", "user.name", "Test User")
+				runGit(t, dir, "config", "user.email", "test@example.com")
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "local.txt"), []byte("local"), 0644))
+				runGit(t, dir, "add", "local.txt")
+				runGit(t, dir, "commit", "-m", "Local commit")
+			},
+			wantErr: "local branch has diverged from upstream",
+		},
+		{
+			name: "hooks",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{
+					Hooks: appconfig.Hooks{
+						PreUpdate:  []string{"echo pre > ../" + filepath.Base(dir) + "-pre"},
+						PostUpdate: []string{"echo $GOGITUP_OLD_HEAD $GOGITUP_NEW_HEAD > ../" + filepath.Base(dir) + "-post"},
+					},
+				}
+			},
+			hasUpstream: true,
+			check: func(t *testing.T, dir, originDir string) {
+				defer func() {
+					_ = os.Remove(dir + "-pre")
+					_ = os.Remove(dir + "-post")
+				}()
+				assertFileExists(t, dir+"-pre")
+				data, err := os.ReadFile(dir + "-post")
+				require.NoError(t, err)
+				heads := strings.Fields(string(data))
+				require.Len(t, heads, 2)
+				assert.NotEqual(t, heads[0], heads[1])
+				assert.Equal(t, runGit(t, dir, "rev-parse", "HEAD"), heads[1])
+			},
+		},
+		{
+			name: "failing pre-update hook aborts update",
+			settings: func(t *testing.T, dir string) appconfig.RepositorySettings {
+				return appconfig.RepositorySettings{
+					Hooks: appconfig.Hooks{PreUpdate: []string{"exit 1"}},
+				}
+			},
+			hasUpstream: true,
+			wantErr:     "pre-update hook failed",
+			check: func(t *testing.T, dir, originDir string) {
+				_, err := os.Stat(filepath.Join(dir, "upstream.txt"))
+				assert.True(t, os.IsNotExist(err))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" {
+				t.Skip("hooks in this test require a POSIX shell")
+			}
+
+			dir, originDir, _, cleanup := setupTestRepoWithRemotes(t)
+			defer cleanup()
+
+			if tt.prepare != nil {
+				tt.prepare(t, dir)
+			}
+			settings := tt.settings(t, dir)
+
+			repo, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			r := &Repository{Path: dir, repo: repo, HasUpstream: tt.hasUpstream}
+
+			err = r.Update(settings)
+			switch {
+			case tt.wantErrIs != nil:
+				assert.ErrorIs(t, err, tt.wantErrIs)
+			case tt.wantErr != "":
+				assert.ErrorContains(t, err, tt.wantErr)
+			default:
+				assert.NoError(t, err)
+			}
+
+			if tt.check != nil {
+				tt.check(t, dir, originDir)
+			}
+		})
+	}
+}
+
+func TestRepository_OnPhase(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	pushToOrigin(t, dir, "incoming.txt", "Remote change")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+
+	var phases []string
+	r.OnPhase(func(phase string) { phases = append(phases, phase) })
+	require.NoError(t, r.Update(appconfig.RepositorySettings{
+		Hooks: appconfig.Hooks{PostUpdate: []string{"true"}},
+	}))
+	assert.Equal(t, []string{PhaseChecking, PhaseFetching, PhaseMerging, PhaseHooks}, phases)
+}
+
+func TestRepository_UpdateLogging(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(previous)
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+
+	require.Error(t, r.Update(appconfig.RepositorySettings{
+		Hooks: appconfig.Hooks{PreUpdate: []string{"exit 1"}},
+	}))
+
+	var failed map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		assert.Equal(t, dir, record["path"])
+		if record["msg"] == "repository update failed" {
+			failed = record
+		}
+	}
+	require.NotNil(t, failed)
+	assert.Equal(t, "WARN", failed["level"])
+	assert.Equal(t, PhaseHooks, failed["phase"])
+	assert.Contains(t, failed["error"], "pre-update hook failed")
+	assert.Contains(t, failed, "duration")
+}
+
+func TestLogArgs(t *testing.T) {
+	args := append(credentialArgs("", "user", "secret"), "fetch", "origin")
+	logged := logArgs(args)
+	assert.Equal(t, []string{"-c", "credential.helper=<redacted>", "fetch", "origin"}, logged)
+	assert.NotContains(t, strings.Join(logged, " "), "secret")
+	assert.Contains(t, args[1], "secret", "the arguments themselves are unchanged")
+
+	args = append(credentialArgs(githubCredentialURL, "user", "secret"), "fetch", "origin")
+	logged = logArgs(args)
+	assert.Equal(t, []string{"-c", "credential.https://github.com.helper=<redacted>", "fetch", "origin"}, logged)
+}
+
+func TestRepository_AuthArgs(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("OTHER_TOKEN", "")
+	r := &Repository{}
+	assert.Empty(t, r.authArgs())
+
+	// GITHUB_TOKEN is only offered to github.com, whichever remote is
+	// contacted
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	args := r.authArgs()
+	require.Len(t, args, 2)
+	assert.True(t, strings.HasPrefix(args[1], "credential.https://github.com.helper="), args[1])
+	assert.Contains(t, args[1], "gh-token")
+
+	// A configured token applies to every remote
+	t.Setenv("OTHER_TOKEN", "other-token")
+	r.Configure(appconfig.RepositorySettings{Credentials: appconfig.Credentials{TokenEnv: "OTHER_TOKEN"}})
+	args = r.authArgs()
+	require.Len(t, args, 2)
+	assert.True(t, strings.HasPrefix(args[1], "credential.helper="), args[1])
+	assert.Contains(t, args[1], "other-token")
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+// stashRef returns the commit of the latest stash entry, or "" if there is none
+func (r *Repository) stashRef(ctx context.Context) string {
+	out, err := r.gitCommand(ctx, "rev-parse", "-q", "--verify", "refs/stash").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// UpdateWithStash stashes the local changes to tracked files, updates the
+// repository and restores the changes. If they no longer apply cleanly the
+// stash is kept and an error tells the user how to recover it.
+func (r *Repository) UpdateWithStash(ctx context.Context, settings appconfig.RepositorySettings) error {
+	before := r.stashRef(ctx)
+	if out, err := r.gitCommand(ctx, "stash", "push", "-m", "gogitup: stash before update").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stash changes: %s: %w", string(out), err)
+	}
+	stashed := r.stashRef(ctx) != before
+
+	updateErr := r.UpdateContext(ctx, settings)
+
+	if stashed {
+		if out, err := r.gitCommand(ctx, "stash", "pop").CombinedOutput(); err != nil {
+			return fmt.Errorf("the stashed changes could not be restored, they are kept in 'git stash list': %s: %w", strings.TrimSpace(string(out)), err)
+		}
+	}
+	return updateErr
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+func TestRepository_UpdateWithStash(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteFile string
+		localEdit  bool
+		wantErr    string
+		wantStash  bool
+	}{
+		{
+			name:       "restores local changes",
+			remoteFile: "incoming.txt",
+			localEdit:  true,
+		},
+		{
+			name:       "without local changes",
+			remoteFile: "incoming.txt",
+		},
+		{
+			name:       "keeps conflicting changes stashed",
+			remoteFile: "test.txt",
+			localEdit:  true,
+			wantErr:    "kept in 'git stash list'",
+			wantStash:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, cleanup := setupTestRepo(t)
+			defer cleanup()
+
+			pushToOrigin(t, dir, tt.remoteFile, "Remote change")
+			if tt.localEdit {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte("local change"), 0644))
+			}
+
+			repo, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			r := &Repository{Path: dir, repo: repo}
+
+			err = r.UpdateWithStash(context.Background(), appconfig.RepositorySettings{})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assertFileExists(t, filepath.Join(dir, "incoming.txt"))
+			}
+
+			stashes := runGit(t, dir, "stash", "list")
+			if tt.wantStash {
+				assert.Contains(t, stashes, "gogitup: stash before update")
+				return
+			}
+			assert.Empty(t, stashes)
+
+			content, err := os.ReadFile(filepath.Join(dir, "test.txt"))
+			require.NoError(t, err)
+			if tt.localEdit {
+				assert.Equal(t, "local change", string(content))
+			} else {
+				assert.Equal(t, "test content", string(content))
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+// GetCacheFile returns the default path to the cache file
+func GetCacheFile() (string, error) {
+	return ProfileCacheFile("")
+}
+
+// ProfileCacheFile returns the default path to the cache file of a config
+// profile, the default cache file for the empty profile. Every profile gets
+// a directory of its own, so that the files kept next to the cache such as
+// the daemon socket are not shared.
+func ProfileCacheFile(profile string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	// Create gogitup cache directory if it doesn't exist
+	gogitupCache := filepath.Join(cacheDir, "gogitup")
+	if profile != "" {
+		gogitupCache = filepath.Join(gogitupCache, "profiles", profile)
+	}
+	if err := os.MkdirAll(gogitupCache, 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return filepath.Join(gogitupCache, "repositories.json"), nil
+}
+
+// Store is the repository list file, the cache of the scanned repositories
+type Store struct {
+	path string
+}
+
+// NewStore returns the store kept in the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path returns the path of the repository list file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// IgnoreFile returns the path of the file holding the ignore patterns, kept
+// next to the repository list, e.g. repositories.ignore.json
+func (s *Store) IgnoreFile() string {
+	return strings.TrimSuffix(s.path, filepath.Ext(s.path)) + ".ignore.json"
+}
+
+// lockTimeout bounds how long a change to the store waits for another
+// process to finish its own
+const lockTimeout = 10 * time.Second
+
+// staleLock is the age after which a lock file is taken to be left behind by
+// a process that died while holding it
+const staleLock = time.Minute
+
+// lock takes the lock file next to the repository list, which serializes the
+// changes of processes sharing the store, such as the daemon, the watcher and
+// the update command. The returned function releases it.
+func (s *Store) lock() (func(), error) {
+	path := s.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for repos file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock repos file: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock repos file: %s is held by another process", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Save writes the repository list to the store. The ignore patterns already
+// in the store are kept, and the repositories matching them are left out.
+// LastScanned is saved as is, only scans move it.
+func (s *Store) Save(repositories []Repository) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.save(repositories)
+}
+
+// save is Save for callers holding the lock
+func (s *Store) save(repositories []Repository) error {
+	patterns, err := s.Ignored()
+	if err != nil {
+		return err
+	}
+	return s.write(removeIgnored(repositories, patterns))
+}
+
+// write writes the repositories to the repository list file
+func (s *Store) write(repositories []Repository) error {
+	if repositories == nil {
+		repositories = []Repository{}
+	}
+	return writeJSON(s.path, repositories, "repos file")
+}
+
+// writeJSON writes v as indented JSON to the file at path, creating its
+// directory. The file is replaced atomically, so readers never see a partial
+// one. What names the file in errors.
+func writeJSON(path string, v any, what string) error {
+	// Create directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", what, err)
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", what, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+
+	return nil
+}
+
+// readJSON reads the JSON file at path into v, leaving v alone when the file
+// does not exist. What names the file in errors.
+func readJSON(path string, v any, what string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", what, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", what, err)
+	}
+	return nil
+}
+
+// read returns every entry of the repository list. A missing file is an
+// empty list.
+func (s *Store) read() ([]Repository, error) {
+	var entries []Repository
+	if err := readJSON(s.path, &entries, "repos file"); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Load reads the repository list from the store, leaving out the
+// repositories that can no longer be opened. A missing file is an empty list.
+func (s *Store) Load() ([]Repository, error) {
+	repositories, _, err := s.Inspect()
+	return repositories, err
+}
+
+// Inspect reads the repository list from the store like Load, also
+// returning the entries that cannot be opened, e.g. because they were
+// deleted or have remotes go-git cannot parse
+func (s *Store) Inspect() ([]Repository, []Repository, error) {
+	entries, err := s.read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Set aside repositories that can't be opened
+	validRepos := make([]Repository, 0, len(entries))
+	var brokenRepos []Repository
+	for i := range entries {
+		repo, err := git.PlainOpen(entries[i].Path)
+		if err != nil {
+			brokenRepos = append(brokenRepos, entries[i])
+			continue
+		}
+		entries[i].repo = repo
+		validRepos = append(validRepos, entries[i])
+	}
+
+	return validRepos, brokenRepos, nil
+}
+
+// Update passes every entry of the repository list to fn, including the ones
+// that cannot be opened, and saves the entries it returns like Save does.
+// Entries fn leaves alone are kept as they are, so repositories that are
+// missing for a while are not dropped by recording an update. The store is
+// locked while fn runs, so changes made by other processes in the meantime
+// are not lost.
+func (s *Store) Update(fn func(entries []Repository) ([]Repository, error)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	entries, err = fn(entries)
+	if err != nil {
+		return err
+	}
+	return s.save(entries)
+}
+
+// LastScan returns when a scan last found a repository of the store, the
+// zero time when none was scanned
+func (s *Store) LastScan() (time.Time, error) {
+	repositories, err := s.read()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var last time.Time
+	for _, repo := range repositories {
+		if repo.LastScanned.After(last) {
+			last = repo.LastScanned
+		}
+	}
+	return last, nil
+}
+
+// Ignored returns the ignore patterns of the store. A missing file has none.
+func (s *Store) Ignored() ([]string, error) {
+	var patterns []string
+	if err := readJSON(s.IgnoreFile(), &patterns, "ignore file"); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// RemoveIgnored returns the repositories that match none of the store's
+// ignore patterns
+func (s *Store) RemoveIgnored(repositories []Repository) ([]Repository, error) {
+	patterns, err := s.Ignored()
+	if err != nil {
+		return nil, err
+	}
+	return removeIgnored(repositories, patterns), nil
+}
+
+// Ignore adds ignore patterns to the store and removes the repositories
+// matching them. It returns the number of repositories removed.
+func (s *Store) Ignore(patterns ...string) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	repositories, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+	ignored, err := s.Ignored()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, pattern := range patterns {
+		if !slices.Contains(ignored, pattern) {
+			ignored = append(ignored, pattern)
+		}
+	}
+	if err := writeJSON(s.IgnoreFile(), ignored, "ignore file"); err != nil {
+		return 0, err
+	}
+
+	kept := removeIgnored(repositories, ignored)
+	if len(kept) == len(repositories) {
+		return 0, nil
+	}
+	if err := s.write(kept); err != nil {
+		return 0, err
+	}
+	return len(repositories) - len(kept), nil
+}
+
+// Unignore removes ignore patterns from the store. The repositories they
+// matched are found again by the next scan.
+func (s *Store) Unignore(patterns ...string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ignored, err := s.Ignored()
+	if err != nil {
+		return err
+	}
+
+	for _, pattern := range patterns {
+		i := slices.Index(ignored, pattern)
+		if i < 0 {
+			return fmt.Errorf("%s is not ignored", pattern)
+		}
+		ignored = slices.Delete(ignored, i, i+1)
+	}
+
+	return writeJSON(s.IgnoreFile(), ignored, "ignore file")
+}
+
+// IsIgnored reports whether an ignore pattern matches the repository at
+// path. A pattern is the path of a repository or of a directory whose
+// repositories are all ignored, or a glob as in the repository settings.
+func IsIgnored(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if appconfig.IsWithin(path, pattern) {
+			return true
+		}
+		if appconfig.MatchPath(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeIgnored returns the repositories no pattern matches
+func removeIgnored(repositories []Repository, patterns []string) []Repository {
+	if len(patterns) == 0 {
+		return repositories
+	}
+	kept := make([]Repository, 0, len(repositories))
+	for _, repo := range repositories {
+		if !IsIgnored(repo.Path, patterns) {
+			kept = append(kept, repo)
+		}
+	}
+	return kept
+}
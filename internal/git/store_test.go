@@ -0,0 +1,293 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Save(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-save-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	reposFile := filepath.Join(tmpDir, "repos.json")
+	store := NewStore(reposFile)
+
+	// Test saving empty list
+	err = store.Save([]Repository{})
+	require.NoError(t, err)
+	assertFileExists(t, reposFile)
+
+	// Test saving with repositories
+	repos := []Repository{
+		{Path: "/path/to/repo1", HasUpstream: true},
+		{Path: "/path/to/repo2", HasUpstream: false},
+	}
+	err = store.Save(repos)
+	require.NoError(t, err)
+
+	// Verify file contents
+	data, err := os.ReadFile(reposFile)
+	require.NoError(t, err)
+
+	var savedRepos []Repository
+	err = json.Unmarshal(data, &savedRepos)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, len(savedRepos))
+	assert.Equal(t, "/path/to/repo1", savedRepos[0].Path)
+	assert.Equal(t, true, savedRepos[0].HasUpstream)
+	assert.Equal(t, "/path/to/repo2", savedRepos[1].Path)
+	assert.Equal(t, false, savedRepos[1].HasUpstream)
+	assert.True(t, savedRepos[0].LastScanned.IsZero(), "saving must not move the scan time")
+	assert.True(t, savedRepos[1].LastScanned.IsZero(), "saving must not move the scan time")
+
+	// Test saving to invalid path
+	err = NewStore("/invalid/path/repos.json").Save(repos)
+	assert.Error(t, err)
+}
+
+func TestStore_LastScan(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "repositories.json"))
+
+	last, err := store.LastScan()
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+
+	scanned := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, store.Save([]Repository{
+		{Path: "/path/to/repo1", LastScanned: scanned.Add(-time.Hour)},
+		{Path: "/path/to/repo2", LastScanned: scanned},
+		{Path: "/path/to/manual", Manual: true},
+	}))
+	last, err = store.LastScan()
+	require.NoError(t, err)
+	assert.True(t, last.Equal(scanned), last)
+}
+
+func TestStore_Load(t *testing.T) {
+	// Create temporary directory for test
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-load-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	reposFile := filepath.Join(tmpDir, "repos.json")
+	store := NewStore(reposFile)
+
+	// Test loading non-existent file
+	repos, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, repos)
+
+	// Create test repository
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	// Save test repository
+	testRepos := []Repository{
+		{Path: repoDir, HasUpstream: false, LastScanned: time.Now()},
+	}
+	data, err := json.MarshalIndent(testRepos, "", "  ")
+	require.NoError(t, err)
+	err = os.WriteFile(reposFile, data, 0644)
+	require.NoError(t, err)
+
+	// Test loading valid repository
+	repos, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(repos))
+	assert.Equal(t, repoDir, repos[0].Path)
+	assert.NotNil(t, repos[0].repo)
+
+	// Test loading invalid repository path
+	invalidPath := filepath.Join(tmpDir, "invalid")
+	testRepos = []Repository{
+		{Path: invalidPath, HasUpstream: false, LastScanned: time.Now()},
+	}
+	data, err = json.MarshalIndent(testRepos, "", "  ")
+	require.NoError(t, err)
+	err = os.WriteFile(reposFile, data, 0644)
+	require.NoError(t, err)
+
+	// Load should skip invalid repositories
+	repos, err = store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, repos)
+
+	// Test loading invalid JSON
+	err = os.WriteFile(reposFile, []byte("invalid json"), 0644)
+	require.NoError(t, err)
+
+	repos, err = store.Load()
+	assert.Error(t, err)
+	assert.Nil(t, repos)
+}
+
+func TestStore_Update(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := NewStore(filepath.Join(tmpDir, "repos.json"))
+	repoDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	missing := filepath.Join(tmpDir, "missing")
+	require.NoError(t, store.Save([]Repository{{Path: repoDir}, {Path: missing, Manual: true}}))
+
+	// Entries that cannot be opened are passed on and kept
+	err := store.Update(func(entries []Repository) ([]Repository, error) {
+		require.Len(t, entries, 2)
+		entries[0].AddTags("work")
+		return entries, nil
+	})
+	require.NoError(t, err)
+	repos, broken, err := store.Inspect()
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, []string{"work"}, repos[0].Tags)
+	require.Len(t, broken, 1)
+	assert.Equal(t, missing, broken[0].Path)
+
+	// An error leaves the list alone
+	err = store.Update(func(entries []Repository) ([]Repository, error) {
+		return nil, errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	repos, broken, err = store.Inspect()
+	require.NoError(t, err)
+	assert.Len(t, repos, 1)
+	assert.Len(t, broken, 1)
+}
+
+func TestStore_Update_Concurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	reposFile := filepath.Join(tmpDir, "repos.json")
+
+	// Every change survives, the lock keeps them from overwriting each other
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := NewStore(reposFile).Update(func(entries []Repository) ([]Repository, error) {
+				return append(entries, Repository{Path: fmt.Sprintf("/repo%d", i)}), nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	entries, err := NewStore(reposFile).read()
+	require.NoError(t, err)
+	assert.Len(t, entries, 20)
+	files, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "the lock and temporary files are removed")
+	assert.Equal(t, "repos.json", files[0].Name())
+
+	// A lock left behind by a process that died is taken over
+	lockFile := reposFile + ".lock"
+	require.NoError(t, os.WriteFile(lockFile, nil, 0644))
+	old := time.Now().Add(-2 * staleLock)
+	require.NoError(t, os.Chtimes(lockFile, old, old))
+	require.NoError(t, NewStore(reposFile).Save(nil))
+	assert.NoFileExists(t, lockFile)
+}
+
+func TestStore_Ignore(t *testing.T) {
+	first, cleanupFirst := setupTestRepo(t)
+	defer cleanupFirst()
+	second, cleanupSecond := setupTestRepo(t)
+	defer cleanupSecond()
+
+	store := NewStore(filepath.Join(t.TempDir(), "repos.json"))
+	require.NoError(t, store.Save([]Repository{{Path: first}, {Path: second}}))
+
+	removed, err := store.Ignore(first)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	patterns, err := store.Ignored()
+	require.NoError(t, err)
+	assert.Equal(t, []string{first}, patterns)
+	assert.Equal(t, filepath.Join(filepath.Dir(store.Path()), "repos.ignore.json"), store.IgnoreFile())
+
+	repos, err := store.Load()
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	assert.Equal(t, second, repos[0].Path)
+
+	// The repository list holds nothing but repositories
+	data, err := os.ReadFile(store.Path())
+	require.NoError(t, err)
+	var entries []Repository
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, second, entries[0].Path)
+
+	// Saving keeps the patterns and leaves out what they match
+	require.NoError(t, store.Save([]Repository{{Path: first}, {Path: second}}))
+	repos, err = store.Load()
+	require.NoError(t, err)
+	assert.Len(t, repos, 1)
+	patterns, err = store.Ignored()
+	require.NoError(t, err)
+	assert.Equal(t, []string{first}, patterns)
+
+	filtered, err := store.RemoveIgnored([]Repository{{Path: first}, {Path: second}})
+	require.NoError(t, err)
+	assert.Equal(t, []Repository{{Path: second}}, filtered)
+
+	require.NoError(t, store.Unignore(first))
+	patterns, err = store.Ignored()
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+	assert.EqualError(t, store.Unignore(first), first+" is not ignored")
+
+	// Patterns that cannot be read are not silently dropped
+	require.NoError(t, os.WriteFile(store.IgnoreFile(), []byte("invalid json"), 0644))
+	assert.ErrorContains(t, store.Save([]Repository{{Path: first}}), "failed to unmarshal ignore file")
+	_, err = store.Ignore(second)
+	assert.Error(t, err)
+	data, err = os.ReadFile(store.IgnoreFile())
+	require.NoError(t, err)
+	assert.Equal(t, "invalid json", string(data))
+}
+
+func TestIsIgnored(t *testing.T) {
+	patterns := []string{"/src/vendor", "/src/forks/*", "*-archive"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/src/vendor", true},
+		{"/src/vendor/lib", true},
+		{"/src/vendored", false},
+		{"/src/forks/app", true},
+		{"/src/forks/app/nested", false},
+		{"/src/old-archive", true},
+		{"/src/app", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsIgnored(tt.path, patterns))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/config"
+)
+
+// gitlinkMode is the tree entry mode of a submodule pointer
+const gitlinkMode = "160000"
+
+// hasSubmodules reports whether the repository declares submodules
+func (r *Repository) hasSubmodules() bool {
+	_, err := os.Stat(filepath.Join(r.Path, ".gitmodules"))
+	return err == nil
+}
+
+// submodulePaths returns the paths of the submodules declared in .gitmodules
+func (r *Repository) submodulePaths() map[string]bool {
+	paths := make(map[string]bool)
+
+	data, err := os.ReadFile(filepath.Join(r.Path, ".gitmodules"))
+	if err != nil {
+		return paths
+	}
+
+	modules := config.NewModules()
+	if err := modules.Unmarshal(data); err != nil {
+		return paths
+	}
+	for _, module := range modules.Submodules {
+		paths[filepath.ToSlash(filepath.Clean(module.Path))] = true
+	}
+	return paths
+}
+
+// updateSubmodules syncs submodule URLs and checks out the commits recorded
+// in the superproject, recursively
+func (r *Repository) updateSubmodules(ctx context.Context) error {
+	r.phase(PhaseSubmodules)
+	if err := r.runGitCommand(ctx, "submodule", "sync", "--recursive"); err != nil {
+		return fmt.Errorf("failed to sync submodules: %w", err)
+	}
+
+	args := []string{"submodule", "update", "--init", "--recursive"}
+	if depth := r.settings.Submodules.Depth; depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	if jobs := r.settings.Submodules.Jobs; jobs > 0 {
+		args = append(args, "--jobs", strconv.Itoa(jobs))
+	}
+	if err := r.runGitCommand(ctx, args...); err != nil {
+		return fmt.Errorf("failed to update submodules: %w", err)
+	}
+
+	return nil
+}
+
+// submoduleChanges describes the submodule pointers that moved between
+// oldHead and newHead, one line per submodule
+func (r *Repository) submoduleChanges(ctx context.Context, oldHead, newHead string) (string, error) {
+	out, err := r.gitCommand(ctx, "diff-tree", "-r", "--raw", "--no-renames", oldHead, newHead).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to diff submodules: %s: %w", string(out), err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// :<old mode> <new mode> <old sha> <new sha> <status>\t<path>
+		meta, path, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(strings.TrimPrefix(meta, ":"))
+		if !ok || len(fields) < 5 {
+			continue
+		}
+		if fields[0] != gitlinkMode && fields[1] != gitlinkMode {
+			continue
+		}
+
+		switch {
+		case fields[0] != gitlinkMode:
+			lines = append(lines, fmt.Sprintf(" submodule %s added at %s", path, shortHash(fields[3])))
+		case fields[1] != gitlinkMode:
+			lines = append(lines, fmt.Sprintf(" submodule %s removed", path))
+		default:
+			lines = append(lines, fmt.Sprintf(" submodule %s %s..%s", path, shortHash(fields[2]), shortHash(fields[3])))
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
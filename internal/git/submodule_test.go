@@ -0,0 +1,102 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+// setupSubmoduleRepo creates a superproject with a "lib" submodule and returns
+// a local clone with initialized submodules, the working copy used to publish
+// new superproject commits and the submodule source repository
+func setupSubmoduleRepo(t *testing.T) (string, string, string) {
+	t.Helper()
+
+	// Local file submodules are disabled by default since git 2.38
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	libDir := filepath.Join(root, "lib")
+	originDir := filepath.Join(root, "origin.git")
+	publishDir := filepath.Join(root, "publish")
+	localDir := filepath.Join(root, "local")
+
+	runGit(t, root, "init", "-q", "-b", "master", libDir)
+	commitFile(t, libDir, "lib.txt", "Library v1")
+
+	runGit(t, root, "init", "-q", "--bare", "-b", "master", originDir)
+	runGit(t, root, "clone", "-q", originDir, publishDir)
+	runGit(t, publishDir, "checkout", "-q", "-b", "master")
+	runGit(t, publishDir, "submodule", "add", "-q", libDir, "lib")
+	commitFile(t, publishDir, "main.txt", "Add lib submodule")
+	runGit(t, publishDir, "push", "-q", "-u", "origin", "master")
+
+	runGit(t, root, "clone", "-q", "--recurse-submodules", originDir, localDir)
+
+	return localDir, publishDir, libDir
+}
+
+// advanceSubmodule commits to the submodule source and records the new commit
+// in the superproject
+func advanceSubmodule(t *testing.T, publishDir, libDir string) string {
+	t.Helper()
+	commitFile(t, libDir, "lib.txt", "Library v2")
+	runGit(t, filepath.Join(publishDir, "lib"), "pull", "-q", "origin", "master")
+	runGit(t, publishDir, "add", "lib")
+	runGit(t, publishDir, "commit", "-q", "-m", "Bump lib")
+	runGit(t, publishDir, "push", "-q", "origin", "master")
+	return runGit(t, libDir, "rev-parse", "HEAD")
+}
+
+func TestRepository_Update_Submodules(t *testing.T) {
+	localDir, publishDir, libDir := setupSubmoduleRepo(t)
+	oldLib := runGit(t, filepath.Join(localDir, "lib"), "rev-parse", "HEAD")
+	newLib := advanceSubmodule(t, publishDir, libDir)
+
+	repo, err := OpenRepository(localDir)
+	require.NoError(t, err)
+
+	trueVal := true
+	err = repo.Update(appconfig.RepositorySettings{
+		Submodules: appconfig.Submodules{Update: &trueVal, Jobs: 2},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, newLib, runGit(t, filepath.Join(localDir, "lib"), "rev-parse", "HEAD"))
+	assert.Contains(t, repo.DiffStats, "submodule lib "+shortHash(oldLib)+".."+shortHash(newLib))
+	assert.Empty(t, runGit(t, localDir, "status", "--porcelain"))
+}
+
+func TestRepository_Update_SubmoduleDrift(t *testing.T) {
+	localDir, publishDir, libDir := setupSubmoduleRepo(t)
+	oldLib := runGit(t, filepath.Join(localDir, "lib"), "rev-parse", "HEAD")
+	advanceSubmodule(t, publishDir, libDir)
+
+	repo, err := OpenRepository(localDir)
+	require.NoError(t, err)
+
+	// Without submodule updates the checkout stays at the old gitlink
+	require.NoError(t, repo.Update(appconfig.RepositorySettings{}))
+	assert.Equal(t, oldLib, runGit(t, filepath.Join(localDir, "lib"), "rev-parse", "HEAD"))
+	assert.Contains(t, repo.DiffStats, "submodule lib")
+
+	// The drifted pointer is not an uncommitted change on the next run
+	commitFile(t, publishDir, "more.txt", "More work")
+	runGit(t, publishDir, "push", "-q", "origin", "master")
+	require.NoError(t, repo.Update(appconfig.RepositorySettings{}))
+	assertFileExists(t, filepath.Join(localDir, "more.txt"))
+
+	// Real changes inside the superproject are still detected
+	require.NoError(t, os.WriteFile(filepath.Join(localDir, "main.txt"), []byte("dirty"), 0644))
+	assert.ErrorIs(t, repo.Update(appconfig.RepositorySettings{}), ErrUncommittedChanges)
+}
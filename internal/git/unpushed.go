@@ -0,0 +1,197 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnpushedBranch is a local branch with commits its upstream lacks, or
+// without an upstream to push to
+type UnpushedBranch struct {
+	Name string
+	// Upstream is the branch it tracks, e.g. "origin/main", empty when none
+	// is set
+	Upstream string
+	// UpstreamGone is set when the upstream no longer exists
+	UpstreamGone bool
+	// Ahead counts the commits the upstream does not have
+	Ahead int
+}
+
+// Reason returns a human readable description of what is unpushed
+func (b UnpushedBranch) Reason() string {
+	switch {
+	case b.Upstream == "":
+		return "no upstream"
+	case b.UpstreamGone:
+		return fmt.Sprintf("upstream %s is gone", b.Upstream)
+	default:
+		return fmt.Sprintf("%d commits ahead of %s", b.Ahead, b.Upstream)
+	}
+}
+
+// Unpushed is the work of a repository that exists nowhere else and would
+// be lost with it
+type Unpushed struct {
+	Branches []UnpushedBranch
+	// Commits are the newest commits of the local branches, and of a
+	// detached HEAD, that no remote branch contains. CommitCount counts all
+	// of them.
+	Commits     []Commit
+	CommitCount int
+	// Stashes are the entries of the stash, e.g. "stash@{0}: WIP on main: ..."
+	Stashes   []string
+	Untracked []string
+	// Modified are the tracked files with uncommitted changes
+	Modified []string
+}
+
+// Empty reports whether nothing is unpushed
+func (u Unpushed) Empty() bool {
+	return len(u.Branches) == 0 && u.CommitCount == 0 && len(u.Stashes) == 0 &&
+		len(u.Untracked) == 0 && len(u.Modified) == 0
+}
+
+// String describes the unpushed work in a few words, e.g. "2 unpushed
+// commits, 1 untracked files"
+func (u Unpushed) String() string {
+	var parts []string
+	if u.CommitCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d unpushed commits", u.CommitCount))
+	}
+	// Branches ahead of their upstream are covered by the commits
+	untracked := 0
+	for _, b := range u.Branches {
+		if b.Upstream == "" || b.UpstreamGone {
+			untracked++
+		}
+	}
+	if untracked > 0 {
+		parts = append(parts, fmt.Sprintf("%d branches without upstream", untracked))
+	}
+	if len(u.Stashes) > 0 {
+		parts = append(parts, fmt.Sprintf("%d stashes", len(u.Stashes)))
+	}
+	if len(u.Untracked) > 0 {
+		parts = append(parts, fmt.Sprintf("%d untracked files", len(u.Untracked)))
+	}
+	if len(u.Modified) > 0 {
+		parts = append(parts, fmt.Sprintf("%d modified files", len(u.Modified)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Unpushed finds the work of the repository that exists nowhere else, as of
+// the last fetch. At most limit commits are listed, all of them if limit is 0.
+func (r *Repository) Unpushed(ctx context.Context, limit int) (Unpushed, error) {
+	var unpushed Unpushed
+	var err error
+
+	if unpushed.Branches, err = r.unpushedBranches(ctx); err != nil {
+		return unpushed, err
+	}
+	if unpushed.Commits, unpushed.CommitCount, err = r.unpushedCommits(ctx, limit); err != nil {
+		return unpushed, err
+	}
+
+	out, err := r.gitCommand(ctx, "stash", "list").CombinedOutput()
+	if err != nil {
+		return unpushed, fmt.Errorf("failed to list stashes: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	unpushed.Stashes = lines(string(out))
+
+	out, err = r.gitCommand(ctx, "status", "--porcelain").CombinedOutput()
+	if err != nil {
+		return unpushed, fmt.Errorf("failed to check for changes: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	for _, line := range lines(string(out)) {
+		if len(line) < 4 {
+			continue
+		}
+		// Lines are formatted as "XY path", untracked files as "?? path"
+		if strings.HasPrefix(line, "??") {
+			unpushed.Untracked = append(unpushed.Untracked, line[3:])
+		} else {
+			unpushed.Modified = append(unpushed.Modified, line[3:])
+		}
+	}
+
+	return unpushed, nil
+}
+
+// unpushedBranches returns the local branches without an upstream, with an
+// upstream that is gone, or ahead of their upstream
+func (r *Repository) unpushedBranches(ctx context.Context) ([]UnpushedBranch, error) {
+	out, err := r.gitCommand(ctx, "for-each-ref", "--format=%(refname:short)%1f%(upstream:short)%1f%(upstream:track,nobracket)", "refs/heads").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+
+	var branches []UnpushedBranch
+	for _, line := range lines(string(out)) {
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		branch := UnpushedBranch{Name: fields[0], Upstream: fields[1]}
+
+		// The tracking state is e.g. "ahead 2, behind 1" or "gone"
+		for _, state := range strings.Split(fields[2], ", ") {
+			if state == "gone" {
+				branch.UpstreamGone = true
+			} else if count, ok := strings.CutPrefix(state, "ahead "); ok {
+				branch.Ahead, _ = strconv.Atoi(count)
+			}
+		}
+
+		if branch.Upstream == "" || branch.UpstreamGone || branch.Ahead > 0 {
+			branches = append(branches, branch)
+		}
+	}
+	return branches, nil
+}
+
+// unpushedCommits returns the newest commits no remote branch contains and
+// how many there are
+func (r *Repository) unpushedCommits(ctx context.Context, limit int) ([]Commit, int, error) {
+	revs := []string{"--branches"}
+	// Commits made on a detached HEAD are on no branch
+	if err := r.gitCommand(ctx, "symbolic-ref", "-q", "HEAD").Run(); err != nil {
+		if _, err := r.revParse(ctx, "HEAD"); err == nil {
+			revs = append(revs, "HEAD")
+		}
+	}
+	revs = append(revs, "--not", "--remotes")
+
+	out, err := r.gitCommand(ctx, append([]string{"rev-list", "--count"}, revs...)...).CombinedOutput()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count unpushed commits: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+	if count == 0 {
+		return nil, 0, nil
+	}
+
+	args := []string{"log", "--format=" + commitFormat}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	out, err = r.gitCommand(ctx, append(append(args, revs...), "--")...).CombinedOutput()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list unpushed commits: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return parseCommits(string(out)), count, nil
+}
+
+// lines splits command output into its non-empty lines
+func lines(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appconfig "github.com/trutx/gogitup/internal/config"
+)
+
+func TestRepository_Unpushed(t *testing.T) {
+	dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	r := &Repository{Path: dir, repo: repo}
+	r.Configure(appconfig.RepositorySettings{})
+
+	runGit(t, dir, "fetch", "-q", "origin")
+	runGit(t, dir, "branch", "-q", "--set-upstream-to=origin/master")
+	unpushed, err := r.Unpushed(context.Background(), 0)
+	require.NoError(t, err)
+	assert.True(t, unpushed.Empty(), unpushed)
+	assert.Empty(t, unpushed.String())
+
+	// A branch whose upstream was deleted, one without upstream and one
+	// ahead of its upstream
+	runGit(t, dir, "push", "-q", "origin", "master:deleted")
+	runGit(t, dir, "branch", "-q", "--track", "deleted", "origin/deleted")
+	runGit(t, dir, "push", "-q", "origin", "--delete", "deleted")
+	runGit(t, dir, "fetch", "-q", "--prune", "origin")
+	runGit(t, dir, "branch", "local")
+	commitFile(t, dir, "local.txt", "Local change")
+
+	// A commit on a detached HEAD, a stash, an untracked and a modified file
+	runGit(t, dir, "checkout", "-q", "--detach")
+	commitFile(t, dir, "detached.txt", "Detached change")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte("stashed"), 0644))
+	runGit(t, dir, "-c", "user.name=Test User", "-c", "user.email=test@example.com", "stash", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.txt"), []byte("modified"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("new"), 0644))
+
+	unpushed, err = r.Unpushed(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []UnpushedBranch{
+		{Name: "deleted", Upstream: "origin/deleted", UpstreamGone: true},
+		{Name: "local"},
+		{Name: "master", Upstream: "origin/master", Ahead: 1},
+	}, unpushed.Branches)
+	assert.Equal(t, 2, unpushed.CommitCount)
+	assert.Len(t, unpushed.Commits, 1)
+	require.Len(t, unpushed.Stashes, 1)
+	assert.Contains(t, unpushed.Stashes[0], "stash@{0}")
+	assert.Equal(t, []string{"untracked.txt"}, unpushed.Untracked)
+	assert.Equal(t, []string{"test.txt"}, unpushed.Modified)
+	assert.False(t, unpushed.Empty())
+	assert.Equal(t, "2 unpushed commits, 2 branches without upstream, 1 stashes, 1 untracked files, 1 modified files", unpushed.String())
+
+	assert.Equal(t, "upstream origin/deleted is gone", unpushed.Branches[0].Reason())
+	assert.Equal(t, "no upstream", unpushed.Branches[1].Reason())
+	assert.Equal(t, "1 commits ahead of origin/master", unpushed.Branches[2].Reason())
+
+	unpushed, err = r.Unpushed(context.Background(), 0)
+	require.NoError(t, err)
+	var subjects []string
+	for _, c := range unpushed.Commits {
+		subjects = append(subjects, c.Subject)
+	}
+	assert.ElementsMatch(t, []string{"Local change", "Detached change"}, subjects)
+}
@@ -0,0 +1,135 @@
+// Package logging sets up the structured diagnostics of gogitup, written with
+// log/slog to stderr or a log file.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Supported handler formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configure the default logger
+type Options struct {
+	// Level is one of debug, info, warn or error, empty means warn
+	Level string
+	// Format is text or json, empty means text
+	Format string
+	// File receives the log instead of stderr when set, it is appended to
+	File string
+}
+
+var (
+	level slog.LevelVar
+	out   = &switchWriter{w: os.Stderr, stderr: true}
+)
+
+// ParseLevel returns the level of a --log-level value
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "", "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, must be one of debug, info, warn or error", s)
+}
+
+// NewHandler returns a handler of the given format writing to w
+func NewHandler(w io.Writer, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, must be text or json", format)
+}
+
+// Setup makes a logger configured by opts the slog default. The log file is
+// kept open for the rest of the process.
+func Setup(opts Options) error {
+	l, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stderr
+	if opts.File != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+			return fmt.Errorf("failed to create log directory: %w", err)
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = f
+	}
+
+	handler, err := NewHandler(out, opts.Format, &slog.HandlerOptions{Level: &level})
+	if err != nil {
+		return err
+	}
+
+	level.Set(l)
+	out.set(w, opts.File == "")
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel changes the level of the default logger, e.g. for commands that
+// log at info level unless told otherwise
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// RedirectStderr sends log output meant for stderr to w until the returned
+// function is called, so that it does not disturb a display on the terminal.
+// Output to a log file is not affected.
+func RedirectStderr(w io.Writer) (restore func()) {
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	if !out.stderr {
+		return func() {}
+	}
+	previous := out.w
+	out.w = w
+	return func() {
+		out.mu.Lock()
+		defer out.mu.Unlock()
+		out.w = previous
+	}
+}
+
+// switchWriter is the writer of the default handler, whose target can change
+// after the handler was created
+type switchWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	stderr bool
+}
+
+func (s *switchWriter) set(w io.Writer, stderr bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w, s.stderr = w, stderr
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
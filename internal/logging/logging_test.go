@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{in: "", want: slog.LevelWarn},
+		{in: "debug", want: slog.LevelDebug},
+		{in: "INFO", want: slog.LevelInfo},
+		{in: "warning", want: slog.LevelWarn},
+		{in: "error", want: slog.LevelError},
+		{in: "trace", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLevel(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, FormatJSON, nil)
+	require.NoError(t, err)
+	slog.New(handler).Info("repository updated", "path", "/src/a", "phase", "fetching")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "repository updated", record["msg"])
+	assert.Equal(t, "/src/a", record["path"])
+	assert.Equal(t, "fetching", record["phase"])
+
+	_, err = NewHandler(&buf, "xml", nil)
+	assert.Error(t, err)
+}
+
+func TestSetup(t *testing.T) {
+	previous := slog.Default()
+	defer func() {
+		slog.SetDefault(previous)
+		out.set(os.Stderr, true)
+	}()
+
+	file := filepath.Join(t.TempDir(), "logs", "gogitup.log")
+	require.NoError(t, Setup(Options{Level: "info", Format: FormatJSON, File: file}))
+
+	slog.Debug("hidden")
+	slog.Info("shown", "path", "/src/a")
+	SetLevel(slog.LevelDebug)
+	slog.Debug("now shown")
+
+	// Output to a log file is not redirected
+	var redirected bytes.Buffer
+	restore := RedirectStderr(&redirected)
+	slog.Warn("still in file")
+	restore()
+	assert.Empty(t, redirected.String())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Contains(t, string(lines[0]), `"msg":"shown"`)
+	assert.Contains(t, string(lines[0]), `"path":"/src/a"`)
+	assert.Contains(t, string(lines[1]), `"msg":"now shown"`)
+	assert.Contains(t, string(lines[2]), `"msg":"still in file"`)
+
+	assert.Error(t, Setup(Options{Level: "loud"}))
+	assert.Error(t, Setup(Options{Format: "xml"}))
+}
+
+func TestRedirectStderr(t *testing.T) {
+	previous := slog.Default()
+	defer func() {
+		slog.SetDefault(previous)
+		out.set(os.Stderr, true)
+	}()
+	require.NoError(t, Setup(Options{}))
+
+	var buf bytes.Buffer
+	restore := RedirectStderr(&buf)
+	slog.Warn("during display")
+	restore()
+
+	assert.Contains(t, buf.String(), "msg=\"during display\"")
+}
@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/trutx/gogitup/internal/config"
+	"go.yaml.in/yaml/v3"
+)
+
+// Entry describes a single repository of a workspace
+type Entry struct {
+	// URL of the origin remote
+	URL string `yaml:"url"`
+	// Path where the repository is cloned. Relative paths are resolved
+	// against the workspace root; when empty, the repository name is used.
+	Path string `yaml:"path,omitempty"`
+	// Upstream is the URL of the upstream remote for forks
+	Upstream string `yaml:"upstream,omitempty"`
+	// Branch to check out after cloning
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// Manifest lists the repositories of a workspace
+type Manifest struct {
+	Repositories []Entry `yaml:"repositories"`
+}
+
+// Load reads a manifest from the given file
+func Load(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	return &m, nil
+}
+
+// Write writes the manifest as YAML
+func (m *Manifest) Write(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return encoder.Close()
+}
+
+// Save writes the manifest to the given file
+func (m *Manifest) Save(file string) error {
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest file: %w", err)
+	}
+	return nil
+}
+
+// Validate checks that every entry has a URL and that no two entries share
+// the same target path under root
+func (m *Manifest) Validate(root string) error {
+	seen := make(map[string]string, len(m.Repositories))
+	for i, entry := range m.Repositories {
+		if entry.URL == "" {
+			return fmt.Errorf("repository #%d has no url", i+1)
+		}
+		target := entry.TargetPath(root)
+		if other, ok := seen[target]; ok {
+			return fmt.Errorf("repositories %s and %s share the path %s", other, entry.URL, target)
+		}
+		seen[target] = entry.URL
+	}
+	return nil
+}
+
+// TargetPath returns the absolute path the entry is cloned to
+func (e Entry) TargetPath(root string) string {
+	p := e.Path
+	if p == "" {
+		p = NameFromURL(e.URL)
+	}
+
+	p = config.ExpandPath(p)
+
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		p = abs
+	}
+	return p
+}
+
+// NameFromURL returns the repository name of a remote URL, e.g. "api" for
+// both https://github.com/org/api.git and git@github.com:org/api.git
+func NameFromURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return path.Clean(url)
+}
+
+// ShortenHome replaces the user's home directory prefix of p with ~ so that
+// exported manifests can be shared between machines
+func ShortenHome(p string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return p
+	}
+	if p == home {
+		return "~"
+	}
+	if strings.HasPrefix(p, home+string(filepath.Separator)) {
+		return "~/" + filepath.ToSlash(p[len(home)+1:])
+	}
+	return p
+}
@@ -0,0 +1,104 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAndSave(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "gogitup-test-manifest-*")
+	require.NoError(t, err)
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			t.Errorf("Failed to remove temp directory: %v", err)
+		}
+	}()
+
+	file := filepath.Join(tmpDir, "manifest.yaml")
+	m := &Manifest{
+		Repositories: []Entry{
+			{URL: "git@github.com:me/api.git", Path: "work/api", Upstream: "https://github.com/org/api.git", Branch: "main"},
+			{URL: "https://github.com/me/tool"},
+		},
+	}
+	require.NoError(t, m.Save(file))
+
+	loaded, err := Load(file)
+	require.NoError(t, err)
+	assert.Equal(t, m, loaded)
+
+	// Unknown keys are rejected
+	require.NoError(t, os.WriteFile(file, []byte("repositories:\n  - url: x\n    brnach: main\n"), 0644))
+	_, err = Load(file)
+	assert.ErrorContains(t, err, "failed to parse manifest")
+
+	// Missing file
+	_, err = Load(filepath.Join(tmpDir, "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read manifest")
+}
+
+func TestNameFromURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://github.com/org/api.git", expected: "api"},
+		{url: "https://github.com/org/api", expected: "api"},
+		{url: "https://github.com/org/api/", expected: "api"},
+		{url: "git@github.com:org/api.git", expected: "api"},
+		{url: "git@host:api.git", expected: "api"},
+		{url: "/srv/git/api.git", expected: "api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NameFromURL(tt.url))
+		})
+	}
+}
+
+func TestEntry_TargetPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		entry    Entry
+		expected string
+	}{
+		{name: "name from url", entry: Entry{URL: "git@github.com:org/api.git"}, expected: "/ws/api"},
+		{name: "relative path", entry: Entry{URL: "x", Path: "work/api"}, expected: "/ws/work/api"},
+		{name: "absolute path", entry: Entry{URL: "x", Path: "/src/api"}, expected: "/src/api"},
+		{name: "home path", entry: Entry{URL: "x", Path: "~/api"}, expected: filepath.Join(home, "api")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.entry.TargetPath("/ws"))
+		})
+	}
+}
+
+func TestManifest_Validate(t *testing.T) {
+	m := &Manifest{Repositories: []Entry{{URL: "a/api.git"}, {URL: "b/api.git"}}}
+	assert.ErrorContains(t, m.Validate("/ws"), "share the path")
+
+	m = &Manifest{Repositories: []Entry{{Path: "api"}}}
+	assert.ErrorContains(t, m.Validate("/ws"), "has no url")
+
+	m = &Manifest{Repositories: []Entry{{URL: "a/api.git"}, {URL: "b/api.git", Path: "b-api"}}}
+	assert.NoError(t, m.Validate("/ws"))
+}
+
+func TestShortenHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	assert.Equal(t, "~", ShortenHome(home))
+	assert.Equal(t, "~/code/api", ShortenHome(filepath.Join(home, "code", "api")))
+	assert.Equal(t, "/srv/api", ShortenHome("/srv/api"))
+}
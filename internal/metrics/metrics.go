@@ -0,0 +1,142 @@
+// Package metrics renders the state of the tracked repositories in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// ContentType is the media type of the exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// outcomes are the update outcomes counted per repository
+var outcomes = []string{git.OutcomeUpdated, git.OutcomeSkipped, git.OutcomeFailed}
+
+// Write writes the metrics of repos to w
+func Write(w io.Writer, repos []git.Repository) error {
+	sorted := slices.Clone(repos)
+	slices.SortFunc(sorted, func(a, b git.Repository) int { return strings.Compare(a.Path, b.Path) })
+
+	bw := bufio.NewWriter(w)
+	family(bw, "gogitup_repositories", "gauge", "Number of tracked repositories.")
+	fmt.Fprintf(bw, "gogitup_repositories %d\n", len(sorted))
+
+	family(bw, "gogitup_repository_last_success_timestamp_seconds", "gauge", "Unix time of the last successful update of the repository.")
+	for _, repo := range sorted {
+		if !repo.LastUpdated.IsZero() {
+			sample(bw, "gogitup_repository_last_success_timestamp_seconds", repo.Path, "", float64(repo.LastUpdated.Unix()))
+		}
+	}
+
+	family(bw, "gogitup_repository_last_update_failed", "gauge", "Whether the last update of the repository failed.")
+	for _, repo := range sorted {
+		if repo.LastOutcome != "" {
+			sample(bw, "gogitup_repository_last_update_failed", repo.Path, "", boolValue(repo.LastOutcome == git.OutcomeFailed))
+		}
+	}
+
+	family(bw, "gogitup_repository_last_duration_seconds", "gauge", "Duration of the last update of the repository.")
+	for _, repo := range sorted {
+		if repo.LastOutcome != "" {
+			sample(bw, "gogitup_repository_last_duration_seconds", repo.Path, "", repo.LastDuration.Seconds())
+		}
+	}
+
+	family(bw, "gogitup_repository_commits_behind", "gauge", "Commits of the remote branch missing from HEAD after the last update.")
+	for _, repo := range sorted {
+		if repo.LastOutcome != "" {
+			sample(bw, "gogitup_repository_commits_behind", repo.Path, "", float64(repo.Behind))
+		}
+	}
+
+	family(bw, "gogitup_repository_dirty", "gauge", "Whether the last update was skipped because of uncommitted changes.")
+	for _, repo := range sorted {
+		if repo.LastOutcome != "" {
+			sample(bw, "gogitup_repository_dirty", repo.Path, "", boolValue(repo.Dirty))
+		}
+	}
+
+	family(bw, "gogitup_repository_updates_total", "counter", "Number of updates of the repository by outcome.")
+	for _, repo := range sorted {
+		if len(repo.Outcomes) == 0 {
+			continue
+		}
+		for _, outcome := range outcomes {
+			sample(bw, "gogitup_repository_updates_total", repo.Path, outcome, float64(repo.Outcomes[outcome]))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteTextfile writes the metrics of repos to path for the node_exporter
+// textfile collector, together with the time of the run. The file is
+// replaced atomically so the collector never reads a partial file.
+func WriteTextfile(path string, repos []git.Repository, at time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create metrics directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	err = Write(tmp, repos)
+	if err == nil {
+		family(tmp, "gogitup_last_run_timestamp_seconds", "gauge", "Unix time of the last update run.")
+		_, err = fmt.Fprintf(tmp, "gogitup_last_run_timestamp_seconds %d\n", at.Unix())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+// family writes the HELP and TYPE lines of a metric
+func family(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// sample writes a sample of a repository metric, with an outcome label if
+// outcome is set
+func sample(w io.Writer, name, path, outcome string, value float64) {
+	labels := `repository="` + escapeLabel(path) + `"`
+	if outcome != "" {
+		labels += `,outcome="` + outcome + `"`
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// escapeLabel escapes a label value as required by the exposition format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func TestWrite(t *testing.T) {
+	updated := time.Unix(1700000000, 0)
+	repos := []git.Repository{
+		{
+			Path:         "/src/zeta",
+			LastOutcome:  git.OutcomeSkipped,
+			Dirty:        true,
+			Behind:       4,
+			LastDuration: 250 * time.Millisecond,
+			Outcomes:     map[string]int{git.OutcomeSkipped: 1},
+		},
+		{
+			Path:         `/src/"quoted"`,
+			LastUpdated:  updated,
+			LastOutcome:  git.OutcomeUpdated,
+			LastDuration: 1500 * time.Millisecond,
+			Outcomes:     map[string]int{git.OutcomeUpdated: 3, git.OutcomeFailed: 1},
+		},
+		{Path: "/src/never-updated"},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, repos))
+	out := b.String()
+
+	assert.Contains(t, out, "# TYPE gogitup_repositories gauge\ngogitup_repositories 3\n")
+	assert.Contains(t, out, `gogitup_repository_last_success_timestamp_seconds{repository="/src/\"quoted\""} 1700000000`)
+	assert.Contains(t, out, `gogitup_repository_last_duration_seconds{repository="/src/\"quoted\""} 1.5`)
+	assert.Contains(t, out, `gogitup_repository_last_update_failed{repository="/src/zeta"} 0`)
+	assert.Contains(t, out, `gogitup_repository_commits_behind{repository="/src/zeta"} 4`)
+	assert.Contains(t, out, `gogitup_repository_dirty{repository="/src/zeta"} 1`)
+	assert.Contains(t, out, `gogitup_repository_dirty{repository="/src/\"quoted\""} 0`)
+	assert.Contains(t, out, "# TYPE gogitup_repository_updates_total counter\n")
+	assert.Contains(t, out, `gogitup_repository_updates_total{repository="/src/\"quoted\"",outcome="updated"} 3`)
+	assert.Contains(t, out, `gogitup_repository_updates_total{repository="/src/\"quoted\"",outcome="failed"} 1`)
+	assert.Contains(t, out, `gogitup_repository_updates_total{repository="/src/zeta",outcome="updated"} 0`)
+
+	// Repositories without any update have no samples
+	assert.NotContains(t, out, "never-updated\"}")
+	assert.NotContains(t, out, "gogitup_repository_last_success_timestamp_seconds{repository=\"/src/zeta\"}")
+}
+
+func TestWriteTextfile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "textfile")
+	path := filepath.Join(dir, "gogitup.prom")
+	repos := []git.Repository{{Path: "/src/alpha", LastOutcome: git.OutcomeUpdated}}
+
+	require.NoError(t, WriteTextfile(path, repos, time.Unix(1700000000, 0)))
+	require.NoError(t, WriteTextfile(path, repos, time.Unix(1700000060, 0)))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `gogitup_repository_dirty{repository="/src/alpha"} 0`)
+	assert.Contains(t, string(data), "gogitup_last_run_timestamp_seconds 1700000060\n")
+
+	// No temporary files are left for the collector to pick up
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	info, err := entries[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert.Equal(t, `C:\\src\\repo`, escapeLabel(`C:\src\repo`))
+	assert.Equal(t, `a\"b\nc`, escapeLabel("a\"b\nc"))
+}
@@ -0,0 +1,54 @@
+// Package pool runs work on a bounded number of goroutines.
+package pool
+
+import (
+	"sync"
+)
+
+// ClampWorkers bounds the requested number of workers to [1, jobs]
+func ClampWorkers(requested, jobs int) int {
+	if requested < 1 {
+		requested = 1
+	}
+	if jobs > 0 && requested > jobs {
+		requested = jobs
+	}
+	return requested
+}
+
+// Run runs work for every job using numWorkers goroutines and returns a
+// channel that yields one result per job. The channel is closed once all jobs
+// have been processed.
+func Run[J, T any](items []J, numWorkers int, work func(*J) T) <-chan T {
+	numWorkers = ClampWorkers(numWorkers, len(items))
+
+	// Create channels for work distribution
+	jobs := make(chan *J, len(items))
+	results := make(chan T, len(items))
+	var wg sync.WaitGroup
+
+	// Start worker goroutines
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- work(job)
+			}
+		}()
+	}
+
+	// Send work to workers
+	for i := range items {
+		jobs <- &items[i]
+	}
+	close(jobs)
+
+	// Close the results channel once all workers are done
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
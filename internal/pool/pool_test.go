@@ -0,0 +1,57 @@
+package pool
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+func TestClampWorkers(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested int
+		jobs      int
+		expected  int
+	}{
+		{name: "negative", requested: -1, jobs: 5, expected: 1},
+		{name: "zero", requested: 0, jobs: 5, expected: 1},
+		{name: "within_range", requested: 3, jobs: 5, expected: 3},
+		{name: "exceeds_jobs", requested: 10, jobs: 5, expected: 5},
+		{name: "no_jobs", requested: 4, jobs: 0, expected: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClampWorkers(tt.requested, tt.jobs))
+		})
+	}
+}
+
+func TestRunPool(t *testing.T) {
+	repos := make([]git.Repository, 20)
+	for i := range repos {
+		repos[i].Path = fmt.Sprintf("/repo/%02d", i)
+	}
+
+	var calls int32
+	results := Run(repos, 4, func(repo *git.Repository) string {
+		atomic.AddInt32(&calls, 1)
+		return repo.Path
+	})
+
+	var paths []string
+	for path := range results {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	assert.Equal(t, int32(len(repos)), atomic.LoadInt32(&calls))
+	assert.Len(t, paths, len(repos))
+	for i, path := range paths {
+		assert.Equal(t, repos[i].Path, path)
+	}
+}
@@ -0,0 +1,174 @@
+// Package stale finds repositories nobody works on anymore: the ones without
+// recent activity and the ones whose remote is gone, unreachable or archived.
+package stale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// remoteTimeout bounds the check of a remote
+const remoteTimeout = 30 * time.Second
+
+// gitHubAPI is the GitHub API asked whether repositories of github.com are
+// archived
+var gitHubAPI = "https://api.github.com"
+
+// Options configure what makes a repository stale
+type Options struct {
+	// Days without activity after which a repository is stale
+	Days int
+	// Offline skips the checks of the remotes
+	Offline bool
+	// Now is the time activity is compared with
+	Now time.Time
+}
+
+// Report tells whether a repository is stale and why
+type Report struct {
+	Repository *git.Repository
+	// LastActivity is the last checkout or commit, zero when unknown
+	LastActivity time.Time
+	// Reasons the repository is stale, empty when it is not
+	Reasons []string
+	// Work is the unpushed work of a stale repository, its commits are
+	// counted but not listed
+	Work git.Unpushed
+	// WorkError is set when the local work cannot be determined
+	WorkError error
+}
+
+// Stale reports whether the repository is stale
+func (r Report) Stale() bool {
+	return len(r.Reasons) > 0
+}
+
+// Safe reports whether the repository can be archived or removed: it is
+// known to have no work that exists nowhere else
+func (r Report) Safe() bool {
+	return r.WorkError == nil && r.Work.Empty()
+}
+
+// Inactive reports whether lastActivity, the LastActivity of a repository,
+// is older than the given days or unknown, which makes the repository stale
+func Inactive(lastActivity, now time.Time, days int) bool {
+	return lastActivity.IsZero() || lastActivity.Before(now.AddDate(0, 0, -days))
+}
+
+// Check reports whether repo is stale. The repository must be configured,
+// the remotes checked depend on its settings.
+func Check(ctx context.Context, repo *git.Repository, opts Options) Report {
+	report := Report{Repository: repo, LastActivity: repo.LastActivity(ctx)}
+
+	switch {
+	case report.LastActivity.IsZero():
+		report.Reasons = append(report.Reasons, "no checkout or commit on record")
+	case Inactive(report.LastActivity, opts.Now, opts.Days):
+		days := int(opts.Now.Sub(report.LastActivity).Hours() / 24)
+		report.Reasons = append(report.Reasons, fmt.Sprintf("no checkout or commit for %d days", days))
+	}
+
+	if !opts.Offline {
+		for _, remote := range repo.UpdateRemotes() {
+			if reason := checkRemote(ctx, repo, remote); reason != "" {
+				report.Reasons = append(report.Reasons, reason)
+			}
+		}
+	}
+
+	if report.Stale() {
+		report.Work, report.WorkError = CheckWork(ctx, repo)
+	}
+	return report
+}
+
+// CheckWork returns the unpushed work of repo the way Check does, to check
+// it again right before acting on a report
+func CheckWork(ctx context.Context, repo *git.Repository) (git.Unpushed, error) {
+	// Only the count of the commits matters, so list the newest one only
+	return repo.Unpushed(ctx, 1)
+}
+
+// checkRemote returns why the named remote makes the repository stale, or
+// "" when it does not. Remotes failing for other reasons, like rejected
+// credentials, are left to 'gogitup doctor'.
+func checkRemote(ctx context.Context, repo *git.Repository, remote string) string {
+	remoteURL := repo.RemoteURL(remote)
+	if remoteURL == "" {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, remoteTimeout)
+	defer cancel()
+
+	err := repo.CheckRemote(ctx, remote)
+	switch {
+	case errors.Is(err, git.ErrRemoteNotFound):
+		return fmt.Sprintf("remote %s (%s) no longer exists", remote, remoteURL)
+	case errors.Is(err, git.ErrHostNotFound):
+		return fmt.Sprintf("the host of remote %s (%s) does not resolve", remote, remoteURL)
+	case err == nil && archived(ctx, remoteURL, repo.Token(remoteURL)):
+		return fmt.Sprintf("remote %s (%s) is archived", remote, remoteURL)
+	}
+	return ""
+}
+
+// archived asks the GitHub API whether the repository of a github.com remote
+// URL is archived. Repositories the API gives no answer for, e.g. when the
+// rate limit is exceeded, are not archived.
+func archived(ctx context.Context, remoteURL, token string) bool {
+	name := git.GitHubRepository(remoteURL)
+	if name == "" {
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gitHubAPI+"/repos/"+name, nil)
+	if err != nil {
+		return false
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	var repository struct {
+		Archived bool `json:"archived"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
+		return false
+	}
+	return repository.Archived
+}
+
+// Archive moves the repository at path into dir, keeping its directory name,
+// and returns its new path
+func Archive(path, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	target := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Lstat(target); err == nil {
+		return "", fmt.Errorf("cannot archive %s: %s already exists", path, target)
+	}
+	if err := os.Rename(path, target); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", path, target, err)
+	}
+	return target, nil
+}
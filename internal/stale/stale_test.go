@@ -0,0 +1,133 @@
+package stale
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// runGit runs git in dir with a fixed identity
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// cloneRepository clones a new bare repository with one commit and returns
+// the clone and the bare repository
+func cloneRepository(t *testing.T) (*git.Repository, string) {
+	t.Helper()
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	clone := filepath.Join(dir, "clone")
+	runGit(t, dir, "init", "-q", "--bare", "-b", "master", remote)
+	runGit(t, dir, "clone", "-q", remote, clone)
+	runGit(t, clone, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	runGit(t, clone, "push", "-q", "origin", "master")
+
+	repo, err := git.OpenRepository(clone)
+	require.NoError(t, err)
+	repo.Configure(config.RepositorySettings{})
+	return repo, remote
+}
+
+func TestCheck(t *testing.T) {
+	repo, remote := cloneRepository(t)
+	now := time.Now()
+
+	// Cloned and committed to right now
+	report := Check(context.Background(), repo, Options{Days: 90, Now: now})
+	assert.False(t, report.Stale(), report.Reasons)
+	assert.WithinDuration(t, now, report.LastActivity, time.Minute)
+
+	// Without activity for longer than the given days
+	report = Check(context.Background(), repo, Options{Days: 90, Offline: true, Now: now.AddDate(0, 0, 100)})
+	assert.Equal(t, []string{"no checkout or commit for 100 days"}, report.Reasons)
+	assert.True(t, report.Safe())
+
+	// The remote is gone and the repository has local work
+	require.NoError(t, os.RemoveAll(remote))
+	runGit(t, repo.Path, "commit", "-q", "--allow-empty", "-m", "Local change")
+	report = Check(context.Background(), repo, Options{Days: 90, Now: now})
+	assert.Equal(t, []string{"remote origin (" + remote + ") no longer exists"}, report.Reasons)
+	assert.NoError(t, report.WorkError)
+	assert.Equal(t, 1, report.Work.CommitCount)
+	assert.Equal(t, "1 unpushed commits", report.Work.String())
+	assert.False(t, report.Safe())
+
+	// Offline the remote is not checked
+	report = Check(context.Background(), repo, Options{Days: 90, Offline: true, Now: now})
+	assert.False(t, report.Stale(), report.Reasons)
+}
+
+func TestCheck_DetachedHead(t *testing.T) {
+	repo, _ := cloneRepository(t)
+
+	// Commits made on a detached HEAD are on no branch, but still unpushed
+	runGit(t, repo.Path, "checkout", "-q", "--detach")
+	runGit(t, repo.Path, "commit", "-q", "--allow-empty", "-m", "Detached change")
+	report := Check(context.Background(), repo, Options{Days: 90, Offline: true, Now: time.Now().AddDate(0, 0, 100)})
+	assert.True(t, report.Stale())
+	assert.NoError(t, report.WorkError)
+	assert.Equal(t, 1, report.Work.CommitCount)
+	assert.False(t, report.Safe())
+}
+
+func TestArchived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/trutx/archived":
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"archived": true}`))
+		case "/repos/trutx/active":
+			_, _ = w.Write([]byte(`{"archived": false}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	previous := gitHubAPI
+	gitHubAPI = server.URL
+	defer func() { gitHubAPI = previous }()
+
+	ctx := context.Background()
+	assert.True(t, archived(ctx, "git@github.com:trutx/archived.git", "secret"))
+	assert.False(t, archived(ctx, "https://github.com/trutx/active.git", ""))
+	assert.False(t, archived(ctx, "https://github.com/trutx/limited.git", ""))
+	assert.False(t, archived(ctx, "https://gitlab.com/trutx/archived.git", "secret"))
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "src", "project")
+	require.NoError(t, os.MkdirAll(path, 0755))
+	archiveDir := filepath.Join(dir, "archive")
+
+	target, err := Archive(path, archiveDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(archiveDir, "project"), target)
+	assert.DirExists(t, target)
+	assert.NoDirExists(t, path)
+
+	// Archived repositories are never overwritten
+	require.NoError(t, os.MkdirAll(path, 0755))
+	_, err = Archive(path, archiveDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already exists")
+	assert.DirExists(t, path)
+}
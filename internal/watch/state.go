@@ -0,0 +1,72 @@
+package watch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// HeartbeatInterval is how often a running watcher refreshes its state file.
+// A state file older than three intervals belongs to a watcher that is gone.
+const HeartbeatInterval = time.Minute
+
+// State is written next to the repository list by a running watcher
+type State struct {
+	PID         int       `json:"pid"`
+	Started     time.Time `json:"started"`
+	Heartbeat   time.Time `json:"heartbeat"`
+	Directories []string  `json:"directories"`
+}
+
+// StateFile returns the path of the watcher state file for a repository list
+func StateFile(reposFile string) string {
+	return reposFile + ".watch"
+}
+
+// ReadState reads a watcher state file
+func ReadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse watcher state: %w", err)
+	}
+	return &state, nil
+}
+
+// writeState writes the state file atomically
+func writeState(path string, state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watcher state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write watcher state: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// IsCurrent reports whether a live watcher keeps the repository list current
+// for exactly the given directories, in which case full scans can be skipped
+func IsCurrent(stateFile string, directories []string) bool {
+	state, err := ReadState(stateFile)
+	if err != nil {
+		return false
+	}
+	if time.Since(state.Heartbeat) > 3*HeartbeatInterval {
+		return false
+	}
+	return slices.Equal(sorted(state.Directories), sorted(directories))
+}
+
+// sorted returns a sorted copy of s
+func sorted(s []string) []string {
+	s = slices.Clone(s)
+	slices.Sort(s)
+	return s
+}
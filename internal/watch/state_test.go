@@ -0,0 +1,51 @@
+package watch
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCurrent(t *testing.T) {
+	stateFile := StateFile(filepath.Join(t.TempDir(), "repositories.json"))
+	dirs := []string{"/repos/b", "/repos/a"}
+
+	tests := []struct {
+		name  string
+		state *State
+		dirs  []string
+		want  bool
+	}{
+		{name: "no watcher", want: false},
+		{
+			name:  "live watcher",
+			state: &State{Heartbeat: time.Now(), Directories: []string{"/repos/a", "/repos/b"}},
+			dirs:  dirs,
+			want:  true,
+		},
+		{
+			name:  "stale heartbeat",
+			state: &State{Heartbeat: time.Now().Add(-time.Hour), Directories: dirs},
+			dirs:  dirs,
+			want:  false,
+		},
+		{
+			name:  "different directories",
+			state: &State{Heartbeat: time.Now(), Directories: []string{"/repos/a"}},
+			dirs:  dirs,
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.state != nil {
+				require.NoError(t, writeState(stateFile, *tt.state))
+			}
+			assert.Equal(t, tt.want, IsCurrent(stateFile, tt.dirs))
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Package watch keeps the repository list current by watching the configured
+// directories for repositories being created or removed.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// DefaultDebounce is how long the watcher waits for further events before
+// updating the repository list
+const DefaultDebounce = 500 * time.Millisecond
+
+// maxRetries bounds how often a repository that cannot be opened yet, e.g.
+// because it is still being cloned, is looked at again
+const maxRetries = 20
+
+// errUnchanged ends a sync that found nothing to save
+var errUnchanged = errors.New("repository list unchanged")
+
+// Options configure a Watcher
+type Options struct {
+	// Directories are the scan directories from the config file
+	Directories []string
+	// Store is the repository list kept current
+	Store *git.Store
+	// StateFile is written while the watcher runs, see StateFile
+	StateFile string
+	// Debounce defaults to DefaultDebounce
+	Debounce time.Duration
+	// Logf logs changes to the repository list, it may be nil
+	Logf func(format string, args ...any)
+}
+
+// Watcher watches the scan directories and updates the repository list
+// incrementally. fsnotify watches are not recursive, so every directory down
+// to the repository roots is watched individually.
+type Watcher struct {
+	opts    Options
+	fs      *fsnotify.Watcher
+	watched map[string]bool
+	// known holds the paths saved by the last sync
+	known map[string]bool
+}
+
+// New returns a watcher with the given options
+func New(opts Options) *Watcher {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+	return &Watcher{opts: opts, watched: make(map[string]bool)}
+}
+
+// Run performs a full scan, then watches for changes until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer func() { _ = fsw.Close() }()
+	w.fs = fsw
+
+	// Watch before scanning so repositories created in between are not missed
+	for _, dir := range w.opts.Directories {
+		w.watchTree(dir)
+	}
+	if _, _, err := w.sync(w.opts.Directories); err != nil {
+		return err
+	}
+
+	state := State{PID: os.Getpid(), Started: time.Now(), Heartbeat: time.Now(), Directories: w.opts.Directories}
+	if err := writeState(w.opts.StateFile, state); err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(w.opts.StateFile) }()
+	w.opts.Logf("watching %d directories", len(w.watched))
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	debounce := time.NewTimer(w.opts.Debounce)
+	debounce.Stop()
+	pending := make(map[string]bool)
+	retries := make(map[string]int)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if w.handle(event, pending) {
+				debounce.Reset(w.opts.Debounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			w.opts.Logf("watch error: %v", err)
+		case <-debounce.C:
+			dirs := make([]string, 0, len(pending))
+			for dir := range pending {
+				dirs = append(dirs, dir)
+			}
+			clear(pending)
+			added, removed, err := w.sync(dirs)
+			if err != nil {
+				w.opts.Logf("failed to update repository list: %v", err)
+			} else if added > 0 || removed > 0 {
+				w.opts.Logf("repository list updated: %d added, %d removed", added, removed)
+			}
+
+			// Repositories that are still being created are looked at again
+			for _, dir := range incompleteRepositories(dirs) {
+				if retries[dir] < maxRetries {
+					retries[dir]++
+					pending[dir] = true
+				}
+			}
+			if len(pending) > 0 {
+				debounce.Reset(w.opts.Debounce)
+			}
+		case <-heartbeat.C:
+			state.Heartbeat = time.Now()
+			if err := writeState(w.opts.StateFile, state); err != nil {
+				w.opts.Logf("failed to write watcher state: %v", err)
+			}
+		}
+	}
+}
+
+// handle records the directory to rescan for an event and reports whether
+// the event is relevant
+func (w *Watcher) handle(event fsnotify.Event, pending map[string]bool) bool {
+	path := event.Name
+
+	// A .git entry appearing or disappearing turns its parent into a
+	// repository or back into a plain directory
+	if filepath.Base(path) == ".git" {
+		pending[filepath.Dir(path)] = true
+		return true
+	}
+
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			return false
+		}
+		w.watchTree(path)
+		pending[path] = true
+		return true
+	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+		if !w.watched[path] {
+			return false
+		}
+		w.unwatchTree(path)
+		pending[path] = true
+		return true
+	}
+	return false
+}
+
+// watchTree adds watches for root and every directory below it, down to the
+// repository roots
+func (w *Watcher) watchTree(root string) {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if !w.watched[path] {
+			if err := w.fs.Add(path); err != nil {
+				w.opts.Logf("failed to watch %s: %v", path, err)
+				return filepath.SkipDir
+			}
+			w.watched[path] = true
+		}
+
+		// Changes inside a repository are not interesting, only the
+		// repository root is watched to notice its .git going away
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		w.opts.Logf("failed to watch %s: %v", root, err)
+	}
+}
+
+// unwatchTree removes the watches of root and every directory below it
+func (w *Watcher) unwatchTree(root string) {
+	for path := range w.watched {
+		if config.IsWithin(path, root) {
+			_ = w.fs.Remove(path)
+			delete(w.watched, path)
+		}
+	}
+}
+
+// sync rescans the given directories and replaces the repositories cached
+// below them with the ones found, keeping user-managed fields. It returns the
+// number of repositories added and removed.
+func (w *Watcher) sync(dirs []string) (int, int, error) {
+	dirs = topLevel(dirs)
+
+	found, err := git.FindRepositories(dirs, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	if found, err = w.opts.Store.RemoveIgnored(found); err != nil {
+		return 0, 0, fmt.Errorf("failed to load ignore patterns: %w", err)
+	}
+
+	// Removals are detected against the paths saved by the previous sync,
+	// entries that cannot be opened are kept like the rest of the list
+	added, removed := 0, 0
+	var repos []git.Repository
+	err = w.opts.Store.Update(func(previous []git.Repository) ([]git.Repository, error) {
+		var outside, inside []git.Repository
+		for _, repo := range previous {
+			if withinAny(repo.Path, dirs) {
+				inside = append(inside, repo)
+			} else {
+				outside = append(outside, repo)
+			}
+		}
+
+		// Manually added repositories are kept even when they are not found
+		merged := git.MergeRepositories(inside, found)
+		mergedPaths := make(map[string]bool, len(merged))
+		for _, repo := range merged {
+			mergedPaths[repo.Path] = true
+			if !w.known[repo.Path] {
+				added++
+			}
+		}
+		for path := range w.known {
+			if withinAny(path, dirs) && !mergedPaths[path] {
+				removed++
+			}
+		}
+		if w.known != nil && added == 0 && removed == 0 {
+			return nil, errUnchanged
+		}
+
+		repos = append(outside, merged...)
+		sort.Slice(repos, func(i, j int) bool { return repos[i].Path < repos[j].Path })
+		return repos, nil
+	})
+	if errors.Is(err, errUnchanged) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to save repositories: %w", err)
+	}
+
+	w.known = make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		w.known[repo.Path] = true
+	}
+	return added, removed, nil
+}
+
+// topLevel drops the directories that live below another one of dirs
+func topLevel(dirs []string) []string {
+	sorted := slices.Clone(dirs)
+	slices.Sort(sorted)
+	var top []string
+	for _, dir := range sorted {
+		if !withinAny(dir, top) {
+			top = append(top, dir)
+		}
+	}
+	return top
+}
+
+// incompleteRepositories returns the directories below dirs that contain a
+// .git directory but cannot be opened as a repository yet
+func incompleteRepositories(dirs []string) []string {
+	var incomplete []string
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if stat, err := os.Stat(filepath.Join(path, ".git")); err == nil && stat.IsDir() {
+				if _, err := git.OpenRepository(path); err != nil {
+					incomplete = append(incomplete, path)
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		})
+	}
+	return incomplete
+}
+
+// withinAny reports whether path is within any of dirs
+func withinAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if config.IsWithin(path, dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// initRepo creates an empty git repository at dir
+func initRepo(t *testing.T, dir string) {
+	t.Helper()
+	out, err := exec.Command("git", "init", "-q", dir).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// cachedPaths returns the paths stored in the repository list file. The file
+// is read directly since Store.Load hides repositories that are gone.
+func cachedPaths(t *testing.T, reposFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(reposFile)
+	if err != nil {
+		return nil
+	}
+	var repos []git.Repository
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil
+	}
+	paths := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		paths = append(paths, repo.Path)
+	}
+	return paths
+}
+
+func TestWatcher_Run(t *testing.T) {
+	tmpDir := t.TempDir()
+	reposDir := filepath.Join(tmpDir, "repos")
+	reposFile := filepath.Join(tmpDir, "repositories.json")
+	stateFile := StateFile(reposFile)
+
+	existing := filepath.Join(reposDir, "existing")
+	initRepo(t, existing)
+
+	w := New(Options{
+		Directories: []string{reposDir},
+		Store:       git.NewStore(reposFile),
+		StateFile:   stateFile,
+		Debounce:    20 * time.Millisecond,
+		Logf:        t.Logf,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	stopped := false
+	t.Cleanup(func() {
+		if !stopped {
+			cancel()
+			<-done
+		}
+	})
+
+	// The initial scan finds the existing repository
+	require.Eventually(t, func() bool {
+		return IsCurrent(stateFile, []string{reposDir})
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{existing}, cachedPaths(t, reposFile))
+
+	// Repositories created in new nested directories are picked up
+	nested := filepath.Join(reposDir, "team", "service")
+	initRepo(t, nested)
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{existing, nested}, cachedPaths(t, reposFile))
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// Removed repositories are dropped
+	require.NoError(t, os.RemoveAll(filepath.Join(reposDir, "team")))
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{existing}, cachedPaths(t, reposFile))
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	stopped = true
+	require.NoError(t, <-done)
+	_, err := os.Stat(stateFile)
+	assert.True(t, os.IsNotExist(err), "state file should be removed on shutdown")
+}
+
+func TestTopLevel(t *testing.T) {
+	dirs := []string{"/repos/a/b", "/repos/a-b", "/repos/a", "/repos/c/d", "/repos/a"}
+	assert.Equal(t, []string{"/repos/a", "/repos/a-b", "/repos/c/d"}, topLevel(dirs))
+}
@@ -0,0 +1,55 @@
+// Package gogitup is the public API of the gogitup update engine. It finds
+// Git repositories with a Scanner, keeps them in a Store and updates them
+// concurrently with an Updater, so the engine can be embedded in other tools.
+//
+// A minimal program that updates the cached repositories:
+//
+//	store, err := gogitup.DefaultStore()
+//	if err != nil {
+//		return err
+//	}
+//	repos, err := store.Load()
+//	if err != nil {
+//		return err
+//	}
+//	updater := gogitup.NewUpdater(&gogitup.Config{}, gogitup.Options{Threads: 4})
+//	for event := range updater.Start(ctx, repos) {
+//		if event.Kind == gogitup.EventFinished {
+//			fmt.Println(event.Path, event.Result.Status())
+//		}
+//	}
+package gogitup
+
+import (
+	"github.com/trutx/gogitup/internal/config"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// Types of the engine, shared with the gogitup command
+type (
+	// Repository is a Git repository tracked by gogitup
+	Repository = git.Repository
+	// Commit is a commit pulled in by an update
+	Commit = git.Commit
+	// Divergence describes a branch that diverged from its remote branch
+	Divergence = git.Divergence
+	// DivergedError is the error of an update that cannot fast-forward
+	DivergedError = git.DivergedError
+	// Config is the gogitup configuration, the zero value updates every
+	// repository with the default settings
+	Config = config.Config
+	// RepositorySettings are the settings of a single repository
+	RepositorySettings = config.RepositorySettings
+)
+
+// Errors of an update that are reported as warnings rather than failures
+var (
+	ErrUncommittedChanges = git.ErrUncommittedChanges
+	ErrSkipped            = git.ErrSkipped
+)
+
+// LoadConfig reads the config file at path, with its includes, and applies
+// the named profile. An empty profile applies none.
+func LoadConfig(path, profile string) (*Config, error) {
+	return config.LoadProfile(path, profile)
+}
@@ -0,0 +1,56 @@
+package gogitup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// Scanner finds the Git repositories below a set of directories
+type Scanner struct {
+	// Directories are searched recursively, a leading ~/ is the home
+	// directory and missing directories are ignored
+	Directories []string
+	// OnFound is called with the number of repositories found so far, it
+	// may be nil
+	OnFound func(count int)
+}
+
+// NewScanner returns a scanner of the given directories
+func NewScanner(directories ...string) *Scanner {
+	return &Scanner{Directories: directories}
+}
+
+// Scan returns the repositories found in the directories
+func (s *Scanner) Scan(ctx context.Context) ([]Repository, error) {
+	repos, err := git.FindRepositoriesContext(ctx, s.Directories, s.OnFound)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find repositories: %w", err)
+	}
+	return repos, nil
+}
+
+// Refresh scans the directories and replaces the repositories in store with
+// the ones found, keeping user-managed fields such as tags and the recorded
+// outcomes of the repositories already in the store. Manually added
+// repositories are kept and ignored ones left out. It returns the saved
+// repositories that can be opened.
+func (s *Scanner) Refresh(ctx context.Context, store *Store) ([]Repository, error) {
+	found, err := s.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if found, err = store.store.RemoveIgnored(found); err != nil {
+		return nil, fmt.Errorf("failed to load ignore patterns: %w", err)
+	}
+
+	// Manually added repositories are kept even while they cannot be opened
+	err = store.Update(func(previous []Repository) ([]Repository, error) {
+		return git.MergeRepositories(previous, found), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return store.Load()
+}
@@ -0,0 +1,143 @@
+package gogitup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// Store is the repository list file, the cache of the scanned repositories
+// and the outcomes of their updates
+type Store struct {
+	store *git.Store
+}
+
+// NewStore returns the store kept in the file at path
+func NewStore(path string) *Store {
+	return &Store{store: git.NewStore(path)}
+}
+
+// DefaultStore returns the store in the default cache file of the user, the
+// one used by the gogitup command
+func DefaultStore() (*Store, error) {
+	path, err := git.GetCacheFile()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(path), nil
+}
+
+// Path returns the path of the repository list file
+func (s *Store) Path() string {
+	return s.store.Path()
+}
+
+// Load returns the repositories in the store that can still be opened. A
+// missing file is an empty store.
+func (s *Store) Load() ([]Repository, error) {
+	repos, err := s.store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repositories: %w", err)
+	}
+	return repos, nil
+}
+
+// Save replaces the repositories in the store, leaving out the ones matching
+// an ignore pattern
+func (s *Store) Save(repos []Repository) error {
+	if err := s.store.Save(repos); err != nil {
+		return fmt.Errorf("failed to save repositories: %w", err)
+	}
+	return nil
+}
+
+// Update passes every repository in the store to fn, including the ones that
+// can no longer be opened, and saves the repositories fn returns. Use it
+// instead of Load and Save to change the store without dropping repositories
+// that are missing for a while. Other processes cannot change the store
+// while fn runs. Errors returned by fn are passed on as they are.
+func (s *Store) Update(fn func(repos []Repository) ([]Repository, error)) error {
+	var fnErr error
+	err := s.store.Update(func(repos []Repository) ([]Repository, error) {
+		repos, fnErr = fn(repos)
+		return repos, fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save repositories: %w", err)
+	}
+	return nil
+}
+
+// LastScan returns when a scan last found a repository of the store, the
+// zero time when none was scanned. Saving the store, e.g. to record update
+// outcomes, does not move it.
+func (s *Store) LastScan() (time.Time, error) {
+	last, err := s.store.LastScan()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load repositories: %w", err)
+	}
+	return last, nil
+}
+
+// Ignored returns the ignore patterns of the store
+func (s *Store) Ignored() ([]string, error) {
+	patterns, err := s.store.Ignored()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore patterns: %w", err)
+	}
+	return patterns, nil
+}
+
+// Ignore keeps the repositories matching the patterns out of the store, now
+// and after every scan. A pattern is the path of a repository or of a
+// directory, or a glob as in the repository settings. It returns the number
+// of repositories removed.
+func (s *Store) Ignore(patterns ...string) (int, error) {
+	removed, err := s.store.Ignore(patterns...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save ignore patterns: %w", err)
+	}
+	return removed, nil
+}
+
+// Unignore removes ignore patterns from the store
+func (s *Store) Unignore(patterns ...string) error {
+	if err := s.store.Unignore(patterns...); err != nil {
+		return fmt.Errorf("failed to remove ignore patterns: %w", err)
+	}
+	return nil
+}
+
+// Record stores the outcome of every result with the repository it belongs
+// to and returns the saved repositories. Repositories that cannot be opened
+// are kept as they are.
+func (s *Store) Record(results []Result, at time.Time) ([]Repository, error) {
+	byPath := make(map[string]Result, len(results))
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+
+	var saved []Repository
+	err := s.Update(func(repos []Repository) ([]Repository, error) {
+		for i := range repos {
+			if result, ok := byPath[repos[i].Path]; ok {
+				repos[i].RecordOutcome(result.Outcome(), result.Err, at)
+				repos[i].LastDuration = result.Elapsed
+				repos[i].Dirty = result.Dirty
+				if result.Behind >= 0 {
+					repos[i].Behind = result.Behind
+				}
+			}
+		}
+		saved = repos
+		return repos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
@@ -0,0 +1,126 @@
+package gogitup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "cache", "repositories.json"))
+
+	// A missing file is an empty store
+	repos, err := store.Load()
+	require.NoError(t, err)
+	assert.Empty(t, repos)
+
+	repos = cloneRepos(t, dir, 2)
+	repos[0].AddTags("work")
+	repos[0].LastScanned = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Save(repos))
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, []string{"work"}, loaded[0].Tags)
+
+	scanned, err := store.LastScan()
+	require.NoError(t, err)
+	assert.True(t, scanned.Equal(repos[0].LastScanned), scanned)
+
+	// Recording outcomes keeps repositories that cannot be opened
+	missing := Repository{Path: filepath.Join(dir, "missing"), Manual: true}
+	require.NoError(t, store.Save(append(repos, missing)))
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	saved, err := store.Record([]Result{
+		{Path: repos[0].Path, Elapsed: time.Second, Behind: 2},
+		{Path: repos[1].Path, Err: errors.New("boom"), Behind: -1},
+	}, at)
+	require.NoError(t, err)
+	require.Len(t, saved, 3)
+	_, broken, err := store.store.Inspect()
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Equal(t, missing.Path, broken[0].Path)
+	assert.True(t, broken[0].Manual)
+
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, OutcomeUpdated, loaded[0].LastOutcome)
+	assert.True(t, loaded[0].LastUpdated.Equal(at))
+	assert.Equal(t, time.Second, loaded[0].LastDuration)
+	assert.Equal(t, 2, loaded[0].Behind)
+	assert.Equal(t, OutcomeFailed, loaded[1].LastOutcome)
+	assert.Equal(t, "boom", loaded[1].LastError)
+
+	// Recording outcomes is not a scan
+	last, err := store.LastScan()
+	require.NoError(t, err)
+	assert.True(t, last.Equal(scanned), last)
+}
+
+func TestScanner_Refresh(t *testing.T) {
+	dir := t.TempDir()
+	repos := cloneRepos(t, dir, 2)
+	store := NewStore(filepath.Join(dir, "repositories.json"))
+
+	var found int
+	scanner := NewScanner(filepath.Join(dir, "src"), filepath.Join(dir, "missing"))
+	scanner.OnFound = func(count int) { found = count }
+	scanned, err := scanner.Refresh(context.Background(), store)
+	require.NoError(t, err)
+	assert.Len(t, scanned, 2)
+	assert.Equal(t, 2, found)
+	last, err := store.LastScan()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), last, time.Minute)
+
+	// Tags survive the next scan
+	scanned[0].AddTags("work")
+	require.NoError(t, store.Save(scanned))
+	scanned, err = scanner.Refresh(context.Background(), store)
+	require.NoError(t, err)
+	for _, repo := range scanned {
+		if repo.Path == repos[0].Path {
+			assert.Equal(t, []string{"work"}, repo.Tags)
+		}
+	}
+
+	// Manually added repositories are kept, ignored ones left out
+	elsewhere := filepath.Join(dir, "elsewhere")
+	require.NoError(t, os.MkdirAll(elsewhere, 0755))
+	manual := cloneRepos(t, elsewhere, 1)[0]
+	manual.Manual = true
+	require.NoError(t, store.Save(append(scanned, manual)))
+	removed, err := store.Ignore(repos[1].Path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	scanned, err = scanner.Refresh(context.Background(), store)
+	require.NoError(t, err)
+	require.Len(t, scanned, 2)
+	assert.Equal(t, repos[0].Path, scanned[0].Path)
+	assert.Equal(t, manual.Path, scanned[1].Path)
+
+	// A manually added repository that is gone for now survives the scan
+	require.NoError(t, os.Rename(manual.Path, manual.Path+".moved"))
+	scanned, err = scanner.Refresh(context.Background(), store)
+	require.NoError(t, err)
+	assert.Len(t, scanned, 1)
+	_, broken, err := store.store.Inspect()
+	require.NoError(t, err)
+	require.Len(t, broken, 1)
+	assert.Equal(t, manual.Path, broken[0].Path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = scanner.Scan(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
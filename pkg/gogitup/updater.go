@@ -0,0 +1,234 @@
+package gogitup
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/trutx/gogitup/internal/git"
+	"github.com/trutx/gogitup/internal/pool"
+)
+
+// Statuses of a finished repository update
+const (
+	StatusUpdated = "updated"
+	StatusCurrent = "up to date"
+	StatusSkipped = "skipped"
+	StatusFailed  = "failed"
+)
+
+// Outcomes recorded in the store, see Result.Outcome
+const (
+	OutcomeUpdated = git.OutcomeUpdated
+	OutcomeSkipped = git.OutcomeSkipped
+	OutcomeFailed  = git.OutcomeFailed
+)
+
+// Result is the outcome of the update of a repository. A repository that was
+// skipped, e.g. because of uncommitted changes, has a Warning and no Err.
+type Result struct {
+	Path    string
+	Err     error
+	Warning string
+	// DiffStats are the colored diff stats of the pulled changes
+	DiffStats string
+	// Commits are the incoming commits, collected with Options.Commits
+	Commits []Commit
+	// LFSObjects and LFSBytes count the downloaded LFS objects
+	LFSObjects int
+	LFSBytes   int64
+	// Changed is set when the update moved HEAD
+	Changed bool
+	Elapsed time.Duration
+	// Dirty is set when the worktree has uncommitted changes
+	Dirty bool
+	// Behind counts the commits missing from HEAD after the update, it is
+	// -1 unless Options.Behind is set
+	Behind int
+	// Divergence describes the branch and the remote branch when the update
+	// failed because they diverged
+	Divergence *Divergence
+}
+
+// Status returns whether the repository was updated, already up to date,
+// skipped or failed
+func (r Result) Status() string {
+	switch {
+	case r.Err != nil:
+		return StatusFailed
+	case r.Warning != "":
+		return StatusSkipped
+	case r.Changed:
+		return StatusUpdated
+	}
+	return StatusCurrent
+}
+
+// Outcome returns the outcome recorded in the store, which does not tell
+// updated and up to date repositories apart
+func (r Result) Outcome() string {
+	switch {
+	case r.Err != nil:
+		return OutcomeFailed
+	case r.Warning != "":
+		return OutcomeSkipped
+	}
+	return OutcomeUpdated
+}
+
+// Options tune an Updater
+type Options struct {
+	// Threads is the number of concurrent updates, at least 1
+	Threads int
+	// Prune, when set, turns pruning on or off for every repository,
+	// whatever its settings say
+	Prune *bool
+	// Submodules turns on submodule updates for every repository, whatever
+	// its settings say
+	Submodules bool
+	// Commits collects the incoming commits of every updated repository
+	Commits bool
+	// Behind counts the commits missing from HEAD after every update
+	Behind bool
+	// Stash stashes local changes around the update instead of skipping
+	// repositories with uncommitted changes
+	Stash bool
+}
+
+// EventKind tells what an Event reports
+type EventKind int
+
+// Kinds of update events
+const (
+	// EventStarted is sent when the update of a repository begins
+	EventStarted EventKind = iota
+	// EventPhase is sent when an update enters a new phase
+	EventPhase
+	// EventFinished carries the result of an update
+	EventFinished
+)
+
+// Event reports the progress of the update of the repository at Path
+type Event struct {
+	Kind EventKind
+	Path string
+	// Phase is set for EventPhase, e.g. "fetching"
+	Phase string
+	// Result is set for EventFinished
+	Result Result
+}
+
+// Updater updates repositories with the settings of a config
+type Updater struct {
+	cfg  *Config
+	opts Options
+}
+
+// NewUpdater returns an updater that resolves the settings of every
+// repository from cfg, which may be nil
+func NewUpdater(cfg *Config, opts Options) *Updater {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	return &Updater{cfg: cfg, opts: opts}
+}
+
+// Start updates repos in the background and returns a channel of their
+// events, which is closed after the last EventFinished. The channel must be
+// drained. Once ctx is cancelled the running updates are aborted and the
+// remaining ones fail with the context error.
+func (u *Updater) Start(ctx context.Context, repos []Repository) <-chan Event {
+	events := make(chan Event, len(repos))
+	results := pool.Run(repos, u.opts.Threads, func(repo *Repository) Result {
+		if ctx.Err() == nil {
+			events <- Event{Kind: EventStarted, Path: repo.Path}
+			repo.OnPhase(func(phase string) {
+				events <- Event{Kind: EventPhase, Path: repo.Path, Phase: phase}
+			})
+			defer repo.OnPhase(nil)
+		}
+		return u.Update(ctx, repo)
+	})
+
+	go func() {
+		defer close(events)
+		for result := range results {
+			events <- Event{Kind: EventFinished, Path: result.Path, Result: result}
+		}
+	}()
+	return events
+}
+
+// Run updates repos and returns their results once all are done
+func (u *Updater) Run(ctx context.Context, repos []Repository) []Result {
+	results := make([]Result, 0, len(repos))
+	for event := range u.Start(ctx, repos) {
+		if event.Kind == EventFinished {
+			results = append(results, event.Result)
+		}
+	}
+	return results
+}
+
+// Update updates a single repository and returns its result
+func (u *Updater) Update(ctx context.Context, repo *Repository) (result Result) {
+	start := time.Now()
+	result.Path = repo.Path
+	result.Behind = -1
+	defer func() { result.Elapsed = time.Since(start) }()
+
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	settings, err := u.cfg.SettingsFor(repo.Path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if u.opts.Prune != nil {
+		settings.Prune = u.opts.Prune
+	}
+	if u.opts.Submodules {
+		settings.Submodules.Update = &u.opts.Submodules
+	}
+
+	update := repo.UpdateContext
+	if u.opts.Stash {
+		update = repo.UpdateWithStash
+	}
+	err = update(ctx, settings)
+
+	if u.opts.Behind {
+		if behind, err := repo.CommitsBehind(); err == nil {
+			result.Behind = behind
+		}
+	}
+
+	switch {
+	case errors.Is(err, ErrUncommittedChanges):
+		result.Warning = "worktree contains uncommitted changes"
+		result.Dirty = true
+	case errors.Is(err, ErrSkipped):
+		result.Warning = "skipped by configuration"
+	case err != nil:
+		result.Err = err
+		var diverged *DivergedError
+		if errors.As(err, &diverged) {
+			result.Divergence = &diverged.Divergence
+		}
+	default:
+		result.DiffStats = repo.DiffStats
+		oldHead, newHead := repo.Heads()
+		result.Changed = oldHead != newHead || repo.DiffStats != ""
+		if u.opts.Commits {
+			// The update itself succeeded, a failure to list the commits
+			// only leaves the list empty
+			result.Commits, _ = repo.IncomingCommits(0)
+		}
+		result.LFSObjects = repo.LFSObjects
+		result.LFSBytes = repo.LFSBytes
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package gogitup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/trutx/gogitup/internal/git"
+)
+
+// runGit runs a git command in dir
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-C", dir, "-c", "user.name=Jane", "-c", "user.email=jane@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// cloneRepos creates a remote with a commit and returns count clones of it
+// below dir, with a new commit pushed to the remote after the cloning
+func cloneRepos(t *testing.T, dir string, count int) []Repository {
+	t.Helper()
+
+	remote := filepath.Join(dir, "remote.git")
+	publish := filepath.Join(dir, "publish")
+	runGit(t, dir, "init", "-q", "--bare", remote)
+	runGit(t, dir, "clone", "-q", remote, publish)
+	require.NoError(t, os.WriteFile(filepath.Join(publish, "a.txt"), []byte("a"), 0644))
+	runGit(t, publish, "add", "a.txt")
+	runGit(t, publish, "commit", "-q", "-m", "Initial commit")
+	runGit(t, publish, "push", "-q", "origin", "HEAD")
+
+	var repos []Repository
+	for i := range count {
+		path := filepath.Join(dir, "src", string(rune('a'+i)))
+		runGit(t, dir, "clone", "-q", remote, path)
+		repo, err := git.OpenRepository(path)
+		require.NoError(t, err)
+		repos = append(repos, *repo)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(publish, "b.txt"), []byte("b"), 0644))
+	runGit(t, publish, "add", "b.txt")
+	runGit(t, publish, "commit", "-q", "-m", "Add b")
+	runGit(t, publish, "push", "-q", "origin", "HEAD")
+	return repos
+}
+
+func TestResult_Status(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  Result
+		status  string
+		outcome string
+	}{
+		{name: "failed", result: Result{Err: errors.New("boom")}, status: StatusFailed, outcome: OutcomeFailed},
+		{name: "skipped", result: Result{Warning: "dirty"}, status: StatusSkipped, outcome: OutcomeSkipped},
+		{name: "updated", result: Result{Changed: true}, status: StatusUpdated, outcome: OutcomeUpdated},
+		{name: "current", result: Result{}, status: StatusCurrent, outcome: OutcomeUpdated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, tt.result.Status())
+			assert.Equal(t, tt.outcome, tt.result.Outcome())
+		})
+	}
+}
+
+func TestUpdater_Start(t *testing.T) {
+	repos := cloneRepos(t, t.TempDir(), 3)
+	// A dirty worktree is skipped with a warning
+	require.NoError(t, os.WriteFile(filepath.Join(repos[2].Path, "a.txt"), []byte("changed"), 0644))
+
+	updater := NewUpdater(nil, Options{Threads: 2, Commits: true, Behind: true})
+	started := make(map[string]bool)
+	phases := make(map[string][]string)
+	results := make(map[string]Result)
+	for event := range updater.Start(context.Background(), repos) {
+		switch event.Kind {
+		case EventStarted:
+			started[event.Path] = true
+		case EventPhase:
+			assert.True(t, started[event.Path], "phase before start")
+			phases[event.Path] = append(phases[event.Path], event.Phase)
+		case EventFinished:
+			assert.NotContains(t, results, event.Path, "finished twice")
+			results[event.Path] = event.Result
+		}
+	}
+
+	require.Len(t, results, 3)
+	for _, repo := range repos[:2] {
+		result := results[repo.Path]
+		require.NoError(t, result.Err)
+		assert.Equal(t, StatusUpdated, result.Status())
+		require.Len(t, result.Commits, 1)
+		assert.Equal(t, "Add b", result.Commits[0].Subject)
+		assert.Zero(t, result.Behind)
+		assert.Positive(t, result.Elapsed)
+		assert.Contains(t, phases[repo.Path], git.PhaseFetching)
+	}
+
+	dirty := results[repos[2].Path]
+	assert.Equal(t, StatusSkipped, dirty.Status())
+	assert.True(t, dirty.Dirty)
+	assert.GreaterOrEqual(t, dirty.Behind, 0)
+}
+
+func TestUpdater_Cancelled(t *testing.T) {
+	repos := cloneRepos(t, t.TempDir(), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := NewUpdater(nil, Options{Threads: 1}).Run(ctx, repos)
+
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+		assert.Equal(t, -1, result.Behind)
+	}
+}
+
+func TestUpdater_Stash(t *testing.T) {
+	repos := cloneRepos(t, t.TempDir(), 1)
+	file := filepath.Join(repos[0].Path, "a.txt")
+	require.NoError(t, os.WriteFile(file, []byte("changed"), 0644))
+
+	result := NewUpdater(nil, Options{Stash: true}).Update(context.Background(), &repos[0])
+	require.NoError(t, result.Err)
+	assert.Equal(t, StatusUpdated, result.Status())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "changed", string(data), "local changes are restored")
+}
+
+func TestUpdater_Diverged(t *testing.T) {
+	repos := cloneRepos(t, t.TempDir(), 1)
+	runGit(t, repos[0].Path, "commit", "-q", "--allow-empty", "-m", "Local commit")
+
+	result := NewUpdater(nil, Options{}).Update(context.Background(), &repos[0])
+	assert.Equal(t, StatusFailed, result.Status())
+	var diverged *DivergedError
+	assert.True(t, errors.As(result.Err, &diverged))
+	require.NotNil(t, result.Divergence)
+	assert.Equal(t, 1, result.Divergence.LocalCount)
+	assert.Equal(t, 1, result.Divergence.RemoteCount)
+	require.Len(t, result.Divergence.Remote, 1)
+	assert.Equal(t, "Add b", result.Divergence.Remote[0].Subject)
+}